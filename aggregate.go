@@ -0,0 +1,129 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sort"
+)
+
+var (
+	// ErrDuplicateKeys is returned by AggregatePubKeys when duplicate keys
+	// are rejected and the passed set contains the same key more than once.
+	ErrDuplicateKeys = errors.New("aggregation set contains duplicate keys")
+
+	// ErrAggregateInfinity is returned by AggregatePubKeys when the keys
+	// sum to the point at infinity.
+	ErrAggregateInfinity = errors.New("aggregate key is the point at infinity")
+)
+
+// canonicalKey returns the compressed serialization of the passed key with its
+// coordinates reduced modulo the field prime, so that keys which represent the
+// same point always produce the same bytes no matter how they were obtained.
+func canonicalKey(key *PublicKey) [PubKeyBytesLenCompressed]byte {
+	P := S256().P
+	x := new(big.Int).Mod(key.X, P)
+	y := new(big.Int).Mod(key.Y, P)
+
+	var out [PubKeyBytesLenCompressed]byte
+	out[0] = pubkeyCompressed
+	if isOdd(y) {
+		out[0] |= 0x1
+	}
+	xBytes := x.Bytes()
+	copy(out[PubKeyBytesLenCompressed-len(xBytes):], xBytes)
+	return out
+}
+
+// HasDuplicateKeys returns whether or not the passed set contains the same
+// public key more than once.  Keys are compared by their canonical compressed
+// serialization, so the same point parsed from different formats, such as the
+// compressed and uncompressed ones, is detected as a duplicate.
+func HasDuplicateKeys(keys []*PublicKey) bool {
+	seen := make(map[[PubKeyBytesLenCompressed]byte]struct{}, len(keys))
+	for _, key := range keys {
+		k := canonicalKey(key)
+		if _, ok := seen[k]; ok {
+			return true
+		}
+		seen[k] = struct{}{}
+	}
+	return false
+}
+
+// CompareCompressed compares the compressed serializations of the two public
+// keys lexicographically and returns -1 when a sorts before b, 0 when they are
+// the same key, and +1 when a sorts after b.  The coordinates are reduced
+// modulo the field prime first, so equal points always compare equal.
+func CompareCompressed(a, b *PublicKey) int {
+	aBytes, bBytes := canonicalKey(a), canonicalKey(b)
+	return bytes.Compare(aBytes[:], bBytes[:])
+}
+
+// SortPubKeys sorts the passed keys in place in ascending lexicographic order
+// of their compressed serializations as defined by CompareCompressed.  This is
+// the ordering required by the KeySort algorithm of MuSig2 and commonly used
+// for the keys of multisig scripts.
+func SortPubKeys(keys []*PublicKey) {
+	serialized := make([][PubKeyBytesLenCompressed]byte, len(keys))
+	for i, key := range keys {
+		serialized[i] = canonicalKey(key)
+	}
+	sort.Sort(pubKeySorter{keys: keys, serialized: serialized})
+}
+
+// pubKeySorter implements sort.Interface to sort public keys along with their
+// compressed serializations, which are computed once up front.
+type pubKeySorter struct {
+	keys       []*PublicKey
+	serialized [][PubKeyBytesLenCompressed]byte
+}
+
+func (s pubKeySorter) Len() int {
+	return len(s.keys)
+}
+
+func (s pubKeySorter) Less(i, j int) bool {
+	return bytes.Compare(s.serialized[i][:], s.serialized[j][:]) < 0
+}
+
+func (s pubKeySorter) Swap(i, j int) {
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+	s.serialized[i], s.serialized[j] = s.serialized[j], s.serialized[i]
+}
+
+// AggregatePubKeys returns the naive aggregate of the passed keys, that is the
+// sum of all of them.  When rejectDuplicates is set, ErrDuplicateKeys is
+// returned for a set that contains the same key more than once.
+//
+// Naive aggregation is vulnerable to rogue-key attacks where a participant
+// chooses its key based on the keys of the others, so it must only be used
+// with keys that come with a proof of possession or in schemes which otherwise
+// account for it.  Duplicate keys are a common symptom of such misuse, which
+// is why callers are encouraged to reject them.
+func AggregatePubKeys(keys []*PublicKey, rejectDuplicates bool) (*PublicKey, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no keys to aggregate")
+	}
+	if rejectDuplicates && HasDuplicateKeys(keys) {
+		return nil, ErrDuplicateKeys
+	}
+
+	curve := S256()
+	x, y := new(big.Int), new(big.Int)
+	for _, key := range keys {
+		x, y = curve.Add(x, y, key.X, key.Y)
+	}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, ErrAggregateInfinity
+	}
+	return &PublicKey{
+		Curve: curve,
+		X:     new(big.Int).Set(x),
+		Y:     new(big.Int).Set(y),
+	}, nil
+}
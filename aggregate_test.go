@@ -0,0 +1,140 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+// TestHasDuplicateKeys ensures duplicate keys are detected regardless of the
+// format they were parsed from and that distinct keys are not reported.
+func TestHasDuplicateKeys(t *testing.T) {
+	curve := S256()
+	_, pub1 := PrivKeyFromBytes(curve, decodeHex("01"))
+	_, pub2 := PrivKeyFromBytes(curve, decodeHex("02"))
+	_, pub3 := PrivKeyFromBytes(curve, decodeHex("03"))
+
+	// The same key as pub2, parsed from its uncompressed serialization.
+	pub2Uncompressed, err := ParsePubKey(pub2.SerializeUncompressed(), curve)
+	if err != nil {
+		t.Fatalf("unexpected error parsing key: %v", err)
+	}
+
+	// The same key as pub3 with its coordinates offset by the field prime.
+	pub3Unreduced := &PublicKey{
+		Curve: curve,
+		X:     new(big.Int).Add(pub3.X, curve.P),
+		Y:     new(big.Int).Add(pub3.Y, curve.P),
+	}
+
+	tests := []struct {
+		name string
+		keys []*PublicKey
+		want bool
+	}{
+		{"empty", nil, false},
+		{"single", []*PublicKey{pub1}, false},
+		{"distinct", []*PublicKey{pub1, pub2, pub3}, false},
+		{"same pointer", []*PublicKey{pub1, pub2, pub1}, true},
+		{"different formats", []*PublicKey{pub2, pub1, pub2Uncompressed}, true},
+		{"unreduced coordinates", []*PublicKey{pub3, pub3Unreduced}, true},
+	}
+	for _, test := range tests {
+		if got := HasDuplicateKeys(test.keys); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// TestAggregatePubKeys ensures the naive aggregate is the sum of the keys and
+// that duplicates are only rejected when requested.
+func TestAggregatePubKeys(t *testing.T) {
+	curve := S256()
+	_, pub1 := PrivKeyFromBytes(curve, decodeHex("01"))
+	_, pub2 := PrivKeyFromBytes(curve, decodeHex("02"))
+	_, pub3 := PrivKeyFromBytes(curve, decodeHex("03"))
+	_, pub4 := PrivKeyFromBytes(curve, decodeHex("04"))
+	_, pub6 := PrivKeyFromBytes(curve, decodeHex("06"))
+
+	agg, err := AggregatePubKeys([]*PublicKey{pub1, pub2, pub3}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !agg.IsEqual(pub6) {
+		t.Fatalf("got %x, want %x", agg.SerializeCompressed(),
+			pub6.SerializeCompressed())
+	}
+
+	dups := []*PublicKey{pub2, pub2}
+	if _, err := AggregatePubKeys(dups, true); err != ErrDuplicateKeys {
+		t.Fatalf("got error %v, want %v", err, ErrDuplicateKeys)
+	}
+	agg, err = AggregatePubKeys(dups, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !agg.IsEqual(pub4) {
+		t.Fatalf("got %x, want %x", agg.SerializeCompressed(),
+			pub4.SerializeCompressed())
+	}
+
+	neg := &PublicKey{Curve: curve, X: pub1.X, Y: new(big.Int).Sub(curve.P, pub1.Y)}
+	_, err = AggregatePubKeys([]*PublicKey{pub1, neg}, true)
+	if err != ErrAggregateInfinity {
+		t.Fatalf("got error %v, want %v", err, ErrAggregateInfinity)
+	}
+	if _, err := AggregatePubKeys(nil, true); err == nil {
+		t.Fatal("expected error for an empty set")
+	}
+}
+
+// TestSortPubKeys ensures keys are sorted by their compressed serializations,
+// including keys that only differ in the parity byte.
+func TestSortPubKeys(t *testing.T) {
+	curve := S256()
+	keyFor := func(d []byte) *PublicKey {
+		_, pub := PrivKeyFromBytes(curve, d)
+		return pub
+	}
+	nMinus3 := new(big.Int).Sub(curve.N, big.NewInt(3)).Bytes()
+
+	keys := []*PublicKey{
+		keyFor(nMinus3),
+		keyFor(decodeHex("01")),
+		keyFor(decodeHex("03")),
+		keyFor(decodeHex("04")),
+		keyFor(decodeHex("05")),
+		keyFor(decodeHex("02")),
+		keyFor(decodeHex("01")),
+	}
+	want := []string{
+		"022f8bde4d1a07209355b4a7250a5c5128e88b84bddc619ab7cba8d569b240efe4",
+		"0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+		"0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+		"02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5",
+		"02e493dbf1c10d80f3581e4904930b1404cc6c13900ee0758474fa94abe8c4cd13",
+		"02f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+		"03f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+	}
+
+	SortPubKeys(keys)
+	for i, key := range keys {
+		if got := hex.EncodeToString(key.SerializeCompressed()); got != want[i] {
+			t.Errorf("#%d: got %s, want %s", i, got, want[i])
+		}
+	}
+
+	// The keys that only differ in the parity byte compare by it.
+	if CompareCompressed(keys[5], keys[6]) != -1 ||
+		CompareCompressed(keys[6], keys[5]) != 1 {
+
+		t.Error("keys differing in parity compare incorrectly")
+	}
+	if CompareCompressed(keys[1], keys[2]) != 0 {
+		t.Error("equal keys do not compare equal")
+	}
+}
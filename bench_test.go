@@ -53,6 +53,17 @@ func BenchmarkScalarBaseMult(b *testing.B) {
 	}
 }
 
+// BenchmarkScalarBaseMultCompressed benchmarks the secp256k1 curve
+// ScalarBaseMultCompressed function.
+func BenchmarkScalarBaseMultCompressed(b *testing.B) {
+	k := fromHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575")
+	curve := S256()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		curve.ScalarBaseMultCompressed(k.Bytes())
+	}
+}
+
 // BenchmarkScalarBaseMultLarge benchmarks the secp256k1 curve ScalarBaseMult
 // function with abnormally large k values.
 func BenchmarkScalarBaseMultLarge(b *testing.B) {
@@ -121,3 +132,114 @@ func BenchmarkFieldNormalize(b *testing.B) {
 		f.Normalize()
 	}
 }
+
+// benchValidateKeys returns a set of public keys for use in the public key
+// validation benchmarks.
+func benchValidateKeys(b *testing.B, n int) []*PublicKey {
+	keys := make([]*PublicKey, n)
+	for i := range keys {
+		priv, err := NewPrivateKey(S256())
+		if err != nil {
+			b.Fatalf("failed to generate key: %v", err)
+		}
+		keys[i] = priv.PubKey()
+	}
+	return keys
+}
+
+// BenchmarkValidatePublicKeys benchmarks validating a set of public keys with
+// ValidatePublicKeys.
+func BenchmarkValidatePublicKeys(b *testing.B) {
+	keys := benchValidateKeys(b, 100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ValidatePublicKeys(keys)
+	}
+}
+
+// BenchmarkValidatePublicKeysNaive benchmarks validating a set of public keys
+// with individual calls to IsOnCurve.
+func BenchmarkValidatePublicKeysNaive(b *testing.B) {
+	keys := benchValidateKeys(b, 100)
+	curve := S256()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, key := range keys {
+			curve.IsOnCurve(key.X, key.Y)
+		}
+	}
+}
+
+// BenchmarkFieldSquare benchmarks squaring a field value with SquareVal.
+func BenchmarkFieldSquare(b *testing.B) {
+	f := new(fieldVal).SetHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575")
+	var r fieldVal
+	for i := 0; i < b.N; i++ {
+		r.SquareVal(f)
+	}
+}
+
+// BenchmarkFieldSquareInterleaved benchmarks squaring a field value with the
+// interleaved squaring variant.
+func BenchmarkFieldSquareInterleaved(b *testing.B) {
+	f := new(fieldVal).SetHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575")
+	var r fieldVal
+	for i := 0; i < b.N; i++ {
+		r.squareValInterleaved(f)
+	}
+}
+
+// benchmarkSelectTable returns a table of 16 Jacobian points for use in the
+// table lookup benchmarks.
+func benchmarkSelectTable() []JacobianPoint {
+	curve := S256()
+	table := make([]JacobianPoint, 16)
+	table[0].SetAffine(curve.Gx, curve.Gy)
+	for i := 1; i < len(table); i++ {
+		table[i].AddNonConst(&table[i-1], &table[0])
+	}
+	return table
+}
+
+// selectedPoint stores the result of the table lookup benchmarks to prevent
+// the compiler from optimizing the lookups away.
+var selectedPoint *JacobianPoint
+
+// BenchmarkOddMultiples benchmarks computing the first 8 odd multiples of a
+// Jacobian point.
+func BenchmarkOddMultiples(b *testing.B) {
+	curve := S256()
+	var p JacobianPoint
+	p.SetAffine(curve.Gx, curve.Gy)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		OddMultiples(&p, 8)
+	}
+}
+
+// BenchmarkSelectPoint benchmarks looking up an entry in a table of 16 Jacobian
+// points in constant time.
+func BenchmarkSelectPoint(b *testing.B) {
+	table := benchmarkSelectTable()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		selectedPoint = selectPoint(table, i&15)
+	}
+}
+
+// BenchmarkSelectPointDirect benchmarks looking up an entry in a table of 16
+// Jacobian points by directly indexing it for comparison with the constant-time
+// lookup.
+func BenchmarkSelectPointDirect(b *testing.B) {
+	table := benchmarkSelectTable()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		selectedPoint = &table[i&15]
+	}
+}
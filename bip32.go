@@ -0,0 +1,164 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"runtime"
+	"sync"
+)
+
+// HardenedKeyStart is the index of the first hardened child key as defined by
+// BIP32.  Hardened child keys can only be derived from a private key.
+const HardenedKeyStart = 0x80000000 // 2^31
+
+// CKDpub derives the non-hardened child public key at the passed index from the
+// parent public key and chain code according to the public parent key to
+// public child key derivation function defined by BIP32.  It returns the child
+// public key along with its chain code.
+//
+// An error is returned for hardened indices since they can't be derived from a
+// public key, as well as in the extremely unlikely event the index does not
+// produce a valid child key, in which case the caller should proceed with the
+// next index.
+func CKDpub(parent *PublicKey, chainCode []byte, index uint32) (*PublicKey, []byte, error) {
+	if index >= HardenedKeyStart {
+		return nil, nil, fmt.Errorf("cannot derive hardened child %d "+
+			"from a public key", index-HardenedKeyStart)
+	}
+	if len(chainCode) != 32 {
+		return nil, nil, fmt.Errorf("chain code must be 32 bytes, got %d",
+			len(chainCode))
+	}
+
+	mac := hmac.New(sha512.New, chainCode)
+	return deriveChildPub(mac, parent, parent.SerializeCompressed(), index)
+}
+
+// deriveChildPub derives the non-hardened child public key at the passed index
+// using the passed HMAC-SHA512 keyed with the chain code of the parent public
+// key, which is also passed in its compressed serialization.  This allows the
+// HMAC key setup and the serialization of the parent to be shared among many
+// derivations.  The index must not be hardened.
+func deriveChildPub(mac hash.Hash, parent *PublicKey, parentSer []byte,
+	index uint32) (*PublicKey, []byte, error) {
+
+	// I = HMAC-SHA512(Key = c_par, Data = ser_P(K_par) || ser_32(i))
+	var indexBytes [4]byte
+	binary.BigEndian.PutUint32(indexBytes[:], index)
+	mac.Reset()
+	mac.Write(parentSer)
+	mac.Write(indexBytes[:])
+	ilr := mac.Sum(nil)
+
+	// The child key is K_i = parse_256(I_L)*G + K_par and is invalid when
+	// I_L >= N or the result is the point at infinity.
+	curve := S256()
+	il := ilr[:32]
+	if new(big.Int).SetBytes(il).Cmp(curve.N) >= 0 {
+		return nil, nil, errors.New("derived child key is invalid")
+	}
+	ilx, ily := curve.ScalarBaseMult(il)
+	childX, childY := curve.Add(ilx, ily, parent.X, parent.Y)
+	if childX.Sign() == 0 && childY.Sign() == 0 {
+		return nil, nil, errors.New("derived child key is invalid")
+	}
+
+	child := &PublicKey{Curve: curve, X: childX, Y: childY}
+	return child, ilr[32:], nil
+}
+
+// DeriveChildPubKeys derives count consecutive non-hardened child public keys
+// starting at the passed index from the parent public key and chain code.  It
+// returns the child public keys along with their chain codes such that the
+// entries at position i are the same as those returned by CKDpub for the index
+// startIndex+i.
+//
+// This is intended for scanning wallets which need to check many children of
+// the same extended public key.  The serialization of the parent key is only
+// computed once and the children are derived in parallel.
+//
+// An error is returned when any of the indices is hardened, and in the
+// extremely unlikely event that one of the indices does not produce a valid
+// child key.  In the latter case, the error identifies the index so the caller
+// can skip it.
+func DeriveChildPubKeys(xpub *PublicKey, chainCode []byte, startIndex uint32,
+	count int) ([]*PublicKey, [][]byte, error) {
+
+	if count < 0 {
+		return nil, nil, fmt.Errorf("invalid number of children %d", count)
+	}
+	if len(chainCode) != 32 {
+		return nil, nil, fmt.Errorf("chain code must be 32 bytes, got %d",
+			len(chainCode))
+	}
+	if count > 0 && uint64(startIndex)+uint64(count) > HardenedKeyStart {
+		return nil, nil, fmt.Errorf("cannot derive hardened children "+
+			"from a public key: indices %d through %d requested",
+			startIndex, uint64(startIndex)+uint64(count)-1)
+	}
+
+	keys := make([]*PublicKey, count)
+	chainCodes := make([][]byte, count)
+	errs := make([]error, count)
+	parentSer := xpub.SerializeCompressed()
+
+	// Split the indices among the workers in an interleaved fashion so the
+	// work is evenly distributed.  Each worker uses its own HMAC since they
+	// are not safe for concurrent use.
+	numWorkers := runtime.NumCPU()
+	if numWorkers > count {
+		numWorkers = count
+	}
+	var wg sync.WaitGroup
+	wg.Add(numWorkers)
+	for w := 0; w < numWorkers; w++ {
+		go func(w int) {
+			defer wg.Done()
+			mac := hmac.New(sha512.New, chainCode)
+			for i := w; i < count; i += numWorkers {
+				index := startIndex + uint32(i)
+				keys[i], chainCodes[i], errs[i] = deriveChildPub(mac,
+					xpub, parentSer, index)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, nil, fmt.Errorf("child %d: %v",
+				startIndex+uint32(i), err)
+		}
+	}
+	return keys, chainCodes, nil
+}
+
+// VerifyDerivationPath returns whether or not the public key derived from the
+// passed extended public key, which is made up of the public key and its chain
+// code, along the passed path of child indices is the expected public key.
+//
+// An error is returned when the path contains a hardened index, since those
+// can't be derived from a public key, or when any of the child keys along the
+// path is invalid.
+func VerifyDerivationPath(xpub *PublicKey, chainCode []byte, path []uint32,
+	expected *PublicKey) (bool, error) {
+
+	key := xpub
+	for _, index := range path {
+		var err error
+		key, chainCode, err = CKDpub(key, chainCode, index)
+		if err != nil {
+			return false, err
+		}
+	}
+	return key.IsEqual(expected), nil
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestVerifyDerivationPath ensures non-hardened paths derived from an extended
+// public key match the BIP32 test vectors and that hardened paths are
+// rejected.
+func TestVerifyDerivationPath(t *testing.T) {
+	// parsePubKey parses the passed hex encoded public key and fails the
+	// test on error.
+	parsePubKey := func(pubKeyHex string) *PublicKey {
+		t.Helper()
+		pubKey, err := ParsePubKey(decodeHex(pubKeyHex), S256())
+		if err != nil {
+			t.Fatalf("failed to parse public key %s: %v", pubKeyHex, err)
+		}
+		return pubKey
+	}
+
+	// The following are from BIP32 test vector 1.
+	//
+	// Chain m/0H.
+	xpub0H := parsePubKey("035a784662a4a20a65bf6aab9ae98a6c068a81c52e4b032c0fb5400c706cfccc56")
+	chainCode0H := decodeHex("47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141")
+	// Chain m/0H/1.
+	pubKey0H1 := parsePubKey("03501e454bf00751f24b1b489aa925215d66af2234e3891c3b21a52bedb3cd711c")
+	chainCode0H1 := decodeHex("2a7857631386ba23dacac34180dd1983734e444fdbf774041578e9b6adb37c19")
+	// Chain m/0H/1/2H.
+	xpub0H12H := parsePubKey("0357bfe1e341d01c69fe5654309956cbea516822fba8a601743a012a7896ee8dc2")
+	chainCode0H12H := decodeHex("04466b9cc8e161e966409ca52986c584f07e9dc81f735db683c3ff6ec7b1503f")
+	// Chain m/0H/1/2H/2/1000000000.
+	pubKey0H12H21000000000 := parsePubKey("022a471424da5e657499d1ff51cb43c47481a03b1e77f951fe64cec9f5a48f7011")
+
+	child, childChainCode, err := CKDpub(xpub0H, chainCode0H, 1)
+	if err != nil {
+		t.Fatalf("failed to derive child: %v", err)
+	}
+	if !child.IsEqual(pubKey0H1) {
+		t.Fatalf("mismatched child key - got %x, want %x",
+			child.SerializeCompressed(), pubKey0H1.SerializeCompressed())
+	}
+	if !bytes.Equal(childChainCode, chainCode0H1) {
+		t.Fatalf("mismatched child chain code - got %x, want %x",
+			childChainCode, chainCode0H1)
+	}
+
+	tests := []struct {
+		name      string
+		xpub      *PublicKey
+		chainCode []byte
+		path      []uint32
+		expected  *PublicKey
+		valid     bool
+		err       bool
+	}{{
+		name:      "m/0H/1/2H -> 2/1000000000",
+		xpub:      xpub0H12H,
+		chainCode: chainCode0H12H,
+		path:      []uint32{2, 1000000000},
+		expected:  pubKey0H12H21000000000,
+		valid:     true,
+	}, {
+		name:      "m/0H -> 1",
+		xpub:      xpub0H,
+		chainCode: chainCode0H,
+		path:      []uint32{1},
+		expected:  pubKey0H1,
+		valid:     true,
+	}, {
+		name:      "empty path",
+		xpub:      xpub0H,
+		chainCode: chainCode0H,
+		expected:  xpub0H,
+		valid:     true,
+	}, {
+		name:      "wrong expected key",
+		xpub:      xpub0H12H,
+		chainCode: chainCode0H12H,
+		path:      []uint32{2, 1000000001},
+		expected:  pubKey0H12H21000000000,
+	}, {
+		name:      "hardened element",
+		xpub:      xpub0H,
+		chainCode: chainCode0H,
+		path:      []uint32{1, HardenedKeyStart + 2},
+		expected:  xpub0H12H,
+		err:       true,
+	}, {
+		name:      "bad chain code length",
+		xpub:      xpub0H,
+		chainCode: chainCode0H[:31],
+		path:      []uint32{1},
+		expected:  pubKey0H1,
+		err:       true,
+	}}
+	for _, test := range tests {
+		valid, err := VerifyDerivationPath(test.xpub, test.chainCode,
+			test.path, test.expected)
+		if (err != nil) != test.err {
+			t.Errorf("%s: unexpected error - got %v, want error %v",
+				test.name, err, test.err)
+			continue
+		}
+		if valid != test.valid {
+			t.Errorf("%s: got valid %v, want %v", test.name, valid,
+				test.valid)
+		}
+	}
+}
+
+// TestDeriveChildPubKeys ensures deriving a batch of consecutive children
+// produces the same keys and chain codes as deriving them individually and
+// that invalid batches are rejected.
+func TestDeriveChildPubKeys(t *testing.T) {
+	// Chain m/0H from BIP32 test vector 1.
+	xpub, err := ParsePubKey(decodeHex("035a784662a4a20a65bf6aab9ae98a6c0"+
+		"68a81c52e4b032c0fb5400c706cfccc56"), S256())
+	if err != nil {
+		t.Fatalf("failed to parse public key: %v", err)
+	}
+	chainCode := decodeHex("47fdacbd0f1097043b78c63c20c34ef4ed9a111d98004" +
+		"7ad16282c7ae6236141")
+
+	tests := []struct {
+		name       string
+		startIndex uint32
+		count      int
+	}{
+		{"none", 0, 0},
+		{"single", 1, 1},
+		{"many", 0, 50},
+		{"up to the first hardened index", HardenedKeyStart - 5, 5},
+	}
+	for _, test := range tests {
+		keys, chainCodes, err := DeriveChildPubKeys(xpub, chainCode,
+			test.startIndex, test.count)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if len(keys) != test.count || len(chainCodes) != test.count {
+			t.Errorf("%s: got %d keys and %d chain codes, want %d",
+				test.name, len(keys), len(chainCodes), test.count)
+			continue
+		}
+		for i := 0; i < test.count; i++ {
+			index := test.startIndex + uint32(i)
+			wantKey, wantChainCode, err := CKDpub(xpub, chainCode, index)
+			if err != nil {
+				t.Fatalf("%s: failed to derive child %d: %v",
+					test.name, index, err)
+			}
+			if !keys[i].IsEqual(wantKey) {
+				t.Errorf("%s: mismatched key for child %d", test.name,
+					index)
+			}
+			if !bytes.Equal(chainCodes[i], wantChainCode) {
+				t.Errorf("%s: mismatched chain code for child %d",
+					test.name, index)
+			}
+		}
+	}
+
+	errTests := []struct {
+		name       string
+		chainCode  []byte
+		startIndex uint32
+		count      int
+	}{
+		{"negative count", chainCode, 0, -1},
+		{"crosses into hardened", chainCode, HardenedKeyStart - 5, 6},
+		{"hardened start", chainCode, HardenedKeyStart, 1},
+		{"bad chain code length", chainCode[:31], 0, 1},
+	}
+	for _, test := range errTests {
+		_, _, err := DeriveChildPubKeys(xpub, test.chainCode,
+			test.startIndex, test.count)
+		if err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
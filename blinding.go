@@ -0,0 +1,37 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+)
+
+// DeriveBlinding deterministically derives a blinding factor in the range
+// [1, N-1], where N is the order of the secp256k1 group, from the passed seed
+// and index.  This allows a wallet to regenerate the blinding factors used in
+// commitments from a single seed instead of storing each one of them.
+//
+// The blinding factor is computed as HMAC-SHA256 keyed with the seed over the
+// 8-byte big endian index followed by a 4-byte big endian counter that starts
+// at zero.  In the extremely unlikely event the result is not in the required
+// range, the counter is incremented and the process is repeated.
+func DeriveBlinding(seed []byte, index uint64) *big.Int {
+	N := S256().N
+
+	var msg [12]byte
+	binary.BigEndian.PutUint64(msg[:8], index)
+	for counter := uint32(0); ; counter++ {
+		binary.BigEndian.PutUint32(msg[8:], counter)
+		h := hmac.New(sha256.New, seed)
+		h.Write(msg[:])
+		blinding := new(big.Int).SetBytes(h.Sum(nil))
+		if blinding.Sign() != 0 && blinding.Cmp(N) < 0 {
+			return blinding
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"testing"
+)
+
+// TestDeriveBlinding ensures blinding factors are derived deterministically
+// from the seed and index, are in the range [1, N-1], and differ for different
+// seeds and indices.
+func TestDeriveBlinding(t *testing.T) {
+	seed := []byte("blinding seed")
+	N := S256().N
+
+	seen := make(map[string]uint64)
+	for index := uint64(0); index < 256; index++ {
+		blinding := DeriveBlinding(seed, index)
+		if blinding.Sign() <= 0 || blinding.Cmp(N) >= 0 {
+			t.Fatalf("index %d: blinding factor %x is not in [1, N-1]",
+				index, blinding)
+		}
+		if again := DeriveBlinding(seed, index); again.Cmp(blinding) != 0 {
+			t.Fatalf("index %d: blinding factor is not deterministic - "+
+				"got %x, want %x", index, again, blinding)
+		}
+		if other, ok := seen[blinding.String()]; ok {
+			t.Fatalf("index %d: blinding factor matches index %d",
+				index, other)
+		}
+		seen[blinding.String()] = index
+	}
+
+	if DeriveBlinding([]byte("other seed"), 0).Cmp(DeriveBlinding(seed, 0)) == 0 {
+		t.Fatal("different seeds derived the same blinding factor")
+	}
+
+	// The first attempt is HMAC-SHA256 over the index and a zero counter.
+	mac := hmac.New(sha256.New, seed)
+	mac.Write(decodeHex("000000000000000700000000"))
+	want := mac.Sum(nil)
+	got := paddedAppend(32, nil, DeriveBlinding(seed, 7).Bytes())
+	if !hmac.Equal(got, want) {
+		t.Fatalf("mismatched blinding factor - got %x, want %x", got,
+			want)
+	}
+}
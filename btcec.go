@@ -21,8 +21,11 @@ package secp256k1
 
 import (
 	"crypto/elliptic"
+	"errors"
+	"fmt"
 	"math/big"
 	"sync"
+	"sync/atomic"
 )
 
 var (
@@ -30,6 +33,10 @@ var (
 	// used to avoid needing to create it multiple times during the internal
 	// arithmetic.
 	fieldOne = new(fieldVal).SetInt(1)
+
+	// ErrPointNotOnCurve is returned by SafeScalarMult when the passed point
+	// is not on the secp256k1 curve.
+	ErrPointNotOnCurve = errors.New("point is not on the secp256k1 curve")
 )
 
 // KoblitzCurve supports a koblitz curve implementation that fits the ECC Curve
@@ -44,8 +51,12 @@ type KoblitzCurve struct {
 	// since it is calculated repeatedly.
 	byteSize int
 
-	// bytePoints
-	bytePoints *[32][256][3]fieldVal
+	// bytePoints holds the pre-computed points for each 8-bit window of a
+	// scalar used to accelerate scalar base multiplication.  It covers the
+	// least significant windows, which is all of them unless the package
+	// is built with the smallbasetable build tag.  The points for any
+	// remaining windows are computed on demand.
+	bytePoints [][256][3]fieldVal
 
 	// The next 6 values are used specifically for endomorphism
 	// optimizations in ScalarMult.
@@ -80,9 +91,9 @@ func (curve *KoblitzCurve) bigAffineToField(x, y *big.Int) (*fieldVal, *fieldVal
 	return x3, y3
 }
 
-// fieldJacobianToBigAffine takes a Jacobian point (x, y, z) as field values and
-// converts it to an affine point as big integers.
-func (curve *KoblitzCurve) fieldJacobianToBigAffine(x, y, z *fieldVal) (*big.Int, *big.Int) {
+// fieldJacobianToAffine converts the Jacobian point (x, y, z) to affine
+// coordinates in place, leaving z set to one and x and y normalized.
+func fieldJacobianToAffine(x, y, z *fieldVal) {
 	// Inversions are expensive and both point addition and point doubling
 	// are faster when working with points that have a z value of one.  So,
 	// if the point needs to be converted to affine, go ahead and normalize
@@ -97,6 +108,12 @@ func (curve *KoblitzCurve) fieldJacobianToBigAffine(x, y, z *fieldVal) (*big.Int
 	// Normalize the x and y values.
 	x.Normalize()
 	y.Normalize()
+}
+
+// fieldJacobianToBigAffine takes a Jacobian point (x, y, z) as field values and
+// converts it to an affine point as big integers.
+func (curve *KoblitzCurve) fieldJacobianToBigAffine(x, y, z *fieldVal) (*big.Int, *big.Int) {
+	fieldJacobianToAffine(x, y, z)
 
 	// Convert the field values for the now affine point to big.Ints.
 	x3, y3 := new(big.Int), new(big.Int)
@@ -108,7 +125,19 @@ func (curve *KoblitzCurve) fieldJacobianToBigAffine(x, y, z *fieldVal) (*big.Int
 // IsOnCurve returns boolean if the point (x,y) is on the curve.
 // Part of the elliptic.Curve interface. This function differs from the
 // crypto/elliptic algorithm since a = 0 not -3.
+//
+// Coordinates that are negative or not less than the field prime are rejected,
+// so there is exactly one accepted encoding of each point.  This also makes
+// elliptic.Unmarshal reject such non-canonical encodings.  The point at
+// infinity, which is represented by (0, 0), is not on the curve and is rejected
+// since 0 != 0^3 + 7.
 func (curve *KoblitzCurve) IsOnCurve(x, y *big.Int) bool {
+	if x.Sign() < 0 || x.Cmp(curve.P) >= 0 || y.Sign() < 0 ||
+		y.Cmp(curve.P) >= 0 {
+
+		return false
+	}
+
 	// Convert big ints to field values for faster arithmetic.
 	fx, fy := curve.bigAffineToField(x, y)
 
@@ -231,21 +260,16 @@ func (curve *KoblitzCurve) addZ1EqualsZ2(x1, y1, z1, x2, y2, x3, y3, z3 *fieldVa
 	// Calculate X3, Y3, and Z3 according to the intermediate elements
 	// breakdown above.
 	var a, b, c, d, e, f fieldVal
-	var negX1, negY1, negE, negX3 fieldVal
-	negX1.Set(x1).Negate(1)                // negX1 = -X1 (mag: 2)
-	negY1.Set(y1).Negate(1)                // negY1 = -Y1 (mag: 2)
-	a.Set(&negX1).Add(x2)                  // A = X2-X1 (mag: 3)
-	b.SquareVal(&a)                        // B = A^2 (mag: 1)
-	c.Set(&negY1).Add(y2)                  // C = Y2-Y1 (mag: 3)
-	d.SquareVal(&c)                        // D = C^2 (mag: 1)
-	e.Mul2(x1, &b)                         // E = X1*B (mag: 1)
-	negE.Set(&e).Negate(1)                 // negE = -E (mag: 2)
-	f.Mul2(x2, &b)                         // F = X2*B (mag: 1)
-	x3.Add2(&e, &f).Negate(3).Add(&d)      // X3 = D-E-F (mag: 5)
-	negX3.Set(x3).Negate(5).Normalize()    // negX3 = -X3 (mag: 1)
-	y3.Set(y1).Mul(f.Add(&negE)).Negate(3) // Y3 = -(Y1*(F-E)) (mag: 4)
-	y3.Add(e.Add(&negX3).Mul(&c))          // Y3 = C*(E-X3)+Y3 (mag: 5)
-	z3.Mul2(z1, &a)                        // Z3 = Z1*A (mag: 1)
+	a.Sub2(x2, x1)                 // A = X2-X1 (mag: 3)
+	b.SquareVal(&a)                // B = A^2 (mag: 1)
+	c.Sub2(y2, y1)                 // C = Y2-Y1 (mag: 3)
+	d.SquareVal(&c)                // D = C^2 (mag: 1)
+	e.Mul2(x1, &b)                 // E = X1*B (mag: 1)
+	f.Mul2(x2, &b)                 // F = X2*B (mag: 1)
+	x3.Sub2(&d, x3.Add2(&e, &f))   // X3 = D-E-F (mag: 3)
+	y3.Mul2(y1, f.Sub(&e))         // Y3 = Y1*(F-E) (mag: 1)
+	y3.Sub2(e.Sub(x3).Mul(&c), y3) // Y3 = C*(E-X3)-Y3 (mag: 3)
+	z3.Mul2(z1, &a)                // Z3 = Z1*A (mag: 1)
 
 	// Normalize the resulting field values to a magnitude of 1 as needed.
 	x3.Normalize()
@@ -453,12 +477,13 @@ func (curve *KoblitzCurve) addJacobian(x1, y1, z1, x2, y2, z2, x3, y3, z3 *field
 // interface.
 func (curve *KoblitzCurve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
 	// A point at infinity is the identity according to the group law for
-	// elliptic curve cryptography.  Thus, ∞ + P = P and P + ∞ = P.
+	// elliptic curve cryptography.  Thus, ∞ + P = P and P + ∞ = P.  Copies
+	// are returned so the result never aliases the arguments.
 	if x1.Sign() == 0 && y1.Sign() == 0 {
-		return x2, y2
+		return new(big.Int).Set(x2), new(big.Int).Set(y2)
 	}
 	if x2.Sign() == 0 && y2.Sign() == 0 {
-		return x1, y1
+		return new(big.Int).Set(x1), new(big.Int).Set(y1)
 	}
 
 	// Convert the affine coordinates from big integers to field values
@@ -600,7 +625,22 @@ func (curve *KoblitzCurve) doubleJacobian(x1, y1, z1, x3, y3, z3 *fieldVal) {
 }
 
 // Double returns 2*(x1,y1). Part of the elliptic.Curve interface.
+//
+// Doubling the point at infinity, which is represented by (0, 0), returns the
+// point at infinity.  A point with a y coordinate of zero would have order two,
+// but the group has prime order, so no such point is on the curve.  Doubling
+// (x, 0) for any x therefore has no meaning and also returns the point at
+// infinity, which is the result of the vertical tangent the group law uses for
+// points of order two.
 func (curve *KoblitzCurve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
+	// A point at infinity is the identity according to the group law for
+	// elliptic curve cryptography.  Thus, 2*∞ = ∞.
+	if x1.Sign() == 0 && y1.Sign() == 0 {
+		return new(big.Int), new(big.Int)
+	}
+
+	// The tangent at a point with y = 0 is vertical, so the doubling
+	// formulas would divide by zero.  See the comment above.
 	if y1.Sign() == 0 {
 		return new(big.Int), new(big.Int)
 	}
@@ -617,6 +657,24 @@ func (curve *KoblitzCurve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
 	return curve.fieldJacobianToBigAffine(fx3, fy3, fz3)
 }
 
+// Negate returns -(x1,y1), which is (x1, P-y1).  Negating the point at
+// infinity, which is represented by (0, 0), returns the point at infinity.  The
+// result never aliases the arguments.
+func (curve *KoblitzCurve) Negate(x1, y1 *big.Int) (*big.Int, *big.Int) {
+	if x1.Sign() == 0 && y1.Sign() == 0 {
+		return new(big.Int), new(big.Int)
+	}
+	y := new(big.Int).Sub(curve.P, y1)
+	return new(big.Int).Set(x1), y.Mod(y, curve.P)
+}
+
+// Sub returns (x1,y1)-(x2,y2), which is (x1,y1)+(-(x2,y2)).  Subtracting a
+// point from itself returns the point at infinity (0, 0).
+func (curve *KoblitzCurve) Sub(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	negX2, negY2 := curve.Negate(x2, y2)
+	return curve.Add(x1, y1, negX2, negY2)
+}
+
 // splitK returns a balanced length-two representation of k and their signs.
 // This is algorithm 3.74 from [GECC].
 //
@@ -753,11 +811,78 @@ func NAF(k []byte) ([]byte, []byte) {
 	return retPos[1:], retNeg[1:]
 }
 
-// ScalarMult returns k*(Bx, By) where k is a big endian integer.
-// Part of the elliptic.Curve interface.
-func (curve *KoblitzCurve) ScalarMult(Bx, By *big.Int, k []byte) (*big.Int, *big.Int) {
+// ScalarDigits splits the big endian integer k into its base 2^windowBits
+// digits as is needed by windowed multiplication algorithms.  The digits are
+// returned in little endian order, meaning the first digit is the least
+// significant one, so that k is the sum of digits[i] * 2^(windowBits*i).
+//
+// The number of digits returned is the number needed to hold every bit of k,
+// including any leading zero bits.  The window size must be between 1 and 32
+// bits inclusive, otherwise nil is returned.
+func ScalarDigits(k []byte, windowBits uint) []uint {
+	if windowBits < 1 || windowBits > 32 {
+		return nil
+	}
+
+	numBits := uint(len(k)) * 8
+	digits := make([]uint, (numBits+windowBits-1)/windowBits)
+	for bit := uint(0); bit < numBits; bit++ {
+		// Bit 0 is the least significant bit of the last byte.
+		if k[len(k)-1-int(bit/8)]>>(bit%8)&1 == 1 {
+			digits[bit/windowBits] |= 1 << (bit % windowBits)
+		}
+	}
+	return digits
+}
+
+// WNAF returns the width-w Non-Adjacent Form (wNAF) of the big endian integer
+// k, which generalizes NAF to a wider window.  Every nonzero digit is an odd
+// integer in the range (-2^(width-1), 2^(width-1)) and any width consecutive
+// digits contain at most one nonzero digit, so on average only 1/(width+1) of
+// the digits are nonzero, compared to 1/3 for NAF.  This allows windowed
+// multiplication algorithms to use a table of only the odd multiples of the
+// point, such as those returned by OddMultiples.  This is algorithm 3.35 from
+// [GECC].
+//
+// Like ScalarDigits, the digits are returned in little endian order, meaning
+// k is the sum of digits[i] * 2^i.  The most significant digit is nonzero, so
+// an empty slice is returned when k is zero.  The width must be between 2 and 8
+// bits inclusive, otherwise nil is returned.  A width of 2 produces the same
+// representation as NAF.
+func WNAF(k []byte, width uint) []int8 {
+	if width < 2 || width > 8 {
+		return nil
+	}
+
+	window := int64(1) << width
+	mask := big.NewInt(window - 1)
+	kk := new(big.Int).SetBytes(k)
+	digits := make([]int8, 0, kk.BitLen()+1)
+	var d big.Int
+	for kk.Sign() > 0 {
+		var digit int64
+		if kk.Bit(0) == 1 {
+			// digit = k mods 2^width, which is odd since k is.
+			digit = d.And(kk, mask).Int64()
+			if digit >= window/2 {
+				digit -= window
+			}
+			kk.Sub(kk, d.SetInt64(digit))
+		}
+		digits = append(digits, int8(digit))
+		kk.Rsh(kk, 1)
+	}
+	return digits
+}
+
+// scalarMultJacobian multiplies the passed Jacobian point (p1x, p1y, p1z) by
+// the big endian integer k and stores the result in (qx, qy, qz).  That is to
+// say (qx, qy, qz) = k*(p1x, p1y, p1z).
+func (curve *KoblitzCurve) scalarMultJacobian(k []byte, p1x, p1y, p1z, qx, qy, qz *fieldVal) {
 	// Point Q = ∞ (point at infinity).
-	qx, qy, qz := new(fieldVal), new(fieldVal), new(fieldVal)
+	qx.SetInt(0)
+	qy.SetInt(0)
+	qz.SetInt(0)
 
 	// Decompose K into k1 and k2 in order to halve the number of EC ops.
 	// See Algorithm 3.74 in [GECC].
@@ -767,16 +892,15 @@ func (curve *KoblitzCurve) ScalarMult(Bx, By *big.Int, k []byte) (*big.Int, *big
 	//   k * P = k1 * P + k2 * ϕ(P)
 	//
 	// P1 below is P in the equation, P2 below is ϕ(P) in the equation
-	p1x, p1y := curve.bigAffineToField(Bx, By)
 	p1yNeg := new(fieldVal).NegateVal(p1y, 1)
-	p1z := new(fieldVal).SetInt(1)
 
-	// NOTE: ϕ(x,y) = (βx,y).  The Jacobian z coordinate is 1, so this math
-	// goes through.
+	// NOTE: ϕ(x,y) = (βx,y).  Since X = x*z^2 in Jacobian coordinates,
+	// scaling X by β scales the affine x by β as well, so the z coordinate
+	// carries over unchanged.
 	p2x := new(fieldVal).Mul2(p1x, curve.beta)
 	p2y := new(fieldVal).Set(p1y)
 	p2yNeg := new(fieldVal).NegateVal(p2y, 1)
-	p2z := new(fieldVal).SetInt(1)
+	p2z := new(fieldVal).Set(p1z)
 
 	// Flip the positive and negative values of the points as needed
 	// depending on the signs of k1 and k2.  As mentioned in the equation
@@ -852,42 +976,496 @@ func (curve *KoblitzCurve) ScalarMult(Bx, By *big.Int, k []byte) (*big.Int, *big
 			k2ByteNeg <<= 1
 		}
 	}
+}
+
+// ScalarMultMethod identifies the algorithm used by ScalarMult.
+type ScalarMultMethod int32
+
+const (
+	// ScalarMultGLV splits the scalar into two halves via the GLV
+	// endomorphism and multiplies using their NAF representations.  It is
+	// the default since it is the fastest method.
+	ScalarMultGLV ScalarMultMethod = iota
+
+	// ScalarMultPlain multiplies using plain left-to-right double-and-add
+	// over the bits of the scalar.  It is considerably slower and intended
+	// for differential testing and as a fallback for callers who suspect
+	// an edge case in the GLV method.
+	ScalarMultPlain
+)
+
+// String returns the name of the method in a human-readable form.
+func (m ScalarMultMethod) String() string {
+	switch m {
+	case ScalarMultGLV:
+		return "GLV"
+	case ScalarMultPlain:
+		return "Plain"
+	default:
+		return fmt.Sprintf("Unknown ScalarMultMethod (%d)", int32(m))
+	}
+}
+
+// scalarMultMethod is the method used by ScalarMult.  It is accessed
+// atomically so that it may be changed while other goroutines multiply.
+var scalarMultMethod int32
+
+// SetScalarMultMethod sets the algorithm used by ScalarMult for all curves.
+// It is safe to call concurrently with ScalarMult, although it is intended to
+// be set once during initialization.  Only ScalarMult is affected, since the
+// other multiplication functions either use the precomputed table for the base
+// point or are internal to verification.
+func SetScalarMultMethod(m ScalarMultMethod) {
+	atomic.StoreInt32(&scalarMultMethod, int32(m))
+}
+
+// GetScalarMultMethod returns the algorithm currently used by ScalarMult.
+func GetScalarMultMethod() ScalarMultMethod {
+	return ScalarMultMethod(atomic.LoadInt32(&scalarMultMethod))
+}
+
+// scalarMultPlainJacobian multiplies the passed Jacobian point (p1x, p1y, p1z)
+// by the big endian integer k using plain left-to-right double-and-add and
+// stores the result in (qx, qy, qz).  It produces the same results as
+// scalarMultJacobian.
+func (curve *KoblitzCurve) scalarMultPlainJacobian(k []byte, p1x, p1y, p1z, qx, qy, qz *fieldVal) {
+	// Point Q = ∞ (point at infinity).
+	qx.SetInt(0)
+	qy.SetInt(0)
+	qz.SetInt(0)
+
+	for _, b := range curve.moduloReduce(k) {
+		for j := 7; j >= 0; j-- {
+			// Q = 2 * Q
+			curve.doubleJacobian(qx, qy, qz, qx, qy, qz)
+			if b>>uint(j)&1 == 1 {
+				curve.addJacobian(qx, qy, qz, p1x, p1y, p1z,
+					qx, qy, qz)
+			}
+		}
+	}
+}
+
+// ScalarMult returns k*(Bx, By) where k is a big endian integer.  The
+// algorithm used is selected with SetScalarMultMethod.
+// Part of the elliptic.Curve interface.
+func (curve *KoblitzCurve) ScalarMult(Bx, By *big.Int, k []byte) (*big.Int, *big.Int) {
+	p1x, p1y := curve.bigAffineToField(Bx, By)
+	p1z := new(fieldVal).SetInt(1)
+
+	qx, qy, qz := new(fieldVal), new(fieldVal), new(fieldVal)
+	if GetScalarMultMethod() == ScalarMultPlain {
+		curve.scalarMultPlainJacobian(k, p1x, p1y, p1z, qx, qy, qz)
+	} else {
+		curve.scalarMultJacobian(k, p1x, p1y, p1z, qx, qy, qz)
+	}
 
 	// Convert the Jacobian coordinate field values back to affine big.Ints.
 	return curve.fieldJacobianToBigAffine(qx, qy, qz)
 }
 
+// SafeScalarMult returns k*(Bx, By) where k is a big endian integer like
+// ScalarMult, but returns ErrPointNotOnCurve instead when (Bx, By) is not a
+// point on the curve.
+//
+// The formulas used for scalar multiplication do not involve the b parameter
+// of the curve equation, so ScalarMult happily computes multiples of a point
+// on a different curve, such as the quadratic twist, which may have a group
+// order with small factors.  An attacker who gets such a point multiplied by
+// a private key, as in ECDH with an untrusted public key, learns the key
+// modulo those factors from the result.  The result is on the curve if and
+// only if the input is, so checking the input up front is sufficient to
+// prevent such invalid curve attacks.
+func (curve *KoblitzCurve) SafeScalarMult(Bx, By *big.Int, k []byte) (x, y *big.Int, err error) {
+	if Bx == nil || By == nil || !curve.IsOnCurve(Bx, By) {
+		return nil, nil, ErrPointNotOnCurve
+	}
+	x, y = curve.ScalarMult(Bx, By, k)
+	return x, y, nil
+}
+
 // ScalarBaseMult returns k*G where G is the base point of the group and k is a
 // big endian integer.
 // Part of the elliptic.Curve interface.
 func (curve *KoblitzCurve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	qx, qy, qz := new(fieldVal), new(fieldVal), new(fieldVal)
+	curve.scalarBaseMultJacobian(k, qx, qy, qz)
+	return curve.fieldJacobianToBigAffine(qx, qy, qz)
+}
+
+// fieldJacobianToCompressed converts the Jacobian point (x, y, z) to affine
+// coordinates and returns it serialized in the 33-byte compressed format
+// without converting the coordinates to big integers.
+func fieldJacobianToCompressed(x, y, z *fieldVal) [PubKeyBytesLenCompressed]byte {
+	fieldJacobianToAffine(x, y, z)
+
+	var b [PubKeyBytesLenCompressed]byte
+	b[0] = pubkeyCompressed
+	if y.IsOdd() {
+		b[0] |= 0x1
+	}
+	var xBytes [32]byte
+	x.PutBytes(&xBytes)
+	copy(b[1:], xBytes[:])
+	return b
+}
+
+// ScalarBaseMultCompressed returns k*G, where G is the base point of the group
+// and k is a big endian integer, serialized in the 33-byte compressed format.
+// It is equivalent to serializing the result of ScalarBaseMult with
+// SerializeCompressed, but avoids converting the point to big integers.
+func (curve *KoblitzCurve) ScalarBaseMultCompressed(k []byte) [PubKeyBytesLenCompressed]byte {
+	qx, qy, qz := new(fieldVal), new(fieldVal), new(fieldVal)
+	curve.scalarBaseMultJacobian(k, qx, qy, qz)
+	return fieldJacobianToCompressed(qx, qy, qz)
+}
+
+// ScalarMultCompressed returns k*(Bx, By), where k is a big endian integer,
+// serialized in the 33-byte compressed format.  It is equivalent to serializing
+// the result of ScalarMult with SerializeCompressed, but avoids converting the
+// point to big integers.
+func (curve *KoblitzCurve) ScalarMultCompressed(Bx, By *big.Int, k []byte) [PubKeyBytesLenCompressed]byte {
+	p1x, p1y := curve.bigAffineToField(Bx, By)
+	p1z := new(fieldVal).SetInt(1)
+
+	qx, qy, qz := new(fieldVal), new(fieldVal), new(fieldVal)
+	curve.scalarMultJacobian(k, p1x, p1y, p1z, qx, qy, qz)
+	return fieldJacobianToCompressed(qx, qy, qz)
+}
+
+// scalarBaseMultJacobian multiplies the base point G by the big endian integer
+// k and stores the result in Jacobian coordinates in (qx, qy, qz).  The result
+// is left in Jacobian coordinates so callers that do not need the affine point
+// can avoid the inversion required to convert it.
+func (curve *KoblitzCurve) scalarBaseMultJacobian(k []byte, qx, qy, qz *fieldVal) {
 	newK := curve.moduloReduce(k)
-	diff := len(curve.bytePoints) - len(newK)
+	diff := curve.byteSize - len(newK)
 
 	// Point Q = ∞ (point at infinity).
-	qx, qy, qz := new(fieldVal), new(fieldVal), new(fieldVal)
+	qx.SetInt(0)
+	qy.SetInt(0)
+	qz.SetInt(0)
 
-	// curve.bytePoints has all 256 byte points for each 8-bit window. The
-	// strategy is to add up the byte points. This is best understood by
-	// expressing k in base-256 which it already sort of is.
+	// curve.bytePoints has all 256 byte points for each 8-bit window it
+	// covers. The strategy is to add up the byte points. This is best
+	// understood by expressing k in base-256 which it already sort of is.
 	// Each "digit" in the 8-bit window can be looked up using bytePoints
 	// and added together.
+	firstWindow := curve.byteSize - len(curve.bytePoints)
 	for i, byteVal := range newK {
-		p := curve.bytePoints[diff+i][byteVal]
+		if diff+i < firstWindow {
+			continue
+		}
+		p := curve.bytePoints[diff+i-firstWindow][byteVal]
 		curve.addJacobian(qx, qy, qz, &p[0], &p[1], &p[2], qx, qy, qz)
 	}
-	return curve.fieldJacobianToBigAffine(qx, qy, qz)
+
+	// Compute the contribution of any windows that are not covered by the
+	// pre-computed table on demand.
+	if diff < firstWindow {
+		curve.addUncoveredWindows(newK[:firstWindow-diff], qx, qy, qz)
+	}
+}
+
+// addUncoveredWindows adds k*2^(8*w)*G to the Jacobian point (qx, qy, qz),
+// where k is the big endian integer formed by the most significant bytes of a
+// scalar that are not covered by the pre-computed byte points and w is the
+// number of windows that are covered.
+//
+// The base point is doubled 8*w times to reach the first uncovered window and
+// the result is then accumulated with the double-and-add method working from
+// the least significant bit of k upwards.
+func (curve *KoblitzCurve) addUncoveredWindows(k []byte, qx, qy, qz *fieldVal) {
+	bx, by := curve.bigAffineToField(curve.Gx, curve.Gy)
+	bz := new(fieldVal).SetInt(1)
+	for i := 0; i < 8*len(curve.bytePoints); i++ {
+		curve.doubleJacobian(bx, by, bz, bx, by, bz)
+	}
+
+	for i := len(k) - 1; i >= 0; i-- {
+		for j := uint(0); j < 8; j++ {
+			if k[i]>>j&1 == 1 {
+				curve.addJacobian(qx, qy, qz, bx, by, bz, qx, qy,
+					qz)
+			}
+			curve.doubleJacobian(bx, by, bz, bx, by, bz)
+		}
+	}
+}
+
+// DualBaseMult returns both k*G, where G is the base point of the group, and
+// k*(Px, Py) where k is a big endian integer.  This is useful for protocols,
+// such as proofs of discrete log equality, that need the multiples of two
+// different points by the same scalar.
+//
+// The scalar is only reduced once for both multiplications.  The decomposition
+// and NAF of it are only computed for the multiplication of (Px, Py), since k*G
+// makes use of the precomputed byte points instead, so the cost is otherwise
+// the same as calling ScalarBaseMult and ScalarMult separately, which produce
+// identical results.
+func (curve *KoblitzCurve) DualBaseMult(k []byte, Px, Py *big.Int) (gx, gy, px, py *big.Int) {
+	newK := curve.moduloReduce(k)
+
+	qx, qy, qz := new(fieldVal), new(fieldVal), new(fieldVal)
+	curve.scalarBaseMultJacobian(newK, qx, qy, qz)
+	gx, gy = curve.fieldJacobianToBigAffine(qx, qy, qz)
+
+	p1x, p1y := curve.bigAffineToField(Px, Py)
+	p1z := new(fieldVal).SetInt(1)
+	curve.scalarMultJacobian(newK, p1x, p1y, p1z, qx, qy, qz)
+	px, py = curve.fieldJacobianToBigAffine(qx, qy, qz)
+	return gx, gy, px, py
+}
+
+// VerifyEquation returns s*G - e*(Px, Py), where G is the base point of the
+// group and s and e are big endian integers.  This is the equation at the heart
+// of Schnorr signature verification, where the result is the nonce point R
+// when the signature is valid, and it is exposed for reuse by related
+// protocols.  The point at infinity is returned as (0, 0).
+//
+// Both terms are accumulated in a single interleaved double-and-add loop, also
+// known as Shamir's trick, so they share the doublings.  Both scalars are first
+// decomposed into halves of around 128 bits with the endomorphism.  The halves
+// of e are added bit by bit from their NAF on the negated point, while the
+// halves of s are added a byte at a time from the precomputed multiples of G
+// once the doublings for the bits of the byte are done.
+func (curve *KoblitzCurve) VerifyEquation(s, e []byte, Px, Py *big.Int) (rx, ry *big.Int) {
+	// Decompose both scalars in order to halve the number of doublings.
+	// See Algorithm 3.74 in [GECC].
+	s1, s2, signS1, signS2 := curve.splitK(curve.moduloReduce(s))
+	e1, e2, signE1, signE2 := curve.splitK(curve.moduloReduce(e))
+
+	// -e*P = e1*(-P) + e2*ϕ(-P) where -P = (Px, -Py) and ϕ(x,y) = (βx,y).
+	p1x, p1yNeg := curve.bigAffineToField(Px, Py)
+	p1y := new(fieldVal).NegateVal(p1yNeg, 1).Normalize()
+	p1z := new(fieldVal).SetInt(1)
+	p2x := new(fieldVal).Mul2(p1x, curve.beta)
+	p2y := new(fieldVal).Set(p1y)
+	p2yNeg := new(fieldVal).Set(p1yNeg)
+	p2z := new(fieldVal).SetInt(1)
+	if signE1 == -1 {
+		p1y, p1yNeg = p1yNeg, p1y
+	}
+	if signE2 == -1 {
+		p2y, p2yNeg = p2yNeg, p2y
+	}
+	e1PosNAF, e1NegNAF := NAF(e1)
+	e2PosNAF, e2NegNAF := NAF(e2)
+
+	// The least significant window of the pre-computed byte points holds
+	// the multiples of G itself.  The multiples of ϕ(G) are derived from
+	// them by scaling X by β, which leaves the z coordinate unchanged.
+	gPoints := &curve.bytePoints[len(curve.bytePoints)-1]
+
+	m := len(s1)
+	for _, digits := range [][]byte{s2, e1PosNAF, e2PosNAF} {
+		if m < len(digits) {
+			m = len(digits)
+		}
+	}
+
+	// byteAt returns byte i of the passed big endian digits when they are
+	// padded with leading zeros to m bytes.
+	byteAt := func(digits []byte, i int) byte {
+		if i < m-len(digits) {
+			return 0
+		}
+		return digits[i-(m-len(digits))]
+	}
+
+	// Point Q = ∞ (point at infinity).
+	var qx, qy, qz, tx, ty fieldVal
+	for i := 0; i < m; i++ {
+		e1BytePos, e1ByteNeg := byteAt(e1PosNAF, i), byteAt(e1NegNAF, i)
+		e2BytePos, e2ByteNeg := byteAt(e2PosNAF, i), byteAt(e2NegNAF, i)
+		for j := 7; j >= 0; j-- {
+			// Q = 2 * Q
+			curve.doubleJacobian(&qx, &qy, &qz, &qx, &qy, &qz)
+
+			if e1BytePos&0x80 == 0x80 {
+				curve.addJacobian(&qx, &qy, &qz, p1x, p1y, p1z,
+					&qx, &qy, &qz)
+			} else if e1ByteNeg&0x80 == 0x80 {
+				curve.addJacobian(&qx, &qy, &qz, p1x, p1yNeg, p1z,
+					&qx, &qy, &qz)
+			}
+
+			if e2BytePos&0x80 == 0x80 {
+				curve.addJacobian(&qx, &qy, &qz, p2x, p2y, p2z,
+					&qx, &qy, &qz)
+			} else if e2ByteNeg&0x80 == 0x80 {
+				curve.addJacobian(&qx, &qy, &qz, p2x, p2yNeg, p2z,
+					&qx, &qy, &qz)
+			}
+			e1BytePos <<= 1
+			e1ByteNeg <<= 1
+			e2BytePos <<= 1
+			e2ByteNeg <<= 1
+		}
+
+		// The bytes of the halves of s are added after the doublings for
+		// the bits of this byte, so they are only scaled by the doublings
+		// of the remaining bytes.
+		if b := byteAt(s1, i); b != 0 {
+			p := &gPoints[b]
+			ty.Set(&p[1])
+			if signS1 == -1 {
+				ty.Normalize().Negate(1)
+			}
+			curve.addJacobian(&qx, &qy, &qz, &p[0], &ty, &p[2], &qx,
+				&qy, &qz)
+		}
+		if b := byteAt(s2, i); b != 0 {
+			p := &gPoints[b]
+			tx.Mul2(&p[0], curve.beta)
+			ty.Set(&p[1])
+			if signS2 == -1 {
+				ty.Normalize().Negate(1)
+			}
+			curve.addJacobian(&qx, &qy, &qz, &tx, &ty, &p[2], &qx,
+				&qy, &qz)
+		}
+	}
+
+	return curve.fieldJacobianToBigAffine(&qx, &qy, &qz)
+}
+
+// MarshalCompressed returns the point (x, y) serialized in the 33-byte SEC1
+// compressed format, which is the x coordinate prefixed with 0x02 when the y
+// coordinate is even and 0x03 when it is odd.  The point must be on the curve.
+// It is the compressed counterpart of elliptic.Marshal.
+func (curve *KoblitzCurve) MarshalCompressed(x, y *big.Int) []byte {
+	b := make([]byte, PubKeyBytesLenCompressed)
+	b[0] = pubkeyCompressed | byte(y.Bit(0))
+	var xBytes [32]byte
+	copy(b[1:], bigIntToBytes32(x, &xBytes)[:])
+	return b
+}
+
+// UnmarshalCompressed parses a point serialized in the 33-byte SEC1 compressed
+// format by MarshalCompressed and returns its coordinates.  Nil coordinates
+// are returned when the data is not 33 bytes long, the prefix is neither 0x02
+// nor 0x03, the x coordinate is not less than the field prime, or there is no
+// point on the curve with the x coordinate.
+//
+// The y coordinate is recovered with field arithmetic instead of the generic
+// big integer exponentiation used by elliptic.UnmarshalCompressed.
+func (curve *KoblitzCurve) UnmarshalCompressed(data []byte) (x, y *big.Int) {
+	if len(data) != PubKeyBytesLenCompressed {
+		return nil, nil
+	}
+	if data[0]&^0x1 != pubkeyCompressed {
+		return nil, nil
+	}
+	var fx, fy fieldVal
+	if !fx.SetByteSliceChecked(data[1:]) {
+		return nil, nil
+	}
+	if !decompressYField(&fx, data[0]&0x1 == 0x1, &fy) {
+		return nil, nil
+	}
+
+	xBytes, yBytes := fx.Bytes32(), fy.Bytes32()
+	return new(big.Int).SetBytes(xBytes[:]), new(big.Int).SetBytes(yBytes[:])
 }
 
-// QPlus1Div4 returns the Q+1/4 constant for the curve for use in calculating
-// square roots via exponention.
+// DecompressY returns the y coordinate with the requested parity of the point
+// on the curve with the passed x coordinate.  The returned boolean is false,
+// and the y coordinate nil, when x is not in the range [0, P-1] or there is no
+// point on the curve with it, which includes x = 0.
+//
+// This is useful for x-only public keys such as those of BIP340, where the
+// parity of y is implied.  The square root is calculated with field arithmetic
+// instead of the slower big.Int.ModSqrt.
+func (curve *KoblitzCurve) DecompressY(x *big.Int, yOdd bool) (*big.Int, bool) {
+	if x.Sign() < 0 || x.Cmp(curve.P) >= 0 {
+		return nil, false
+	}
+	var fx, fy fieldVal
+	fx.SetByteSlice(x.Bytes())
+	if !decompressYField(&fx, yOdd, &fy) {
+		return nil, false
+	}
+	yBytes := fy.Bytes32()
+	return new(big.Int).SetBytes(yBytes[:]), true
+}
+
+// decompressYField stores the normalized y coordinate with the requested
+// parity of the point on the curve with the passed normalized x coordinate in
+// y.  It returns false, leaving y in an unspecified state, when there is no
+// such point.
+func decompressYField(x *fieldVal, odd bool, y *fieldVal) bool {
+	// y = +-sqrt(x^3 + 7), where the sign is chosen by the parity.
+	y.SquareVal(x).Mul(x).AddInt(7)
+	if !y.SqrtVal(y) {
+		return false
+	}
+
+	// Negate the root when its parity differs from the requested one
+	// without branching on the parity of the root.
+	var want uint32
+	if odd {
+		want = 1
+	}
+	y.CondNegate(int((y.n[0]&1)^want), 1).Normalize()
+	return true
+}
+
+// QPlus1Div4 returns the (P+1)/4 constant, where P is the field prime, for use
+// in calculating square roots via exponentiation.  See fieldVal.SqrtVal for
+// the equivalent that operates on field values.
 func (curve *KoblitzCurve) QPlus1Div4() *big.Int {
 	return curve.q
 }
 
+// The field prime, group order and half the group order encoded as big-endian
+// 32-byte arrays.  They are hard-coded so they can be used without allocating.
+var (
+	fieldPrimeBytes = [32]byte{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xfe, 0xff, 0xff, 0xfc, 0x2f,
+	}
+	curveOrderBytes = [32]byte{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe,
+		0xba, 0xae, 0xdc, 0xe6, 0xaf, 0x48, 0xa0, 0x3b,
+		0xbf, 0xd2, 0x5e, 0x8c, 0xd0, 0x36, 0x41, 0x41,
+	}
+	halfOrderBytes = [32]byte{
+		0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0x5d, 0x57, 0x6e, 0x73, 0x57, 0xa4, 0x50, 0x1d,
+		0xdf, 0xe9, 0x2f, 0x46, 0x68, 0x1b, 0x20, 0xa0,
+	}
+)
+
+// FieldPrimeBytes returns the prime P of the underlying field encoded as a
+// big-endian 32-byte array.  Unlike P.Bytes(), it does not allocate.
+func FieldPrimeBytes() [32]byte {
+	return fieldPrimeBytes
+}
+
+// CurveOrderBytes returns the order N of the group encoded as a big-endian
+// 32-byte array.  Unlike N.Bytes(), it does not allocate.
+func CurveOrderBytes() [32]byte {
+	return curveOrderBytes
+}
+
+// HalfOrderBytes returns half the order N of the group, rounded down, encoded
+// as a big-endian 32-byte array.  Signatures with an S value greater than it
+// are not canonical.
+func HalfOrderBytes() [32]byte {
+	return halfOrderBytes
+}
+
 var initonce sync.Once
 var secp256k1 KoblitzCurve
 
+// initErr is the error, if any, that occurred while initializing the curve.
+var initErr error
+
 func initAll() {
 	initS256()
 }
@@ -922,11 +1500,10 @@ func initS256() {
 	secp256k1.byteSize = secp256k1.BitSize / 8
 
 	// Deserialize and set the pre-computed table used to accelerate scalar
-	// base multiplication.  This is hard-coded data, so any errors are
-	// panics because it means something is wrong in the source code.
-	if err := loadS256BytePoints(); err != nil {
-		panic(err)
-	}
+	// base multiplication.  This is hard-coded data, so any errors mean
+	// something is wrong in the source code.  They are recorded to be
+	// reported by TryInit and cause S256 and MustInit to panic.
+	initErr = loadS256BytePoints()
 
 	// Next 6 constants are from Hal Finney's bitcointalk.org post:
 	// https://bitcointalk.org/index.php?topic=3238.msg45565#msg45565
@@ -951,8 +1528,29 @@ func initS256() {
 	// secp256k1.b2 = fromHex("114CA50F7A8E2F3F657C1108D9D44CFD8")
 }
 
-// S256 returns a Curve which implements secp256k1.
-func S256() *KoblitzCurve {
+// TryInit initializes the secp256k1 curve, including loading the pre-computed
+// table used to accelerate scalar base multiplication, if it has not already
+// been initialized.  It returns any error that occurred while doing so, such
+// as the embedded table failing its checksum.
+//
+// This allows long-running services to detect and gracefully report a corrupt
+// build before calling S256, which panics in that case.
+func TryInit() error {
 	initonce.Do(initAll)
+	return initErr
+}
+
+// MustInit initializes the secp256k1 curve if it has not already been
+// initialized and panics if any error occurred while doing so.
+func MustInit() {
+	if err := TryInit(); err != nil {
+		panic(err)
+	}
+}
+
+// S256 returns a Curve which implements secp256k1.  It panics if the curve
+// failed to initialize.  See TryInit to detect that case without panicking.
+func S256() *KoblitzCurve {
+	MustInit()
 	return &secp256k1
 }
@@ -7,6 +7,8 @@
 package secp256k1
 
 import (
+	"bytes"
+	"crypto/elliptic"
 	"crypto/rand"
 	"fmt"
 	"math/big"
@@ -515,6 +517,167 @@ func TestDoubleAffine(t *testing.T) {
 	}
 }
 
+// TestAddInfinityNoAlias ensures the result of adding the point at infinity
+// to a point does not alias the point, so mutating one does not affect the
+// other.
+func TestAddInfinityNoAlias(t *testing.T) {
+	s256 := S256()
+	x, y := new(big.Int).Set(s256.Gx), new(big.Int).Set(s256.Gy)
+	zero := func() *big.Int { return new(big.Int) }
+
+	tests := []struct {
+		name string
+		add  func() (*big.Int, *big.Int)
+	}{
+		{"∞ + P", func() (*big.Int, *big.Int) {
+			return s256.Add(zero(), zero(), x, y)
+		}},
+		{"P + ∞", func() (*big.Int, *big.Int) {
+			return s256.Add(x, y, zero(), zero())
+		}},
+	}
+	for _, test := range tests {
+		rx, ry := test.add()
+		if rx.Cmp(s256.Gx) != 0 || ry.Cmp(s256.Gy) != 0 {
+			t.Errorf("%s: got (%x, %x), want (%x, %x)", test.name, rx,
+				ry, s256.Gx, s256.Gy)
+			continue
+		}
+
+		// Mutating the result must leave the input unchanged and vice
+		// versa.
+		rx.SetInt64(1)
+		ry.SetInt64(2)
+		if x.Cmp(s256.Gx) != 0 || y.Cmp(s256.Gy) != 0 {
+			t.Errorf("%s: mutating the result changed the input",
+				test.name)
+			x.Set(s256.Gx)
+			y.Set(s256.Gy)
+		}
+		rx, ry = test.add()
+		x.SetInt64(3)
+		y.SetInt64(4)
+		if rx.Cmp(s256.Gx) != 0 || ry.Cmp(s256.Gy) != 0 {
+			t.Errorf("%s: mutating the input changed the result",
+				test.name)
+		}
+		x.Set(s256.Gx)
+		y.Set(s256.Gy)
+	}
+}
+
+// TestNegateAndSub ensures point negation and subtraction produce the expected
+// points for multiples of the generator compared against scalar arithmetic
+// modulo the group order, and that the point at infinity is handled.
+func TestNegateAndSub(t *testing.T) {
+	s256 := S256()
+
+	// Negating the point at infinity is the point at infinity.
+	if x, y := s256.Negate(new(big.Int), new(big.Int)); x.Sign() != 0 ||
+		y.Sign() != 0 {
+
+		t.Fatalf("-∞ = (%x, %x), want point at infinity", x, y)
+	}
+
+	for i := 0; i < 32; i++ {
+		a, err := rand.Int(rand.Reader, s256.N)
+		if err != nil {
+			t.Fatalf("failed to read random scalar: %v", err)
+		}
+		b, err := rand.Int(rand.Reader, s256.N)
+		if err != nil {
+			t.Fatalf("failed to read random scalar: %v", err)
+		}
+		if i == 0 {
+			b.Set(a)
+		}
+		ax, ay := s256.ScalarBaseMult(a.Bytes())
+		bx, by := s256.ScalarBaseMult(b.Bytes())
+
+		// -aG = (N-a)G.
+		negA := new(big.Int).Sub(s256.N, a)
+		wantX, wantY := s256.ScalarBaseMult(negA.Bytes())
+		if x, y := s256.Negate(ax, ay); x.Cmp(wantX) != 0 ||
+			y.Cmp(wantY) != 0 {
+
+			t.Fatalf("#%d: -%xG = (%x, %x), want (%x, %x)", i, a, x, y,
+				wantX, wantY)
+		}
+
+		// aG + -aG = ∞ and aG - aG = ∞.
+		if x, y := s256.Add(ax, ay, wantX, wantY); x.Sign() != 0 ||
+			y.Sign() != 0 {
+
+			t.Fatalf("#%d: P + -P = (%x, %x), want point at infinity",
+				i, x, y)
+		}
+		if x, y := s256.Sub(ax, ay, ax, ay); x.Sign() != 0 ||
+			y.Sign() != 0 {
+
+			t.Fatalf("#%d: P - P = (%x, %x), want point at infinity",
+				i, x, y)
+		}
+
+		// aG - bG = ((a - b) mod N)G.
+		diff := new(big.Int).Sub(a, b)
+		diff.Mod(diff, s256.N)
+		wantX, wantY = s256.ScalarBaseMult(diff.Bytes())
+		if diff.Sign() == 0 {
+			wantX, wantY = new(big.Int), new(big.Int)
+		}
+		if x, y := s256.Sub(ax, ay, bx, by); x.Cmp(wantX) != 0 ||
+			y.Cmp(wantY) != 0 {
+
+			t.Fatalf("#%d: %xG - %xG = (%x, %x), want (%x, %x)", i, a,
+				b, x, y, wantX, wantY)
+		}
+
+		// Subtracting the point at infinity leaves the point unchanged
+		// and subtracting a point from it negates the point.
+		inf := new(big.Int)
+		if x, y := s256.Sub(ax, ay, inf, inf); x.Cmp(ax) != 0 ||
+			y.Cmp(ay) != 0 {
+
+			t.Fatalf("#%d: P - ∞ = (%x, %x), want (%x, %x)", i, x, y,
+				ax, ay)
+		}
+		wantX, wantY = s256.Negate(ax, ay)
+		if x, y := s256.Sub(inf, inf, ax, ay); x.Cmp(wantX) != 0 ||
+			y.Cmp(wantY) != 0 {
+
+			t.Fatalf("#%d: ∞ - P = (%x, %x), want (%x, %x)", i, x, y,
+				wantX, wantY)
+		}
+	}
+}
+
+// TestDoubleInfinityAndZeroY ensures doubling the point at infinity and points
+// with a y coordinate of zero, which are never on the curve, returns the point
+// at infinity.
+func TestDoubleInfinityAndZeroY(t *testing.T) {
+	s256 := S256()
+	tests := []struct {
+		name string
+		x    *big.Int
+	}{
+		{"point at infinity", new(big.Int)},
+		{"x = 1", big.NewInt(1)},
+		{"x = Gx", s256.Gx},
+		{"x = P-1", new(big.Int).Sub(s256.P, one)},
+	}
+	for _, test := range tests {
+		y := new(big.Int)
+		if test.x.Sign() != 0 && s256.IsOnCurve(test.x, y) {
+			t.Errorf("%s: point with y = 0 is on the curve", test.name)
+		}
+		rx, ry := s256.Double(test.x, y)
+		if rx.Sign() != 0 || ry.Sign() != 0 {
+			t.Errorf("%s: got (%x, %x), want point at infinity",
+				test.name, rx, ry)
+		}
+	}
+}
+
 func TestOnCurve(t *testing.T) {
 	s256 := S256()
 	if !s256.IsOnCurve(s256.Params().Gx, s256.Params().Gy) {
@@ -522,6 +685,30 @@ func TestOnCurve(t *testing.T) {
 	}
 }
 
+// TestOnCurveRejectsOutOfRange ensures IsOnCurve rejects the generator with
+// coordinates offset by the field prime, which satisfy the curve equation
+// modulo the prime, as well as the point at infinity.
+func TestOnCurveRejectsOutOfRange(t *testing.T) {
+	s256 := S256()
+	gxPlusP := new(big.Int).Add(s256.Gx, s256.P)
+	gyPlusP := new(big.Int).Add(s256.Gy, s256.P)
+	tests := []struct {
+		name string
+		x, y *big.Int
+	}{
+		{"x+P", gxPlusP, s256.Gy},
+		{"y+P", s256.Gx, gyPlusP},
+		{"x+P and y+P", gxPlusP, gyPlusP},
+		{"y-P", s256.Gx, new(big.Int).Sub(s256.Gy, s256.P)},
+		{"point at infinity", new(big.Int), new(big.Int)},
+	}
+	for _, test := range tests {
+		if s256.IsOnCurve(test.x, test.y) {
+			t.Errorf("%s: point accepted", test.name)
+		}
+	}
+}
+
 type baseMultTest struct {
 	k    string
 	x, y string
@@ -663,6 +850,237 @@ func TestScalarMultRand(t *testing.T) {
 	}
 }
 
+// TestDualBaseMult ensures computing k*G and k*P together produces the same
+// results as computing them separately, including for scalars that are larger
+// than the group order.
+func TestDualBaseMult(t *testing.T) {
+	s256 := S256()
+	px, py := s256.ScalarBaseMult([]byte{0x07})
+	scalars := [][]byte{
+		{0x01},
+		s256.N.Bytes(),
+		new(big.Int).Sub(s256.N, big.NewInt(1)).Bytes(),
+		bytes.Repeat([]byte{0xff}, 40),
+	}
+	for i := 0; i < 64; i++ {
+		data := make([]byte, 32)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatalf("failed to read random data at %d", i)
+		}
+		scalars = append(scalars, data)
+	}
+
+	for i, k := range scalars {
+		gx, gy, kpx, kpy := s256.DualBaseMult(k, px, py)
+		wantGx, wantGy := s256.ScalarBaseMult(k)
+		wantPx, wantPy := s256.ScalarMult(px, py, k)
+		if gx.Cmp(wantGx) != 0 || gy.Cmp(wantGy) != 0 {
+			t.Fatalf("%d: bad k*G for %X: got (%X, %X), want "+
+				"(%X, %X)", i, k, gx, gy, wantGx, wantGy)
+		}
+		if kpx.Cmp(wantPx) != 0 || kpy.Cmp(wantPy) != 0 {
+			t.Fatalf("%d: bad k*P for %X: got (%X, %X), want "+
+				"(%X, %X)", i, k, kpx, kpy, wantPx, wantPy)
+		}
+	}
+}
+
+// TestScalarBaseMultPartialTable ensures scalar base multiplication produces
+// the same results when the pre-computed byte points only cover some of the
+// windows and the remaining ones are computed on demand.
+func TestScalarBaseMultPartialTable(t *testing.T) {
+	s256 := S256()
+	scalars := [][]byte{
+		{0x01},
+		{0x01, 0x00, 0x00, 0x00, 0x00},
+		s256.N.Bytes(),
+		new(big.Int).Sub(s256.N, big.NewInt(1)).Bytes(),
+		bytes.Repeat([]byte{0xff}, 40),
+	}
+	for i := 0; i < 16; i++ {
+		data := make([]byte, 32)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatalf("failed to read random data at %d", i)
+		}
+		scalars = append(scalars, data)
+	}
+
+	for _, numWindows := range []int{0, 1, 4, 16, 31, 32} {
+		if numWindows > len(s256.bytePoints) {
+			continue
+		}
+		partial := *s256
+		partial.bytePoints = s256.bytePoints[len(s256.bytePoints)-numWindows:]
+
+		for i, k := range scalars {
+			x, y := partial.ScalarBaseMult(k)
+			wantX, wantY := s256.ScalarMult(s256.Gx, s256.Gy, k)
+			if x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+				t.Fatalf("%d windows, #%d: bad output for %X: got "+
+					"(%X, %X), want (%X, %X)", numWindows, i, k,
+					x, y, wantX, wantY)
+			}
+			wantX, wantY = s256.ScalarBaseMult(k)
+			if x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+				t.Fatalf("%d windows, #%d: mismatched table output "+
+					"for %X: got (%X, %X), want (%X, %X)",
+					numWindows, i, k, x, y, wantX, wantY)
+			}
+		}
+	}
+}
+
+// TestScalarDigits ensures splitting scalars into base 2^w digits for various
+// window sizes produces digits that are in range and reconstruct the scalar.
+func TestScalarDigits(t *testing.T) {
+	scalars := [][]byte{
+		nil,
+		{0x00},
+		{0x01},
+		{0x80, 0x00, 0x01},
+		S256().N.Bytes(),
+		bytes.Repeat([]byte{0xff}, 33),
+	}
+	for i := 0; i < 32; i++ {
+		data := make([]byte, 32)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatalf("failed to read random data at %d", i)
+		}
+		scalars = append(scalars, data)
+	}
+
+	for _, windowBits := range []uint{1, 2, 3, 4, 5, 8, 13, 32} {
+		for i, k := range scalars {
+			digits := ScalarDigits(k, windowBits)
+			wantLen := (len(k)*8 + int(windowBits) - 1) / int(windowBits)
+			if len(digits) != wantLen {
+				t.Fatalf("w=%d, #%d: got %d digits, want %d",
+					windowBits, i, len(digits), wantLen)
+			}
+
+			// k = sum(digits[i] * 2^(windowBits*i)).
+			got := new(big.Int)
+			for j := len(digits) - 1; j >= 0; j-- {
+				if uint64(digits[j]) >= uint64(1)<<windowBits {
+					t.Fatalf("w=%d, #%d: digit %d is %d which is "+
+						"too large", windowBits, i, j, digits[j])
+				}
+				got.Lsh(got, windowBits)
+				got.Add(got, new(big.Int).SetUint64(uint64(digits[j])))
+			}
+			if want := new(big.Int).SetBytes(k); got.Cmp(want) != 0 {
+				t.Fatalf("w=%d, #%d: reconstructed %x, want %x",
+					windowBits, i, got, want)
+			}
+		}
+	}
+
+	for _, windowBits := range []uint{0, 33} {
+		if digits := ScalarDigits([]byte{0x01}, windowBits); digits != nil {
+			t.Fatalf("w=%d: got %v, want nil", windowBits, digits)
+		}
+	}
+}
+
+// TestWNAF ensures the wNAF digits of various scalars reconstruct the scalar,
+// are odd and in range when nonzero, are spaced at least width digits apart,
+// and match NAF for a width of 2.
+func TestWNAF(t *testing.T) {
+	scalars := [][]byte{
+		nil,
+		{0x00},
+		{0x01},
+		{0x7f},
+		{0xff},
+		{0x80, 0x00, 0x01},
+		S256().N.Bytes(),
+		bytes.Repeat([]byte{0xff}, 33),
+	}
+	for i := 0; i < 32; i++ {
+		data := make([]byte, 32)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatalf("failed to read random data at %d", i)
+		}
+		scalars = append(scalars, data)
+	}
+
+	for width := uint(2); width <= 8; width++ {
+		bound := int64(1) << (width - 1)
+		for i, k := range scalars {
+			digits := WNAF(k, width)
+			if digits == nil {
+				t.Fatalf("w=%d, #%d: got nil digits", width, i)
+			}
+			if len(digits) > 0 && digits[len(digits)-1] == 0 {
+				t.Fatalf("w=%d, #%d: most significant digit is zero",
+					width, i)
+			}
+
+			// k = sum(digits[i] * 2^i).
+			got := new(big.Int)
+			lastNonzero := -int(width)
+			for j := len(digits) - 1; j >= 0; j-- {
+				got.Lsh(got, 1)
+				got.Add(got, big.NewInt(int64(digits[j])))
+			}
+			for j, digit := range digits {
+				if digit == 0 {
+					continue
+				}
+				if digit%2 == 0 || int64(digit) <= -bound ||
+					int64(digit) >= bound {
+
+					t.Fatalf("w=%d, #%d: invalid digit %d at %d",
+						width, i, digit, j)
+				}
+				if j-lastNonzero < int(width) {
+					t.Fatalf("w=%d, #%d: nonzero digits at %d and "+
+						"%d are closer than %d", width, i,
+						lastNonzero, j, width)
+				}
+				lastNonzero = j
+			}
+			if want := new(big.Int).SetBytes(k); got.Cmp(want) != 0 {
+				t.Fatalf("w=%d, #%d: reconstructed %x, want %x",
+					width, i, got, want)
+			}
+
+			// A width of 2 is the NAF, whose positive and negative
+			// digits are returned as big endian bit masks.
+			if width != 2 {
+				continue
+			}
+			nafPos, nafNeg := NAF(k)
+			numBits := len(nafPos) * 8
+			for j := 0; j < numBits || j < len(digits); j++ {
+				var want int8
+				if j < numBits {
+					byteIdx, mask := len(nafPos)-1-j/8, byte(1)<<uint(j%8)
+					if nafPos[byteIdx]&mask != 0 {
+						want = 1
+					} else if nafNeg[byteIdx]&mask != 0 {
+						want = -1
+					}
+				}
+				var got int8
+				if j < len(digits) {
+					got = digits[j]
+				}
+				if got != want {
+					t.Fatalf("#%d: digit %d is %d, NAF digit is %d",
+						i, j, got, want)
+				}
+			}
+		}
+	}
+
+	for _, width := range []uint{0, 1, 9} {
+		if digits := WNAF([]byte{0x01}, width); digits != nil {
+			t.Fatalf("w=%d: got %v, want nil", width, digits)
+		}
+	}
+}
+
 func TestSplitK(t *testing.T) {
 	tests := []struct {
 		k      string
@@ -887,3 +1305,480 @@ func TestNAFRand(t *testing.T) {
 		}
 	}
 }
+
+// TestScalarMultCompressed ensures the compressed scalar multiplication
+// variants produce the same serialization as serializing the results of the
+// big integer variants, including for the point at infinity.
+func TestScalarMultCompressed(t *testing.T) {
+	s256 := S256()
+	px, py := s256.ScalarBaseMult([]byte{0x07})
+	scalars := [][]byte{
+		{0x01},
+		{0x00},
+		s256.N.Bytes(),
+		new(big.Int).Sub(s256.N, big.NewInt(1)).Bytes(),
+		bytes.Repeat([]byte{0xff}, 40),
+	}
+	for i := 0; i < 64; i++ {
+		data := make([]byte, 32)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatalf("failed to read random data at %d", i)
+		}
+		scalars = append(scalars, data)
+	}
+
+	for i, k := range scalars {
+		gx, gy := s256.ScalarBaseMult(k)
+		want := (&PublicKey{Curve: s256, X: gx, Y: gy}).SerializeCompressed()
+		got := s256.ScalarBaseMultCompressed(k)
+		if !bytes.Equal(got[:], want) {
+			t.Fatalf("%d: bad compressed k*G for %X: got %x, want %x",
+				i, k, got, want)
+		}
+
+		kpx, kpy := s256.ScalarMult(px, py, k)
+		want = (&PublicKey{Curve: s256, X: kpx, Y: kpy}).SerializeCompressed()
+		got = s256.ScalarMultCompressed(px, py, k)
+		if !bytes.Equal(got[:], want) {
+			t.Fatalf("%d: bad compressed k*P for %X: got %x, want %x",
+				i, k, got, want)
+		}
+	}
+}
+
+// TestVerifyEquation ensures computing s*G - e*P in one step produces the same
+// results as computing s*G and e*P separately and subtracting them.
+func TestVerifyEquation(t *testing.T) {
+	s256 := S256()
+	px, py := s256.ScalarBaseMult([]byte{0x07})
+	scalars := [][]byte{
+		{0x00},
+		{0x01},
+		s256.N.Bytes(),
+		new(big.Int).Sub(s256.N, big.NewInt(1)).Bytes(),
+		bytes.Repeat([]byte{0xff}, 40),
+	}
+	for i := 0; i < 32; i++ {
+		data := make([]byte, 32)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatalf("failed to read random data at %d", i)
+		}
+		scalars = append(scalars, data)
+	}
+
+	for i, sk := range scalars {
+		for j, ek := range scalars {
+			rx, ry := s256.VerifyEquation(sk, ek, px, py)
+
+			sGx, sGy := s256.ScalarBaseMult(sk)
+			ePx, ePy := s256.ScalarMult(px, py, ek)
+			if ePx.Sign() != 0 || ePy.Sign() != 0 {
+				ePy.Sub(s256.P, ePy)
+			}
+			wantX, wantY := s256.Add(sGx, sGy, ePx, ePy)
+			if rx.Cmp(wantX) != 0 || ry.Cmp(wantY) != 0 {
+				t.Fatalf("%d, %d: bad s*G - e*P for s = %X, e = %X: "+
+					"got (%X, %X), want (%X, %X)", i, j, sk, ek, rx,
+					ry, wantX, wantY)
+			}
+		}
+	}
+
+	// s*G - e*P is the point at infinity when s*G = e*P.
+	rx, ry := s256.VerifyEquation([]byte{0x0e}, []byte{0x02}, px, py)
+	if rx.Sign() != 0 || ry.Sign() != 0 {
+		t.Fatalf("got (%X, %X), want the point at infinity", rx, ry)
+	}
+}
+
+// TestConstantBytes ensures the hard-coded byte encodings of the curve
+// constants match the big-endian encoding of the curve parameters.
+func TestConstantBytes(t *testing.T) {
+	s256 := S256()
+	tests := []struct {
+		name string
+		got  [32]byte
+		want *big.Int
+	}{
+		{"field prime", FieldPrimeBytes(), s256.P},
+		{"curve order", CurveOrderBytes(), s256.N},
+		{"half order", HalfOrderBytes(), s256.halfOrder},
+	}
+	for _, test := range tests {
+		want := paddedAppend(32, nil, test.want.Bytes())
+		if !bytes.Equal(test.got[:], want) {
+			t.Errorf("%s: got %x, want %x", test.name, test.got, want)
+		}
+	}
+
+	// Modifying a returned array must not affect later calls.
+	n := CurveOrderBytes()
+	n[0] = 0
+	if CurveOrderBytes()[0] != 0xff {
+		t.Error("returned array aliases the package constant")
+	}
+}
+
+// TestGroupAxioms ensures Add obeys the group law across random points and the
+// special cases involving the identity, inverses, and equal points, which take
+// dedicated code paths in the implementation.
+func TestGroupAxioms(t *testing.T) {
+	s256 := S256()
+	inf := [2]*big.Int{new(big.Int), new(big.Int)}
+	neg := func(p [2]*big.Int) [2]*big.Int {
+		if p[0].Sign() == 0 && p[1].Sign() == 0 {
+			return p
+		}
+		return [2]*big.Int{p[0], new(big.Int).Sub(s256.P, p[1])}
+	}
+	add := func(p, q [2]*big.Int) [2]*big.Int {
+		x, y := s256.Add(p[0], p[1], q[0], q[1])
+		return [2]*big.Int{x, y}
+	}
+	equal := func(p, q [2]*big.Int) bool {
+		return p[0].Cmp(q[0]) == 0 && p[1].Cmp(q[1]) == 0
+	}
+
+	g := [2]*big.Int{s256.Gx, s256.Gy}
+	points := [][2]*big.Int{inf, g, neg(g), add(g, g)}
+	for i := 0; i < 8; i++ {
+		data := make([]byte, 32)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatalf("failed to read random data at %d", i)
+		}
+		x, y := s256.ScalarBaseMult(data)
+		p := [2]*big.Int{x, y}
+		points = append(points, p, neg(p))
+	}
+
+	for i, p := range points {
+		if !equal(add(p, inf), p) || !equal(add(inf, p), p) {
+			t.Fatalf("%d: P + infinity != P", i)
+		}
+		if !equal(add(p, neg(p)), inf) {
+			t.Fatalf("%d: P + (-P) != infinity", i)
+		}
+		if !equal(add(p, p), func() [2]*big.Int {
+			x, y := s256.Double(p[0], p[1])
+			return [2]*big.Int{x, y}
+		}()) {
+			t.Fatalf("%d: P + P != 2P", i)
+		}
+
+		for j, q := range points {
+			pq := add(p, q)
+			if !equal(pq, add(q, p)) {
+				t.Fatalf("%d, %d: P + Q != Q + P", i, j)
+			}
+			if !s256.IsOnCurve(pq[0], pq[1]) && !equal(pq, inf) {
+				t.Fatalf("%d, %d: P + Q is not on the curve", i, j)
+			}
+
+			for k, r := range points {
+				if !equal(add(pq, r), add(p, add(q, r))) {
+					t.Fatalf("%d, %d, %d: (P + Q) + R != "+
+						"P + (Q + R)", i, j, k)
+				}
+			}
+		}
+	}
+}
+
+// TestEllipticMarshalUnmarshal ensures points round trip through the standard
+// library marshalling functions and that non-canonical encodings with
+// coordinates that are not less than the field prime are rejected both by
+// IsOnCurve and elliptic.Unmarshal.
+func TestEllipticMarshalUnmarshal(t *testing.T) {
+	s256 := S256()
+	for i := 0; i < 16; i++ {
+		data := make([]byte, 32)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatalf("failed to read random data at %d", i)
+		}
+		x, y := s256.ScalarBaseMult(data)
+		gotX, gotY := elliptic.Unmarshal(s256, elliptic.Marshal(s256, x, y))
+		if gotX == nil || gotX.Cmp(x) != 0 || gotY.Cmp(y) != 0 {
+			t.Fatalf("%d: point did not round trip", i)
+		}
+	}
+
+	// The point with x = 1 and its x coordinate offset by the prime.  The
+	// latter satisfies the curve equation modulo P, but is not canonical.
+	x := big.NewInt(1)
+	y, err := decompressPoint(s256, x, false)
+	if err != nil {
+		t.Fatalf("failed to decompress point: %v", err)
+	}
+	xPlusP := new(big.Int).Add(x, s256.P)
+	yPlusP := new(big.Int).Add(y, s256.P)
+	tests := []struct {
+		name string
+		x, y *big.Int
+	}{
+		{"x >= P", xPlusP, y},
+		{"y >= P", x, yPlusP},
+		{"negative y", x, new(big.Int).Sub(y, s256.P)},
+	}
+	if !s256.IsOnCurve(x, y) {
+		t.Fatal("canonical point is not on the curve")
+	}
+	for _, test := range tests {
+		if s256.IsOnCurve(test.x, test.y) {
+			t.Errorf("%s: IsOnCurve accepted non-canonical point",
+				test.name)
+		}
+	}
+
+	// A 65-byte uncompressed encoding of the point with x >= P.
+	blob := append([]byte{0x04}, paddedAppend(32, nil, xPlusP.Bytes())...)
+	blob = paddedAppend(32, blob, y.Bytes())
+	if gotX, _ := elliptic.Unmarshal(s256, blob); gotX != nil {
+		t.Error("Unmarshal accepted point with x >= P")
+	}
+}
+
+// TestScalarMultMethods ensures the GLV and plain scalar multiplication methods
+// agree across random points and scalars, and that ScalarMult honors the
+// selected method.
+func TestScalarMultMethods(t *testing.T) {
+	s256 := S256()
+	scalars := [][]byte{
+		{0x00},
+		{0x01},
+		s256.N.Bytes(),
+		new(big.Int).Sub(s256.N, big.NewInt(1)).Bytes(),
+		bytes.Repeat([]byte{0xff}, 40),
+	}
+	iterations := 2000
+	if testing.Short() {
+		iterations = 100
+	}
+	for i := 0; i < iterations; i++ {
+		data := make([]byte, 32)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatalf("failed to read random data at %d", i)
+		}
+		scalars = append(scalars, data)
+	}
+
+	px, py := s256.ScalarBaseMult([]byte{0x07})
+	for i, k := range scalars {
+		// Use a different point for every random scalar.
+		if i > 0 {
+			px, py = s256.ScalarBaseMult(scalars[i-1])
+			if px.Sign() == 0 && py.Sign() == 0 {
+				px, py = s256.Gx, s256.Gy
+			}
+		}
+		p1x, p1y := s256.bigAffineToField(px, py)
+		p1z := new(fieldVal).SetInt(1)
+
+		var glvX, glvY, glvZ, plainX, plainY, plainZ fieldVal
+		s256.scalarMultJacobian(k, p1x, p1y, p1z, &glvX, &glvY, &glvZ)
+		s256.scalarMultPlainJacobian(k, p1x, p1y, p1z, &plainX, &plainY,
+			&plainZ)
+		gotX, gotY := s256.fieldJacobianToBigAffine(&plainX, &plainY, &plainZ)
+		wantX, wantY := s256.fieldJacobianToBigAffine(&glvX, &glvY, &glvZ)
+		if gotX.Cmp(wantX) != 0 || gotY.Cmp(wantY) != 0 {
+			t.Fatalf("%d: methods disagree for k = %x: plain (%x, %x), "+
+				"GLV (%x, %x)", i, k, gotX, gotY, wantX, wantY)
+		}
+	}
+
+	if got := GetScalarMultMethod(); got != ScalarMultGLV {
+		t.Fatalf("default method is %v, want %v", got, ScalarMultGLV)
+	}
+	SetScalarMultMethod(ScalarMultPlain)
+	defer SetScalarMultMethod(ScalarMultGLV)
+	k := scalars[len(scalars)-1]
+	gotX, gotY := s256.ScalarMult(s256.Gx, s256.Gy, k)
+	wantX, wantY := s256.ScalarBaseMult(k)
+	if gotX.Cmp(wantX) != 0 || gotY.Cmp(wantY) != 0 {
+		t.Fatal("ScalarMult with the plain method produced the wrong point")
+	}
+}
+
+// TestSafeScalarMult ensures SafeScalarMult rejects points that are not on the
+// curve, including a point on the quadratic twist, and agrees with ScalarMult
+// for valid points.
+func TestSafeScalarMult(t *testing.T) {
+	s256 := S256()
+	k := fromHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575").Bytes()
+
+	// Since -1 is not a square modulo P, y^2 = x^3 - 7 is the quadratic twist
+	// of the curve.  Find the point on it with the smallest x coordinate.
+	var twistX, twistY *big.Int
+	for x := int64(1); twistY == nil; x++ {
+		rhs := new(big.Int).Exp(big.NewInt(x), big.NewInt(3), s256.P)
+		rhs.Sub(rhs, big.NewInt(7)).Mod(rhs, s256.P)
+		if y := new(big.Int).ModSqrt(rhs, s256.P); y != nil {
+			twistX, twistY = big.NewInt(x), y
+		}
+	}
+
+	// ScalarMult silently computes a multiple of the twist point that is
+	// itself not on the curve.
+	if x, y := s256.ScalarMult(twistX, twistY, k); s256.IsOnCurve(x, y) {
+		t.Fatal("multiple of twist point is on the curve")
+	}
+
+	invalid := []struct {
+		name string
+		x, y *big.Int
+	}{
+		{"twist point", twistX, twistY},
+		{"point at infinity", new(big.Int), new(big.Int)},
+		{"generator with wrong y", s256.Gx, new(big.Int).Add(s256.Gy, one)},
+		{"nil coordinates", nil, nil},
+	}
+	for _, test := range invalid {
+		x, y, err := s256.SafeScalarMult(test.x, test.y, k)
+		if err != ErrPointNotOnCurve {
+			t.Errorf("%s: unexpected error -- got %v, want %v",
+				test.name, err, ErrPointNotOnCurve)
+		}
+		if x != nil || y != nil {
+			t.Errorf("%s: unexpected point returned", test.name)
+		}
+	}
+
+	px, py := s256.ScalarBaseMult([]byte{0x07})
+	for _, p := range [][2]*big.Int{{s256.Gx, s256.Gy}, {px, py}} {
+		x, y, err := s256.SafeScalarMult(p[0], p[1], k)
+		if err != nil {
+			t.Fatalf("unexpected error for valid point: %v", err)
+		}
+		wantX, wantY := s256.ScalarMult(p[0], p[1], k)
+		if x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+			t.Fatalf("mismatched result -- got (%x, %x), want (%x, %x)",
+				x, y, wantX, wantY)
+		}
+	}
+}
+
+// TestMarshalCompressed ensures points round trip through MarshalCompressed and
+// UnmarshalCompressed, agree with the uncompressed marshalling, and that
+// invalid encodings are rejected.
+func TestMarshalCompressed(t *testing.T) {
+	s256 := S256()
+	for i := 0; i < 16; i++ {
+		data := make([]byte, 32)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatalf("failed to read random data at %d", i)
+		}
+		x, y := s256.ScalarBaseMult(data)
+		b := s256.MarshalCompressed(x, y)
+		if len(b) != PubKeyBytesLenCompressed {
+			t.Fatalf("%d: unexpected length %d", i, len(b))
+		}
+		if want := byte(0x02) | byte(y.Bit(0)); b[0] != want {
+			t.Fatalf("%d: unexpected prefix -- got %#x, want %#x", i,
+				b[0], want)
+		}
+		gotX, gotY := s256.UnmarshalCompressed(b)
+		if gotX == nil || !s256.IsOnCurve(gotX, gotY) {
+			t.Fatalf("%d: decompressed point is not on the curve", i)
+		}
+		if gotX.Cmp(x) != 0 || gotY.Cmp(y) != 0 {
+			t.Fatalf("%d: point did not round trip", i)
+		}
+
+		// The uncompressed encoding must carry the same point.
+		wantX, wantY := elliptic.Unmarshal(s256, elliptic.Marshal(s256,
+			gotX, gotY))
+		if wantX == nil || gotX.Cmp(wantX) != 0 || gotY.Cmp(wantY) != 0 {
+			t.Fatalf("%d: mismatched uncompressed point", i)
+		}
+	}
+
+	// Find the smallest x coordinate without a point on the curve.
+	var nonResidueX *big.Int
+	for x := int64(1); nonResidueX == nil; x++ {
+		rhs := new(big.Int).Exp(big.NewInt(x), big.NewInt(3), s256.P)
+		rhs.Add(rhs, big.NewInt(7)).Mod(rhs, s256.P)
+		if new(big.Int).ModSqrt(rhs, s256.P) == nil {
+			nonResidueX = big.NewInt(x)
+		}
+	}
+
+	valid := s256.MarshalCompressed(s256.Gx, s256.Gy)
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"too short", valid[:32]},
+		{"too long", append(append([]byte(nil), valid...), 0)},
+		{"uncompressed prefix", append([]byte{0x04}, valid[1:]...)},
+		{"hybrid prefix", append([]byte{0x06}, valid[1:]...)},
+		{"x equal to P", append([]byte{0x02}, s256.P.Bytes()...)},
+		{"x greater than P", append([]byte{0x02}, new(big.Int).Add(s256.P,
+			one).Bytes()...)},
+		{"x not on curve", append([]byte{0x03}, paddedAppend(32, nil,
+			nonResidueX.Bytes())...)},
+	}
+	for _, test := range tests {
+		if x, y := s256.UnmarshalCompressed(test.data); x != nil || y != nil {
+			t.Errorf("%s: invalid encoding accepted", test.name)
+		}
+	}
+}
+
+// TestDecompressY ensures DecompressY reconstructs both the even and odd y
+// coordinates of points on the curve and rejects x coordinates without one.
+func TestDecompressY(t *testing.T) {
+	s256 := S256()
+	for i := int64(1); i <= 8; i++ {
+		x, y := s256.ScalarBaseMult(big.NewInt(i).Bytes())
+		negY := new(big.Int).Sub(s256.P, y)
+		evenY, oddY := y, negY
+		if y.Bit(0) == 1 {
+			evenY, oddY = negY, y
+		}
+		for _, want := range []*big.Int{evenY, oddY} {
+			yOdd := want.Bit(0) == 1
+			got, ok := s256.DecompressY(x, yOdd)
+			if !ok {
+				t.Errorf("%d: no y found (odd %v)", i, yOdd)
+				continue
+			}
+			if got.Cmp(want) != 0 {
+				t.Errorf("%d: mismatched y (odd %v) -- got %x, want %x",
+					i, yOdd, got, want)
+			}
+			if !s256.IsOnCurve(x, got) {
+				t.Errorf("%d: point is not on the curve (odd %v)", i,
+					yOdd)
+			}
+		}
+	}
+
+	// Find the smallest positive x coordinate without a point on the curve.
+	var nonResidueX *big.Int
+	for x := int64(1); nonResidueX == nil; x++ {
+		rhs := new(big.Int).Exp(big.NewInt(x), big.NewInt(3), s256.P)
+		rhs.Add(rhs, big.NewInt(7)).Mod(rhs, s256.P)
+		if new(big.Int).ModSqrt(rhs, s256.P) == nil {
+			nonResidueX = big.NewInt(x)
+		}
+	}
+
+	invalid := []struct {
+		name string
+		x    *big.Int
+	}{
+		{"zero", new(big.Int)},
+		{"no point on curve", nonResidueX},
+		{"negative", big.NewInt(-1)},
+		{"equal to P", s256.P},
+		{"greater than P", new(big.Int).Add(s256.P, s256.Gx)},
+	}
+	for _, test := range invalid {
+		for _, yOdd := range []bool{false, true} {
+			if y, ok := s256.DecompressY(test.x, yOdd); ok || y != nil {
+				t.Errorf("%s: unexpected y %v (odd %v)", test.name,
+					y, yOdd)
+			}
+		}
+	}
+}
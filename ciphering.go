@@ -12,6 +12,7 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"crypto/sha512"
+	"encoding/binary"
 	"errors"
 	"io"
 )
@@ -43,11 +44,72 @@ var (
 // GenerateSharedSecret generates a shared secret based on a private key and a
 // public key using Diffie-Hellman key exchange (ECDH) (RFC 4753).
 // RFC5903 Section 9 states we should only return x.
+//
+// The x coordinate is returned without leading zero bytes, so the secret is
+// shorter than 32 bytes for roughly 1 in 256 key pairs.  Encrypt and Decrypt
+// derive their keys from this form, so it is kept for compatibility with
+// existing ciphertexts.  Use GenerateSharedSecretPadded for the fixed length
+// form expected by most other protocols.
 func GenerateSharedSecret(privkey *PrivateKey, pubkey *PublicKey) []byte {
 	x, _ := pubkey.Curve.ScalarMult(pubkey.X, pubkey.Y, privkey.D.Bytes())
 	return x.Bytes()
 }
 
+// GenerateSharedSecretPadded generates a shared secret like
+// GenerateSharedSecret, except that the x coordinate is always returned as 32
+// bytes, padded with leading zeros as needed, as specified by SEC 1 and
+// expected by protocols such as those covered by the Wycheproof test vectors.
+func GenerateSharedSecretPadded(privkey *PrivateKey, pubkey *PublicKey) []byte {
+	return paddedAppend(32, nil, GenerateSharedSecret(privkey, pubkey))
+}
+
+// GenerateSharedSecretXOnly generates a shared secret like
+// GenerateSharedSecretPadded for a peer that is only known by the X coordinate
+// of its public key, as is the case for Taproot-style x-only keys.  The peer
+// key is taken to be the point P with that X coordinate and an even Y
+// coordinate, and the secret is the X coordinate of privkey*P returned as 32
+// bytes.
+//
+// The X coordinate alone is enough since the only other point with the same X
+// coordinate is -P, and privkey*(-P) = -(privkey*P) has the same X coordinate
+// as privkey*P.  The secret therefore matches the one derived from the full
+// public key of the peer, whatever the parity of its Y coordinate.
+//
+// An error is returned when there is no point on the curve with the given X
+// coordinate.
+func GenerateSharedSecretXOnly(privkey *PrivateKey, peerX [32]byte) ([]byte, error) {
+	pubkey, err := PublicKeyFromXAndParity(peerX, pubkeyCompressed)
+	if err != nil {
+		return nil, err
+	}
+	return GenerateSharedSecretPadded(privkey, pubkey), nil
+}
+
+// X963KDF derives outLen bytes of key material from a shared secret, such as
+// the one returned by GenerateSharedSecretPadded, with the key derivation function
+// of ANSI X9.63 using SHA-256.  The output is the concatenation of
+//
+//	SHA-256(sharedSecret || counter || sharedInfo)
+//
+// for a 32-bit big endian counter starting at 1, truncated to outLen bytes.
+// This is the key derivation used by ECIES variants such as those of Apple
+// CryptoKit and the Security framework instead of HKDF.
+//
+// The sharedInfo may be nil.  outLen must not be negative.
+func X963KDF(sharedSecret, sharedInfo []byte, outLen int) []byte {
+	out := make([]byte, 0, outLen+sha256.Size)
+	var counter [4]byte
+	for i := uint32(1); len(out) < outLen; i++ {
+		binary.BigEndian.PutUint32(counter[:], i)
+		h := sha256.New()
+		h.Write(sharedSecret)
+		h.Write(counter[:])
+		h.Write(sharedInfo)
+		out = h.Sum(out)
+	}
+	return out[:outLen]
+}
+
 // Encrypt encrypts data for the target public key using AES-256-CBC. It also
 // generates a private key (the pubkey of which is also in the output). The only
 // supported curve is secp256k1. The `structure' that it encodes everything into
@@ -33,6 +33,167 @@ func TestGenerateSharedSecret(t *testing.T) {
 	}
 }
 
+// legacyCiphertext is a ciphertext of legacyPlaintext for the public key of
+// legacyPrivKey, with the ephemeral public key legacyEphemeral, that was
+// produced by Encrypt with the unpadded shared secret.  The x coordinate of
+// its shared point has a leading zero byte, so a key derived from the padded
+// secret would not decrypt it.
+const (
+	legacyPrivKey   = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	legacyPlaintext = "encrypted before shared secrets were padded"
+	legacyEphemeral = "0437028466e953ff5c84bf15afc70be91c1868538f3166bfbdae2cdff49fbeb" +
+		"3579c716ed91be5704e8c18f1de73b16a1ad9aeedfb74f0f5160bcae13f61073a8e"
+	legacyCiphertext = "b499df9171896bdcbccd21873ccb158e02ca002037028466e953ff5c84bf" +
+		"15afc70be91c1868538f3166bfbdae2cdff49fbeb35700209c716ed91be5704e8c" +
+		"18f1de73b16a1ad9aeedfb74f0f5160bcae13f61073a8e45bd7725eafe60b4069e" +
+		"31e2d474d50967c3e206ff8cff3b67aa279d6b6a49ab59c20393bd5a53dda6d332" +
+		"d389ececb3e89885d892aaf99054f678f345497a4ef6e7f2e93a165b10d1ca7672" +
+		"45b7e195"
+)
+
+// TestGenerateSharedSecretPadded ensures the padded shared secret is always 32
+// bytes and only differs from GenerateSharedSecret by its leading zero bytes.
+func TestGenerateSharedSecretPadded(t *testing.T) {
+	privBytes, _ := hex.DecodeString(legacyPrivKey)
+	privKey, _ := secp256k1.PrivKeyFromBytes(secp256k1.S256(), privBytes)
+	pubBytes, _ := hex.DecodeString(legacyEphemeral)
+	ephemeral, err := secp256k1.ParsePubKey(pubBytes, secp256k1.S256())
+	if err != nil {
+		t.Fatalf("failed to parse public key: %v", err)
+	}
+
+	secret := secp256k1.GenerateSharedSecret(privKey, ephemeral)
+	padded := secp256k1.GenerateSharedSecretPadded(privKey, ephemeral)
+	if len(secret) != 31 {
+		t.Fatalf("unpadded secret is %d bytes, want 31", len(secret))
+	}
+	if len(padded) != 32 || padded[0] != 0 || !bytes.Equal(padded[1:], secret) {
+		t.Fatalf("padded secret %x does not match secret %x", padded,
+			secret)
+	}
+}
+
+// TestDecryptLegacyCiphertext ensures ciphertexts whose shared secret has a
+// leading zero byte still decrypt, which requires Encrypt and Decrypt to keep
+// deriving their keys from the unpadded shared secret.
+func TestDecryptLegacyCiphertext(t *testing.T) {
+	privBytes, _ := hex.DecodeString(legacyPrivKey)
+	privKey, _ := secp256k1.PrivKeyFromBytes(secp256k1.S256(), privBytes)
+	ciphertext, _ := hex.DecodeString(legacyCiphertext)
+
+	plaintext, err := secp256k1.Decrypt(privKey, ciphertext)
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	if string(plaintext) != legacyPlaintext {
+		t.Fatalf("got plaintext %q, want %q", plaintext, legacyPlaintext)
+	}
+}
+
+// TestGenerateSharedSecretXOnly ensures both parties derive the same secret
+// from the x-only public key of the other, that it matches the secret derived
+// from the full public keys, and that X coordinates without a point on the
+// curve are rejected.
+func TestGenerateSharedSecretXOnly(t *testing.T) {
+	xOnly := func(pub *secp256k1.PublicKey) [32]byte {
+		var x [32]byte
+		copy(x[:], pub.SerializeCompressed()[1:])
+		return x
+	}
+
+	for i := 0; i < 16; i++ {
+		privKey1, err := secp256k1.NewPrivateKey(secp256k1.S256())
+		if err != nil {
+			t.Fatalf("private key generation error: %s", err)
+		}
+		privKey2, err := secp256k1.NewPrivateKey(secp256k1.S256())
+		if err != nil {
+			t.Fatalf("private key generation error: %s", err)
+		}
+
+		secret1, err := secp256k1.GenerateSharedSecretXOnly(privKey1,
+			xOnly(privKey2.PubKey()))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		secret2, err := secp256k1.GenerateSharedSecretXOnly(privKey2,
+			xOnly(privKey1.PubKey()))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !bytes.Equal(secret1, secret2) {
+			t.Fatalf("ECDH failed, secrets mismatch - first: %x, "+
+				"second: %x", secret1, secret2)
+		}
+
+		// The secret must match regular ECDH with the full keys.
+		want := secp256k1.GenerateSharedSecretPadded(privKey1,
+			privKey2.PubKey())
+		if !bytes.Equal(secret1, want) {
+			t.Fatalf("got secret %x, want %x", secret1, want)
+		}
+	}
+
+	// X coordinates without a point on the curve must be rejected.
+	privKey, err := secp256k1.NewPrivateKey(secp256k1.S256())
+	if err != nil {
+		t.Fatalf("private key generation error: %s", err)
+	}
+	var badX [32]byte
+	badX[31] = 0x05
+	if _, err := secp256k1.GenerateSharedSecretXOnly(privKey, badX); err == nil {
+		t.Fatal("expected error for an X coordinate not on the curve")
+	}
+}
+
+// TestX963KDF ensures X963KDF produces the expected output for the ANSI X9.63
+// KDF SHA-256 test vectors of NIST CAVS and that shorter outputs are prefixes
+// of longer ones.
+func TestX963KDF(t *testing.T) {
+	tests := []struct {
+		secret string
+		info   string
+		want   string
+	}{
+		{
+			secret: "96c05619d56c328ab95fe84b18264b08725b85e33fd34f08",
+			info:   "",
+			want:   "443024c3dae66b95e6f5670601558f71",
+		},
+		{
+			secret: "22518b10e70f2a3f243810ae3254139efbee04aa57c7af7d",
+			info:   "75eef81aa3041e33b80971203d2c0c52",
+			want: "c498af77161cc59f2962b9a713e2b215152d139766ce34a776df1186" +
+				"6a69bf2e52a13d9c7c6fc878c50c5ea0bc7b00e0da2447cfd874f6cf92" +
+				"f30d0097111485500c90c3af8b487872d04685d14c8d1dc8d7fa08beb0" +
+				"ce0ababc11f0bd496269142d43525a78e5bc79a17f59676a5706dc54d5" +
+				"4d4d1f0bd7e386128ec26afc21",
+		},
+	}
+
+	for i, test := range tests {
+		secret, _ := hex.DecodeString(test.secret)
+		info, _ := hex.DecodeString(test.info)
+		want, _ := hex.DecodeString(test.want)
+
+		got := secp256k1.X963KDF(secret, info, len(want))
+		if !bytes.Equal(got, want) {
+			t.Errorf("#%d: got %x, want %x", i, got, want)
+			continue
+		}
+		for _, n := range []int{0, 1, 31, 32, 33} {
+			if n > len(want) {
+				continue
+			}
+			got := secp256k1.X963KDF(secret, info, n)
+			if !bytes.Equal(got, want[:n]) {
+				t.Errorf("#%d: got %x for %d bytes, want %x", i, got,
+					n, want[:n])
+			}
+		}
+	}
+}
+
 // Test 1: Encryption and decryption
 func TestCipheringBasic(t *testing.T) {
 	privkey, err := secp256k1.NewPrivateKey(secp256k1.S256())
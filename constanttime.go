@@ -0,0 +1,284 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"crypto/subtle"
+	"math/big"
+	"math/bits"
+)
+
+// ConstantTimeEqual returns whether or not the two passed byte slices, such as
+// serialized keys or signatures, are equal.  The time taken is independent of
+// the contents of the slices and only depends on the length of the longer of
+// the two.
+//
+// Unlike subtle.ConstantTimeCompare, which returns immediately when the
+// lengths differ, the contents are always scanned in full and the length
+// comparison is folded into the final result.  This means an attacker probing
+// with inputs of varying lengths can't learn anything beyond the length of the
+// expected value.
+func ConstantTimeEqual(a, b []byte) bool {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+
+	// Treat the shorter slice as if it were padded with zeros.  The only
+	// branches depend on the lengths, never on the contents.
+	var v byte
+	for i := 0; i < n; i++ {
+		var x, y byte
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		v |= x ^ y
+	}
+
+	sameLen := subtle.ConstantTimeEq(int32(len(a)), int32(len(b)))
+	return subtle.ConstantTimeByteEq(v, 0)&sameLen == 1
+}
+
+// ScalarBitLength returns the number of bits needed to represent the big
+// endian integer k, which is the total number of bits in k less its leading
+// zero bits.  A scalar of zero has a bit length of zero.
+//
+// This is not intended for production use.  It is provided for analyzing the
+// timing of scalar multiplication, since the bit length of a scalar is exactly
+// the sort of secret dependent quantity that must not affect the running time
+// of constant time code.
+func ScalarBitLength(k []byte) int {
+	for i, b := range k {
+		if b != 0 {
+			return (len(k)-i)*8 - bits.LeadingZeros8(b)
+		}
+	}
+	return 0
+}
+
+// addJacobianConst adds the passed Jacobian points (x1, y1, z1) and
+// (x2, y2, z2) together and stores the result in (x3, y3, z3) like
+// addJacobian, except that the time taken does not depend on the points.
+//
+// addJacobian branches on whether either point is the point at infinity and
+// on whether the points share the same x coordinate, in which case the sum is
+// either computed by doubling or is the point at infinity.  Those branches
+// reveal when such intermediate results occur, which is a side channel when
+// the points are derived from secret data such as the windows of a private
+// scalar.  This instead always computes both the generic addition and the
+// doubling of the first point and then selects the correct result, including
+// the point at infinity and either input point, with constant time
+// conditional moves.
+//
+// Since the doubling is always computed and none of the faster special cases
+// for points with equal or unit z values are used, this is roughly 1.6 times
+// slower than addJacobian for general points.  See BenchmarkAddJacobianConst.
+func (curve *KoblitzCurve) addJacobianConst(x1, y1, z1, x2, y2, z2, x3, y3, z3 *fieldVal) {
+	// Normalize copies of the inputs so the output may alias them.
+	var px, py, pz, qx, qy, qz fieldVal
+	px.Set(x1).Normalize()
+	py.Set(y1).Normalize()
+	pz.Set(z1).Normalize()
+	qx.Set(x2).Normalize()
+	qy.Set(y2).Normalize()
+	qz.Set(z2).Normalize()
+
+	// Flags for the special cases.  A point is at infinity when its z
+	// value is zero or both of its x and y values are zero.
+	pInf := pz.isZeroFlag() | (px.isZeroFlag() & py.isZeroFlag())
+	qInf := qz.isZeroFlag() | (qx.isZeroFlag() & qy.isZeroFlag())
+
+	// Compute the generic addition as in addGeneric without the branches.
+	var z1z1, z2z2, u1, u2, s1, s2 fieldVal
+	z1z1.SquareVal(&pz)                         // Z1Z1 = Z1^2 (mag: 1)
+	z2z2.SquareVal(&qz)                         // Z2Z2 = Z2^2 (mag: 1)
+	u1.Set(&px).Mul(&z2z2).Normalize()          // U1 = X1*Z2Z2 (mag: 1)
+	u2.Set(&qx).Mul(&z1z1).Normalize()          // U2 = X2*Z1Z1 (mag: 1)
+	s1.Set(&py).Mul(&z2z2).Mul(&qz).Normalize() // S1 = Y1*Z2*Z2Z2 (mag: 1)
+	s2.Set(&qy).Mul(&z1z1).Mul(&pz).Normalize() // S2 = Y2*Z1*Z1Z1 (mag: 1)
+	sameX := u1.equalsFlag(&u2)
+	sameY := s1.equalsFlag(&s2)
+
+	var h, i, j, r, rr, v fieldVal
+	var negU1, negS1, negX3 fieldVal
+	var sx, sy, sz fieldVal
+	negU1.Set(&u1).Negate(1)               // negU1 = -U1 (mag: 2)
+	h.Add2(&u2, &negU1)                    // H = U2-U1 (mag: 3)
+	i.Set(&h).MulInt(2).Square()           // I = (2*H)^2 (mag: 2)
+	j.Mul2(&h, &i)                         // J = H*I (mag: 1)
+	negS1.Set(&s1).Negate(1)               // negS1 = -S1 (mag: 2)
+	r.Set(&s2).Add(&negS1).MulInt(2)       // r = 2*(S2-S1) (mag: 6)
+	rr.SquareVal(&r)                       // rr = r^2 (mag: 1)
+	v.Mul2(&u1, &i)                        // V = U1*I (mag: 1)
+	sx.Set(&v).MulInt(2).Add(&j).Negate(3) // X3 = -(J+2*V) (mag: 4)
+	sx.Add(&rr)                            // X3 = r^2+X3 (mag: 5)
+	negX3.Set(&sx).Negate(5)               // negX3 = -X3 (mag: 6)
+	sy.Mul2(&s1, &j).MulInt(2).Negate(2)   // Y3 = -(2*S1*J) (mag: 3)
+	sy.Add(v.Add(&negX3).Mul(&r))          // Y3 = r*(V-X3)+Y3 (mag: 4)
+	sz.Add2(&pz, &qz).Square()             // Z3 = (Z1+Z2)^2 (mag: 1)
+	sz.Add(z1z1.Add(&z2z2).Negate(2))      // Z3 = Z3-(Z1Z1+Z2Z2) (mag: 4)
+	sz.Mul(&h)                             // Z3 = Z3*H (mag: 1)
+	sx.Normalize()
+	sy.Normalize()
+	sz.Normalize()
+
+	// Always compute the doubling of the first point as well.
+	var dx, dy, dz fieldVal
+	curve.doubleGeneric(&px, &py, &pz, &dx, &dy, &dz)
+
+	// Select the result.  The later selections take precedence since an
+	// input at infinity invalidates the flags computed from the formulas.
+	var zero fieldVal
+	double := sameX & sameY
+	opposite := sameX & (sameY ^ 1)
+	sx.conditionalSet(&dx, double)
+	sy.conditionalSet(&dy, double)
+	sz.conditionalSet(&dz, double)
+	sx.conditionalSet(&zero, opposite)
+	sy.conditionalSet(&zero, opposite)
+	sz.conditionalSet(&zero, opposite)
+	sx.conditionalSet(&px, qInf)
+	sy.conditionalSet(&py, qInf)
+	sz.conditionalSet(&pz, qInf)
+	sx.conditionalSet(&qx, pInf)
+	sy.conditionalSet(&qy, pInf)
+	sz.conditionalSet(&qz, pInf)
+
+	x3.Set(&sx)
+	y3.Set(&sy)
+	z3.Set(&sz)
+}
+
+// selectFieldPoint sets p to the Jacobian point at the passed index in the
+// passed table, or to the point at infinity when the index is not in the
+// table.  Every entry of the table is read and conditionally copied regardless
+// of the index, so neither the time taken nor the memory access pattern reveal
+// which entry was selected.  It is the lookup shared by the constant time
+// scalar multiplications.
+func selectFieldPoint(table [][3]fieldVal, index int, p *[3]fieldVal) {
+	*p = [3]fieldVal{}
+	for i := range table {
+		flag := constantTimeIndexEq(i, index)
+		p[0].conditionalSet(&table[i][0], flag)
+		p[1].conditionalSet(&table[i][1], flag)
+		p[2].conditionalSet(&table[i][2], flag)
+	}
+}
+
+// ScalarBaseMultMasked returns k*G, where G is the base point of the group and
+// k is a big endian integer, like ScalarBaseMult, except that the time taken
+// and the memory accessed do not depend on the value of k.
+//
+// ScalarBaseMult indexes the pre-computed byte points directly with each byte
+// of the scalar and skips the leading zero bytes of it, so both the cache
+// lines it touches and the number of additions it performs reveal information
+// about the scalar.  This instead always processes all 32 windows, reads all
+// 256 entries of each window to select the needed one with constant time
+// masking, and adds it with addJacobianConst, which also handles the point at
+// infinity selected by zero bytes without branching.
+//
+// Reading every entry makes this several times slower than ScalarBaseMult.
+// See BenchmarkScalarBaseMultMasked.  Callers should choose between the two
+// based on whether an attacker can observe the timing or cache behavior of
+// the process computing the multiplication.
+//
+// NOTE: Only the length of k is allowed to affect the running time.  Scalars
+// longer than 32 bytes are reduced modulo the group order first in variable
+// time.  Also, when the package is built with the smallbasetable build tag,
+// the windows that are not covered by the pre-computed table are computed
+// with the same variable time method as ScalarBaseMult.
+func (curve *KoblitzCurve) ScalarBaseMultMasked(k []byte) (*big.Int, *big.Int) {
+	// Left pad the scalar so every window is processed.  Scalars of up to
+	// 32 bytes that are not less than N need not be reduced since N*G is
+	// the point at infinity.
+	var kBytes [32]byte
+	newK := curve.moduloReduce(k)
+	copy(kBytes[len(kBytes)-len(newK):], newK)
+
+	qx, qy, qz := new(fieldVal), new(fieldVal), new(fieldVal)
+	firstWindow := curve.byteSize - len(curve.bytePoints)
+	for i := firstWindow; i < len(kBytes); i++ {
+		var p [3]fieldVal
+		selectFieldPoint(curve.bytePoints[i-firstWindow][:],
+			int(kBytes[i]), &p)
+		curve.addJacobianConst(qx, qy, qz, &p[0], &p[1], &p[2], qx, qy, qz)
+	}
+	if firstWindow > 0 {
+		curve.addUncoveredWindows(kBytes[:firstWindow], qx, qy, qz)
+	}
+	return curve.fieldJacobianToBigAffine(qx, qy, qz)
+}
+
+const (
+	// constWindowBits is the width of the windows ScalarMultConst splits
+	// the scalar into.
+	constWindowBits = 4
+
+	// constTableSize is the number of multiples of the point, including
+	// the point at infinity, ScalarMultConst selects from for each window.
+	constTableSize = 1 << constWindowBits
+
+	// constWindowsPerByte is the number of windows in each byte of the
+	// scalar.
+	constWindowsPerByte = 8 / constWindowBits
+)
+
+// ScalarMultConst returns k*(Bx, By) where k is a big endian integer, like
+// ScalarMult, except that the sequence of field operations and the memory
+// accessed do not depend on the value of k.
+//
+// ScalarMult decomposes the scalar with splitK and walks the non-adjacent
+// forms of the halves, branching on the sign of each half and on every digit,
+// which is a timing and cache side channel when k is a private scalar.  This
+// instead uses a fixed 4-bit window: it always processes all 64 windows of the
+// scalar, performing four doublings with doubleGeneric, which has no special
+// case for the point at infinity, followed by one addition with
+// addJacobianConst of the multiple selected from a table of the first 16
+// multiples of the point with constant time masking.
+//
+// This is roughly twice as slow as ScalarMult.  See BenchmarkScalarMultConst.
+//
+// NOTE: Only the length of k is allowed to affect the running time.  Scalars
+// longer than 32 bytes are reduced modulo the group order first in variable
+// time.  The point itself is treated as public.
+func (curve *KoblitzCurve) ScalarMultConst(Bx, By *big.Int, k []byte) (*big.Int, *big.Int) {
+	// Left pad the scalar so every window is processed.  Scalars of up to
+	// 32 bytes that are not less than N need not be reduced since N*B is
+	// the point at infinity.
+	var kBytes [32]byte
+	newK := curve.moduloReduce(k)
+	copy(kBytes[len(kBytes)-len(newK):], newK)
+
+	// table[i] = i*B, where table[0] is the point at infinity.
+	var table [constTableSize][3]fieldVal
+	bx, by := curve.bigAffineToField(Bx, By)
+	table[1][0].Set(bx)
+	table[1][1].Set(by)
+	table[1][2].SetInt(1)
+	for i := 2; i < constTableSize; i++ {
+		prev, cur := &table[i-1], &table[i]
+		curve.addJacobianConst(&prev[0], &prev[1], &prev[2], bx, by,
+			&table[1][2], &cur[0], &cur[1], &cur[2])
+	}
+
+	qx, qy, qz := new(fieldVal), new(fieldVal), new(fieldVal)
+	for _, b := range kBytes {
+		for w := constWindowsPerByte - 1; w >= 0; w-- {
+			for i := 0; i < constWindowBits; i++ {
+				curve.doubleGeneric(qx, qy, qz, qx, qy, qz)
+			}
+
+			var p [3]fieldVal
+			index := (b >> uint(w*constWindowBits)) & (constTableSize - 1)
+			selectFieldPoint(table[:], int(index), &p)
+			curve.addJacobianConst(qx, qy, qz, &p[0], &p[1], &p[2], qx,
+				qy, qz)
+		}
+	}
+	return curve.fieldJacobianToBigAffine(qx, qy, qz)
+}
@@ -0,0 +1,316 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// TestConstantTimeEqual ensures ConstantTimeEqual works as expected for equal,
+// unequal, and differently sized inputs.
+func TestConstantTimeEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []byte
+		want bool
+	}{
+		{"both empty", nil, []byte{}, true},
+		{"equal", []byte{1, 2, 3}, []byte{1, 2, 3}, true},
+		{"same length, first byte differs", []byte{0, 2, 3},
+			[]byte{1, 2, 3}, false},
+		{"same length, last byte differs", []byte{1, 2, 3},
+			[]byte{1, 2, 4}, false},
+		{"shorter prefix", []byte{1, 2}, []byte{1, 2, 3}, false},
+		{"longer with trailing zero", []byte{1, 2, 3, 0},
+			[]byte{1, 2, 3}, false},
+		{"empty versus zero byte", []byte{}, []byte{0}, false},
+	}
+
+	for _, test := range tests {
+		if got := ConstantTimeEqual(test.a, test.b); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got,
+				test.want)
+		}
+		if got := ConstantTimeEqual(test.b, test.a); got != test.want {
+			t.Errorf("%s (swapped): got %v, want %v", test.name,
+				got, test.want)
+		}
+	}
+}
+
+// TestScalarBitLength ensures ScalarBitLength returns the expected number of
+// bits for various scalars including those with leading zero bytes.
+func TestScalarBitLength(t *testing.T) {
+	tests := []struct {
+		name string
+		k    []byte
+		want int
+	}{
+		{"nil", nil, 0},
+		{"zero", []byte{0, 0, 0}, 0},
+		{"one", []byte{1}, 1},
+		{"one with leading zeros", []byte{0, 0, 1}, 1},
+		{"0xff", []byte{0xff}, 8},
+		{"0x0100", []byte{1, 0}, 9},
+		{"0x0080 00", []byte{0, 0x80, 0}, 16},
+		{"group order", decodeHex("fffffffffffffffffffffffffffff" +
+			"ffebaaedce6af48a03bbfd25e8cd0364141"), 256},
+	}
+
+	for _, test := range tests {
+		if got := ScalarBitLength(test.k); got != test.want {
+			t.Errorf("%s: got %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+// TestAddJacobianConst ensures the constant time point addition produces the
+// same results as addJacobian for general points as well as the special cases
+// of equal points, opposite points, and the point at infinity, with the points
+// given in various Jacobian representations.
+func TestAddJacobianConst(t *testing.T) {
+	curve := S256()
+	seed := time.Now().Unix()
+	rng := rand.New(rand.NewSource(seed))
+
+	// jacobian returns k*G with its z value scaled by a random factor when
+	// scale is set.
+	jacobian := func(k int64, scale bool) [3]fieldVal {
+		var p [3]fieldVal
+		if k == 0 {
+			return p
+		}
+		kBytes := new(big.Int).Mod(big.NewInt(k), curve.N).Bytes()
+		curve.scalarBaseMultJacobian(kBytes, &p[0], &p[1], &p[2])
+		if scale {
+			var lambda, l2, l3 fieldVal
+			var b [32]byte
+			rng.Read(b[:])
+			lambda.SetBytes(&b).Normalize()
+			l2.SquareVal(&lambda)
+			l3.Mul2(&l2, &lambda)
+			p[0].Mul(&l2).Normalize()
+			p[1].Mul(&l3).Normalize()
+			p[2].Mul(&lambda).Normalize()
+		}
+		return p
+	}
+
+	tests := []struct {
+		name string
+		k1   int64
+		k2   int64
+	}{
+		{"general", 3, 5},
+		{"equal", 7, 7},
+		{"opposite", 7, -7},
+		{"first infinity", 0, 9},
+		{"second infinity", 9, 0},
+		{"both infinity", 0, 0},
+	}
+	for i := 0; i < 16; i++ {
+		tests = append(tests, struct {
+			name string
+			k1   int64
+			k2   int64
+		}{"random", rng.Int63(), rng.Int63()})
+	}
+
+	for _, test := range tests {
+		for _, scale := range []bool{false, true} {
+			p1 := jacobian(test.k1, scale)
+			p2 := jacobian(test.k2, scale)
+
+			var wx, wy, wz, gx, gy, gz fieldVal
+			curve.addJacobian(&p1[0], &p1[1], &p1[2], &p2[0], &p2[1],
+				&p2[2], &wx, &wy, &wz)
+			curve.addJacobianConst(&p1[0], &p1[1], &p1[2], &p2[0],
+				&p2[1], &p2[2], &gx, &gy, &gz)
+			wantX, wantY := curve.fieldJacobianToBigAffine(&wx, &wy, &wz)
+			gotX, gotY := curve.fieldJacobianToBigAffine(&gx, &gy, &gz)
+			if gotX.Cmp(wantX) != 0 || gotY.Cmp(wantY) != 0 {
+				t.Fatalf("%s (%d, %d, scaled %v, seed %d): got "+
+					"(%x, %x), want (%x, %x)", test.name,
+					test.k1, test.k2, scale, seed, gotX, gotY,
+					wantX, wantY)
+			}
+
+			// The output may alias the first input.
+			curve.addJacobianConst(&p1[0], &p1[1], &p1[2], &p2[0],
+				&p2[1], &p2[2], &p1[0], &p1[1], &p1[2])
+			gotX, gotY = curve.fieldJacobianToBigAffine(&p1[0],
+				&p1[1], &p1[2])
+			if gotX.Cmp(wantX) != 0 || gotY.Cmp(wantY) != 0 {
+				t.Fatalf("%s (%d, %d, scaled %v, seed %d): wrong "+
+					"result with aliased output", test.name,
+					test.k1, test.k2, scale, seed)
+			}
+		}
+	}
+}
+
+// BenchmarkAddJacobianConst benchmarks the constant time point addition
+// against the variable time one for general points.
+func BenchmarkAddJacobianConst(b *testing.B) {
+	curve := S256()
+	var x1, y1, z1, x2, y2, z2, x3, y3, z3 fieldVal
+	curve.scalarBaseMultJacobian([]byte{0x03}, &x1, &y1, &z1)
+	curve.scalarBaseMultJacobian([]byte{0x05}, &x2, &y2, &z2)
+	curve.doubleJacobian(&x1, &y1, &z1, &x1, &y1, &z1)
+	curve.doubleJacobian(&x2, &y2, &z2, &x2, &y2, &z2)
+
+	b.Run("variable", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			curve.addJacobian(&x1, &y1, &z1, &x2, &y2, &z2, &x3, &y3,
+				&z3)
+		}
+	})
+	b.Run("constant", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			curve.addJacobianConst(&x1, &y1, &z1, &x2, &y2, &z2, &x3,
+				&y3, &z3)
+		}
+	})
+}
+
+// TestScalarBaseMultMasked ensures the masked scalar base multiplication
+// produces the same results as ScalarBaseMult for edge cases and random
+// scalars.
+func TestScalarBaseMultMasked(t *testing.T) {
+	curve := S256()
+	nMinus1 := new(big.Int).Sub(curve.N, big.NewInt(1))
+	nPlus1 := new(big.Int).Add(curve.N, big.NewInt(1))
+	tests := []struct {
+		name string
+		k    []byte
+	}{
+		{"empty", nil},
+		{"zero", make([]byte, 32)},
+		{"one", []byte{0x01}},
+		{"zero byte windows", decodeHex("0100000000000000000000000000" +
+			"00000000000000000000000000000000ff")},
+		{"N-1", nMinus1.Bytes()},
+		{"N", curve.N.Bytes()},
+		{"N+1", nPlus1.Bytes()},
+		{"all ones", decodeHex("ffffffffffffffffffffffffffffffffffffffffff" +
+			"ffffffffffffffffffffff")},
+		{"longer than 32 bytes", decodeHex("d74bf844b0862475103d96a611cf2" +
+			"d898447e288d34b360bc885cb8ce7c005751111111011111110")},
+	}
+	for _, test := range tests {
+		wantX, wantY := curve.ScalarBaseMult(test.k)
+		gotX, gotY := curve.ScalarBaseMultMasked(test.k)
+		if gotX.Cmp(wantX) != 0 || gotY.Cmp(wantY) != 0 {
+			t.Errorf("%s: got (%x, %x), want (%x, %x)", test.name, gotX,
+				gotY, wantX, wantY)
+		}
+	}
+
+	seed := time.Now().Unix()
+	rng := rand.New(rand.NewSource(seed))
+	for i := 0; i < 64; i++ {
+		k := make([]byte, 1+rng.Intn(32))
+		rng.Read(k)
+		wantX, wantY := curve.ScalarBaseMult(k)
+		gotX, gotY := curve.ScalarBaseMultMasked(k)
+		if gotX.Cmp(wantX) != 0 || gotY.Cmp(wantY) != 0 {
+			t.Fatalf("k %x (seed %d): got (%x, %x), want (%x, %x)", k,
+				seed, gotX, gotY, wantX, wantY)
+		}
+	}
+}
+
+// BenchmarkScalarBaseMultMasked benchmarks the masked scalar base
+// multiplication against ScalarBaseMult.
+func BenchmarkScalarBaseMultMasked(b *testing.B) {
+	k := fromHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575")
+	curve := S256()
+
+	b.Run("indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			curve.ScalarBaseMult(k.Bytes())
+		}
+	})
+	b.Run("masked", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			curve.ScalarBaseMultMasked(k.Bytes())
+		}
+	})
+}
+
+// TestScalarMultConst ensures the constant time scalar multiplication produces
+// the same results as ScalarMult for edge case and random scalars and points.
+func TestScalarMultConst(t *testing.T) {
+	curve := S256()
+	nMinus1 := new(big.Int).Sub(curve.N, big.NewInt(1))
+	nPlus1 := new(big.Int).Add(curve.N, big.NewInt(1))
+	scalars := [][]byte{
+		nil,
+		make([]byte, 32),
+		{0x01},
+		{0x0f},
+		{0x10},
+		decodeHex("0100000000000000000000000000" +
+			"00000000000000000000000000000000ff"),
+		nMinus1.Bytes(),
+		curve.N.Bytes(),
+		nPlus1.Bytes(),
+		decodeHex("ffffffffffffffffffffffffffffffffffffffffff" +
+			"ffffffffffffffffffffff"),
+		decodeHex("d74bf844b0862475103d96a611cf2" +
+			"d898447e288d34b360bc885cb8ce7c005751111111011111110"),
+	}
+
+	seed := time.Now().Unix()
+	rng := rand.New(rand.NewSource(seed))
+	for i := 0; i < 32; i++ {
+		k := make([]byte, 1+rng.Intn(32))
+		rng.Read(k)
+		scalars = append(scalars, k)
+	}
+
+	x7, y7 := curve.ScalarBaseMult([]byte{0x07})
+	points := [][2]*big.Int{{curve.Gx, curve.Gy}, {x7, y7}}
+	for i := 0; i < 4; i++ {
+		k := make([]byte, 32)
+		rng.Read(k)
+		x, y := curve.ScalarBaseMult(k)
+		points = append(points, [2]*big.Int{x, y})
+	}
+
+	for _, p := range points {
+		for _, k := range scalars {
+			wantX, wantY := curve.ScalarMult(p[0], p[1], k)
+			gotX, gotY := curve.ScalarMultConst(p[0], p[1], k)
+			if gotX.Cmp(wantX) != 0 || gotY.Cmp(wantY) != 0 {
+				t.Fatalf("k %x, point (%x, %x) (seed %d): got (%x, "+
+					"%x), want (%x, %x)", k, p[0], p[1], seed, gotX,
+					gotY, wantX, wantY)
+			}
+		}
+	}
+}
+
+// BenchmarkScalarMultConst benchmarks the constant time scalar multiplication
+// against ScalarMult.
+func BenchmarkScalarMultConst(b *testing.B) {
+	k := fromHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575")
+	curve := S256()
+	x, y := curve.ScalarBaseMult([]byte{0x07})
+
+	b.Run("variable", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			curve.ScalarMult(x, y, k.Bytes())
+		}
+	})
+	b.Run("const", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			curve.ScalarMultConst(x, y, k.Bytes())
+		}
+	})
+}
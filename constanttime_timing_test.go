@@ -0,0 +1,156 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build timing
+// +build timing
+
+// The tests in this file compare wall-clock timings, which are too noisy on
+// shared machines to run by default.  Run them with the timing build tag:
+//
+//	go test -tags=timing -run Timing
+
+package secp256k1
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+const (
+	// timingScalarsPerLength is the number of scalars of each bit length
+	// that are timed by scalarTimingRatio.
+	timingScalarsPerLength = 64
+
+	// timingRuns is the number of times each scalar is timed.  Only the
+	// fastest run is kept to filter out noise such as preemption.
+	timingRuns = 5
+
+	// maxTimingRatio is the largest ratio between the median timings of
+	// scalars of different bit lengths that is still considered to be
+	// independent of the bit length.  It is deliberately generous since
+	// timings on shared machines are noisy.
+	maxTimingRatio = 1.5
+)
+
+// scalarTimingRatio times the passed scalar multiplication routine for
+// random scalars that are 16 bits long and 256 bits long and returns the
+// ratio of the larger median timing to the smaller one.  A ratio near one
+// indicates the running time does not depend on the bit length of the
+// scalar.
+func scalarTimingRatio(rng *rand.Rand, mult func(k []byte)) float64 {
+	bitLens := []int{16, 256}
+	scalars := make([][][]byte, len(bitLens))
+	for i, bitLen := range bitLens {
+		for j := 0; j < timingScalarsPerLength; j++ {
+			k := make([]byte, 32)
+			rng.Read(k[32-bitLen/8:])
+			k[32-bitLen/8] |= 0x80
+			scalars[i] = append(scalars[i], k)
+		}
+	}
+
+	// Interleave the bit lengths so any drift in the speed of the machine
+	// affects both equally.
+	timings := make([][]time.Duration, len(bitLens))
+	for j := 0; j < timingScalarsPerLength; j++ {
+		for i := range bitLens {
+			k := scalars[i][j]
+			best := time.Duration(1<<63 - 1)
+			for run := 0; run < timingRuns; run++ {
+				start := time.Now()
+				mult(k)
+				if elapsed := time.Since(start); elapsed < best {
+					best = elapsed
+				}
+			}
+			timings[i] = append(timings[i], best)
+		}
+	}
+
+	medians := make([]float64, len(bitLens))
+	for i, durations := range timings {
+		sort.Slice(durations, func(a, b int) bool {
+			return durations[a] < durations[b]
+		})
+		medians[i] = float64(durations[len(durations)/2])
+	}
+	if medians[0] > medians[1] {
+		return medians[0] / medians[1]
+	}
+	return medians[1] / medians[0]
+}
+
+// TestScalarMultConstTimingIndependence ensures the running time of
+// ScalarMultConst does not depend on the bit length of the scalar.
+func TestScalarMultConstTimingIndependence(t *testing.T) {
+	curve := S256()
+	x, y := curve.ScalarBaseMult([]byte{0x07})
+	rng := rand.New(rand.NewSource(1))
+	ratio := scalarTimingRatio(rng, func(k []byte) {
+		curve.ScalarMultConst(x, y, k)
+	})
+	if ratio > maxTimingRatio {
+		t.Fatalf("timing ratio %.2f between scalar bit lengths exceeds "+
+			"%.2f", ratio, maxTimingRatio)
+	}
+}
+
+// TestScalarBaseMultMaskedTimingIndependence ensures the running time of
+// ScalarBaseMultMasked does not depend on the bit length of the scalar.
+func TestScalarBaseMultMaskedTimingIndependence(t *testing.T) {
+	curve := S256()
+	if len(curve.bytePoints) < curve.byteSize {
+		t.Skip("skipping timing test since the uncovered windows of " +
+			"the small base table are computed in variable time")
+	}
+	rng := rand.New(rand.NewSource(1))
+	ratio := scalarTimingRatio(rng, func(k []byte) {
+		curve.ScalarBaseMultMasked(k)
+	})
+	if ratio > maxTimingRatio {
+		t.Fatalf("timing ratio %.2f between scalar bit lengths exceeds "+
+			"%.2f", ratio, maxTimingRatio)
+	}
+}
+
+// TestParsePubKeyCompressedTimingIndependence ensures rejecting compressed
+// public keys whose X coordinate has no square root or is not in the field
+// takes a similar amount of time to parsing a valid key.
+func TestParsePubKeyCompressedTimingIndependence(t *testing.T) {
+	curve := S256()
+	valid, noSqrt, notInField := compressedPathKeys(curve)
+
+	// timeParse returns the median of the fastest of several runs of
+	// parsing the passed key for a number of trials.
+	timeParse := func(pubKeyStr []byte) time.Duration {
+		const trials, runs = 64, 5
+		durations := make([]time.Duration, 0, trials)
+		for i := 0; i < trials; i++ {
+			best := time.Duration(1<<63 - 1)
+			for run := 0; run < runs; run++ {
+				start := time.Now()
+				ParsePubKey(pubKeyStr, curve)
+				if elapsed := time.Since(start); elapsed < best {
+					best = elapsed
+				}
+			}
+			durations = append(durations, best)
+		}
+		sort.Slice(durations, func(a, b int) bool {
+			return durations[a] < durations[b]
+		})
+		return durations[len(durations)/2]
+	}
+
+	validTime := float64(timeParse(valid))
+	for _, invalid := range [][]byte{noSqrt, notInField} {
+		ratio := validTime / float64(timeParse(invalid))
+		if ratio < 1/maxTimingRatio || ratio > maxTimingRatio {
+			t.Errorf("timing ratio %.2f between valid key and invalid "+
+				"key %x exceeds %.2f", ratio, invalid, maxTimingRatio)
+		}
+	}
+}
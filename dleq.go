@@ -0,0 +1,108 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"math/big"
+)
+
+const (
+	// dleqNonceTag is the tag used to deterministically derive the nonce
+	// of a proof of discrete log equality.
+	dleqNonceTag = "DLEQ/nonce"
+
+	// dleqChallengeTag is the tag used to compute the challenge of a proof
+	// of discrete log equality.
+	dleqChallengeTag = "DLEQ/challenge"
+)
+
+// DLEQProof is a Chaum-Pedersen proof that the discrete logarithms of two
+// points with respect to two different generators are equal.  It consists of
+// the challenge C and the response S.
+type DLEQProof struct {
+	C *big.Int
+	S *big.Int
+}
+
+// dleqMult returns k*G1 and k*G2.  DualBaseMult is used when G1 is the base
+// point of the group since that is by far the most common case.
+func dleqMult(k []byte, g1, g2 *PublicKey) (x1, y1, x2, y2 *big.Int) {
+	curve := S256()
+	if g1.X.Cmp(curve.Gx) == 0 && g1.Y.Cmp(curve.Gy) == 0 {
+		return curve.DualBaseMult(k, g2.X, g2.Y)
+	}
+	x1, y1 = curve.ScalarMult(g1.X, g1.Y, k)
+	x2, y2 = curve.ScalarMult(g2.X, g2.Y, k)
+	return x1, y1, x2, y2
+}
+
+// dleqChallenge computes the challenge of a proof of discrete log equality
+// which commits to the statement along with the nonce commitments A1 and A2.
+func dleqChallenge(g1, h1, g2, h2 *PublicKey, a1x, a1y, a2x, a2y *big.Int) *big.Int {
+	curve := S256()
+	a1 := PublicKey{Curve: curve, X: a1x, Y: a1y}
+	a2 := PublicKey{Curve: curve, X: a2x, Y: a2y}
+	c := new(big.Int).SetBytes(TaggedHash(dleqChallengeTag,
+		g1.SerializeCompressed(), h1.SerializeCompressed(),
+		g2.SerializeCompressed(), h2.SerializeCompressed(),
+		a1.SerializeCompressed(), a2.SerializeCompressed()))
+	return c.Mod(c, curve.N)
+}
+
+// ProveDLEQ returns H1 = x*G1 and H2 = x*G2 along with a proof that
+// log_G1(H1) == log_G2(H2) which does not reveal x.
+//
+// The nonce is derived deterministically from x and both generators, so
+// proving the same statement twice yields the same proof.
+func ProveDLEQ(x *big.Int, G1, G2 *PublicKey) (H1, H2 *PublicKey, proof DLEQProof) {
+	curve := S256()
+	xMod := new(big.Int).Mod(x, curve.N)
+	xBytes := paddedAppend(PrivKeyBytesLen, nil, xMod.Bytes())
+
+	h1x, h1y, h2x, h2y := dleqMult(xBytes, G1, G2)
+	H1 = &PublicKey{Curve: curve, X: h1x, Y: h1y}
+	H2 = &PublicKey{Curve: curve, X: h2x, Y: h2y}
+
+	k := new(big.Int).SetBytes(TaggedHash(dleqNonceTag, xBytes,
+		G1.SerializeCompressed(), G2.SerializeCompressed()))
+	k.Mod(k, curve.N)
+
+	// A1 = k*G1, A2 = k*G2, c = H(G1, H1, G2, H2, A1, A2), s = k - c*x.
+	a1x, a1y, a2x, a2y := dleqMult(k.Bytes(), G1, G2)
+	c := dleqChallenge(G1, H1, G2, H2, a1x, a1y, a2x, a2y)
+	s := new(big.Int).Mul(c, xMod)
+	s.Sub(k, s)
+	s.Mod(s, curve.N)
+
+	return H1, H2, DLEQProof{C: c, S: s}
+}
+
+// VerifyDLEQ returns whether or not the passed proof shows that
+// log_G1(H1) == log_G2(H2).
+func VerifyDLEQ(G1, H1, G2, H2 *PublicKey, proof DLEQProof) bool {
+	curve := S256()
+	for _, p := range []*PublicKey{G1, H1, G2, H2} {
+		if p == nil || !curve.IsOnCurve(p.X, p.Y) {
+			return false
+		}
+	}
+	if proof.C == nil || proof.S == nil {
+		return false
+	}
+	if proof.C.Sign() < 0 || proof.C.Cmp(curve.N) >= 0 ||
+		proof.S.Sign() < 0 || proof.S.Cmp(curve.N) >= 0 {
+
+		return false
+	}
+
+	// A1 = s*G1 + c*H1 and A2 = s*G2 + c*H2 must hash back to c.
+	sx1, sy1, sx2, sy2 := dleqMult(proof.S.Bytes(), G1, G2)
+	cx1, cy1 := curve.ScalarMult(H1.X, H1.Y, proof.C.Bytes())
+	cx2, cy2 := curve.ScalarMult(H2.X, H2.Y, proof.C.Bytes())
+	a1x, a1y := curve.Add(sx1, sy1, cx1, cy1)
+	a2x, a2y := curve.Add(sx2, sy2, cx2, cy2)
+	c := dleqChallenge(G1, H1, G2, H2, a1x, a1y, a2x, a2y)
+	return c.Cmp(proof.C) == 0
+}
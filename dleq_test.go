@@ -0,0 +1,66 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"math/big"
+	"testing"
+)
+
+// TestDLEQ ensures valid proofs of discrete log equality verify and that
+// tampering with any part of the proof or statement causes them to fail.
+func TestDLEQ(t *testing.T) {
+	curve := S256()
+	g := &PublicKey{Curve: curve, X: curve.Gx, Y: curve.Gy}
+	_, g2 := PrivKeyFromBytes(curve, decodeHex("7b"))
+	_, g3 := PrivKeyFromBytes(curve, decodeHex("01c8"))
+	x := fromHex("c9afa9d845ba75166b5c215767b1d6934e50c3db36e89b127b8a622b120f6721")
+
+	tests := []struct {
+		name   string
+		g1, g2 *PublicKey
+	}{
+		{"base point and other", g, g2},
+		{"two other points", g2, g3},
+	}
+	for _, test := range tests {
+		h1, h2, proof := ProveDLEQ(x, test.g1, test.g2)
+		if !VerifyDLEQ(test.g1, h1, test.g2, h2, proof) {
+			t.Errorf("%s: valid proof failed to verify", test.name)
+			continue
+		}
+
+		// Proving the same statement again must be deterministic.
+		_, _, proof2 := ProveDLEQ(x, test.g1, test.g2)
+		if proof.C.Cmp(proof2.C) != 0 || proof.S.Cmp(proof2.S) != 0 {
+			t.Errorf("%s: proof is not deterministic", test.name)
+		}
+
+		tampered := DLEQProof{C: proof.C, S: new(big.Int).Add(proof.S, one)}
+		if VerifyDLEQ(test.g1, h1, test.g2, h2, tampered) {
+			t.Errorf("%s: proof with tampered response verified",
+				test.name)
+		}
+		tampered = DLEQProof{C: new(big.Int).Add(proof.C, one), S: proof.S}
+		if VerifyDLEQ(test.g1, h1, test.g2, h2, tampered) {
+			t.Errorf("%s: proof with tampered challenge verified",
+				test.name)
+		}
+
+		// H2 for a different exponent must not verify.
+		otherX := new(big.Int).Add(x, one)
+		_, otherH2, _ := ProveDLEQ(otherX, test.g1, test.g2)
+		if VerifyDLEQ(test.g1, h1, test.g2, otherH2, proof) {
+			t.Errorf("%s: proof verified for unequal logarithms",
+				test.name)
+		}
+
+		// Swapping the generators must not verify.
+		if VerifyDLEQ(test.g2, h2, test.g1, h1, proof) {
+			t.Errorf("%s: proof verified with swapped generators",
+				test.name)
+		}
+	}
+}
@@ -17,5 +17,11 @@ functionality is provided to parse signatures and public keys from
 standard formats.  It was designed for use with btcd, but should be
 general enough for other uses of elliptic curve crypto.  It was originally based
 on some initial work by ThePiachu, but has significantly diverged since then.
+
+Scalar base multiplication is accelerated by a pre-computed table that is
+embedded in the package.  For size-constrained builds, the smallbasetable build
+tag replaces it with a much smaller table that is computed at init time and
+only covers the least significant bytes of the scalar, at the cost of slower
+scalar base multiplication.
 */
 package secp256k1
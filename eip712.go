@@ -0,0 +1,50 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// eip712Digest returns the digest that is signed for EIP-712 typed structured
+// data, which is keccak256(0x19 || 0x01 || domainSeparator || structHash).
+func eip712Digest(domainSeparator, structHash [32]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte{0x19, 0x01})
+	h.Write(domainSeparator[:])
+	h.Write(structHash[:])
+	return h.Sum(nil)
+}
+
+// RecoverEIP712 recovers the public key of the signer of EIP-712 typed
+// structured data from its domain separator, the hash of the signed struct, and
+// the 65-byte signature in the Ethereum [R || S || V] format.  The recovery id
+// V may be either 0 or 1, or 27 or 28 as commonly produced by Ethereum
+// wallets.
+func RecoverEIP712(domainSeparator, structHash [32]byte, sig65 []byte) (*PublicKey, error) {
+	if len(sig65) != 65 {
+		return nil, fmt.Errorf("malformed signature: got %d bytes, want 65",
+			len(sig65))
+	}
+	v := sig65[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return nil, fmt.Errorf("invalid signature recovery id %d",
+			sig65[64])
+	}
+
+	// Convert the signature to the compact format used by RecoverCompact,
+	// which is the recovery id offset by 27 followed by R and S.
+	var compact [65]byte
+	compact[0] = 27 + v
+	copy(compact[1:], sig65[:64])
+	digest := eip712Digest(domainSeparator, structHash)
+	pubKey, _, err := RecoverCompact(S256(), compact[:], digest)
+	return pubKey, err
+}
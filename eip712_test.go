@@ -0,0 +1,78 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// TestRecoverEIP712 ensures the signer of the example typed data in the EIP-712
+// specification is recovered from its signature.
+func TestRecoverEIP712(t *testing.T) {
+	// keccak256 returns the legacy Keccak-256 hash of the passed data.
+	keccak256 := func(data []byte) []byte {
+		h := sha3.NewLegacyKeccak256()
+		h.Write(data)
+		return h.Sum(nil)
+	}
+
+	// The example Mail message from the specification, which is signed by
+	// the private key keccak256("cow") with the address
+	// 0xcd2a3d9f938e13cd947ec05abc7fe734df8dd826.
+	var domainSeparator, structHash [32]byte
+	copy(domainSeparator[:], decodeHex("f2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f"))
+	copy(structHash[:], decodeHex("c52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e"))
+	wantDigest := decodeHex("be609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2")
+	sig := decodeHex("4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d" +
+		"07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b91562" +
+		"1c")
+	wantAddr := decodeHex("cd2a3d9f938e13cd947ec05abc7fe734df8dd826")
+	_, wantPubKey := PrivKeyFromBytes(S256(), keccak256([]byte("cow")))
+
+	if digest := eip712Digest(domainSeparator, structHash); !bytes.Equal(digest, wantDigest) {
+		t.Fatalf("mismatched digest - got %x, want %x", digest, wantDigest)
+	}
+
+	// Both the 27/28 and 0/1 forms of the recovery id must be accepted.
+	sigV01 := append([]byte(nil), sig...)
+	sigV01[64] -= 27
+	for _, s := range [][]byte{sig, sigV01} {
+		pubKey, err := RecoverEIP712(domainSeparator, structHash, s)
+		if err != nil {
+			t.Fatalf("failed to recover signer (v = %d): %v", s[64], err)
+		}
+		if !pubKey.IsEqual(wantPubKey) {
+			t.Fatalf("mismatched signer (v = %d) - got %x, want %x", s[64],
+				pubKey.SerializeCompressed(),
+				wantPubKey.SerializeCompressed())
+		}
+		addr := keccak256(pubKey.SerializeUncompressed()[1:])[12:]
+		if !bytes.Equal(addr, wantAddr) {
+			t.Fatalf("mismatched signer address (v = %d) - got %x, "+
+				"want %x", s[64], addr, wantAddr)
+		}
+	}
+
+	// A different struct hash must recover a different signer.
+	structHash[0] ^= 0x01
+	pubKey, err := RecoverEIP712(domainSeparator, structHash, sig)
+	if err == nil && pubKey.IsEqual(wantPubKey) {
+		t.Fatal("recovered the signer for modified typed data")
+	}
+	structHash[0] ^= 0x01
+
+	// Invalid recovery ids and signature lengths must be rejected.
+	badV := append([]byte(nil), sig...)
+	badV[64] = 29
+	if _, err := RecoverEIP712(domainSeparator, structHash, badV); err == nil {
+		t.Fatal("signature with recovery id 29 was accepted")
+	}
+	if _, err := RecoverEIP712(domainSeparator, structHash, sig[:64]); err == nil {
+		t.Fatal("64-byte signature was accepted")
+	}
+}
@@ -55,6 +55,7 @@ package secp256k1
 // counts.
 
 import (
+	"crypto/subtle"
 	"encoding/hex"
 )
 
@@ -229,6 +230,34 @@ func (f *fieldVal) SetByteSlice(b []byte) *fieldVal {
 	return f.SetBytes(&b32)
 }
 
+// SetByteSliceChecked packs the passed big-endian value into the internal field
+// value representation like SetByteSlice, but also returns whether or not the
+// value is less than the field prime.  Unlike SetByteSlice, slices longer than
+// 32 bytes are accepted as long as the excess leading bytes are zero.
+//
+// When false is returned, the field value is set to the value reduced modulo
+// the prime when it fits in 32 bytes and to zero otherwise.  Callers parsing
+// untrusted data such as the coordinates of public keys should reject the
+// input in that case rather than accept the reduced equivalent.
+func (f *fieldVal) SetByteSliceChecked(b []byte) bool {
+	for len(b) > 32 {
+		if b[0] != 0 {
+			f.Zero()
+			return false
+		}
+		b = b[1:]
+	}
+	f.SetByteSlice(b)
+
+	// The words of the unpacked value don't use any of the overflow bits,
+	// so normalizing only changes them when the value is reduced.
+	var normalized fieldVal
+	normalized.Set(f).Normalize()
+	overflow := normalized.n != f.n
+	f.Set(&normalized)
+	return !overflow
+}
+
 // SetHex decodes the passed big-endian hex string into the internal field value
 // representation.  Only the first 32-bytes are used.
 //
@@ -426,7 +455,36 @@ func (f *fieldVal) Bytes() *[32]byte {
 	return b
 }
 
+// PutBytesUnchecked unpacks the field value to a 32-byte big-endian value in
+// the first 32 bytes of the passed slice.  Values with leading zero bytes are
+// zero padded, unlike the output of big.Int.Bytes, so the result can be used
+// directly in fixed width encodings such as SEC1 public keys.
+//
+// Unlike PutBytes, a normalized copy of the field value is serialized, so the
+// field value need not be normalized and is not modified.  The length of the
+// slice is not checked, so it must be at least 32 bytes or this will panic.
+func (f *fieldVal) PutBytesUnchecked(b []byte) {
+	var normalized fieldVal
+	var b32 [32]byte
+	normalized.Set(f).Normalize()
+	normalized.PutBytes(&b32)
+	copy(b[:32], b32[:])
+}
+
+// Bytes32 returns the field value as a 32-byte big-endian array.  Like
+// PutBytesUnchecked, the field value need not be normalized and is not
+// modified.
+func (f *fieldVal) Bytes32() [32]byte {
+	var b [32]byte
+	f.PutBytesUnchecked(b[:])
+	return b
+}
+
 // IsZero returns whether or not the field value is equal to zero.
+//
+// The field value must be normalized for this function to return the correct
+// result.  In particular, the prime itself is an un-normalized representation
+// of zero that is not reported as such until it is normalized.
 func (f *fieldVal) IsZero() bool {
 	// The value can only be zero if no bits are set in any of the words.
 	// This is a constant time implementation.
@@ -436,6 +494,19 @@ func (f *fieldVal) IsZero() bool {
 	return bits == 0
 }
 
+// NormalizesToZero returns whether or not the field value is congruent to
+// zero modulo the field prime.  Unlike IsZero, the field value need not be
+// normalized, so un-normalized representations of zero such as the prime or
+// twice the prime are reported as zero as well.  The field value itself is not
+// modified.
+//
+// This normalizes a copy of the field value, so callers that already have a
+// normalized value should prefer the faster IsZero.
+func (f *fieldVal) NormalizesToZero() bool {
+	var t fieldVal
+	return t.Set(f).Normalize().IsZero()
+}
+
 // IsOdd returns whether or not the field value is an odd number.
 //
 // The field value must be normalized for this function to return correct
@@ -448,6 +519,11 @@ func (f *fieldVal) IsOdd() bool {
 // Equals returns whether or not the two field values are the same.  Both
 // field values being compared must be normalized for this function to return
 // the correct result.
+//
+// All words are always compared, so the time taken does not depend on the
+// values and this is suitable for comparing values derived from secret data.
+// See equalsFlag for a variant that returns the result as a flag for use with
+// conditionalSet, which avoids branching on the result as well.
 func (f *fieldVal) Equals(val *fieldVal) bool {
 	// Xor only sets bits when they are different, so the two field values
 	// can only be the same if no bits are set after xoring each word.
@@ -460,6 +536,71 @@ func (f *fieldVal) Equals(val *fieldVal) bool {
 	return bits == 0
 }
 
+// Cmp compares the two field values and returns -1 when f is less than val, 0
+// when they are equal, and +1 when f is greater than val, like big.Int.Cmp.
+// Both values are normalized before being compared, so they may have any
+// magnitude, and neither of them is modified.
+//
+// This function is NOT constant time.
+func (f *fieldVal) Cmp(val *fieldVal) int {
+	a, b := *f, *val
+	a.Normalize()
+	b.Normalize()
+
+	// The words of a normalized value are ordered from least to most
+	// significant, so compare them starting with the most significant one.
+	for i := len(a.n) - 1; i >= 0; i-- {
+		switch {
+		case a.n[i] < b.n[i]:
+			return -1
+		case a.n[i] > b.n[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// isZeroFlag returns 1 when the field value is zero and 0 otherwise in constant
+// time.  The field value must be normalized for this function to return the
+// correct result.
+func (f *fieldVal) isZeroFlag() uint32 {
+	bits := f.n[0] | f.n[1] | f.n[2] | f.n[3] | f.n[4] |
+		f.n[5] | f.n[6] | f.n[7] | f.n[8] | f.n[9]
+
+	// The words of a normalized value are at most 26 bits, so the result
+	// fits in an int32.
+	return uint32(subtle.ConstantTimeEq(int32(bits), 0))
+}
+
+// equalsFlag returns 1 when the two field values are the same and 0 otherwise
+// in constant time.  Both field values must be normalized for this function to
+// return the correct result.
+func (f *fieldVal) equalsFlag(val *fieldVal) uint32 {
+	bits := (f.n[0] ^ val.n[0]) | (f.n[1] ^ val.n[1]) | (f.n[2] ^ val.n[2]) |
+		(f.n[3] ^ val.n[3]) | (f.n[4] ^ val.n[4]) | (f.n[5] ^ val.n[5]) |
+		(f.n[6] ^ val.n[6]) | (f.n[7] ^ val.n[7]) | (f.n[8] ^ val.n[8]) |
+		(f.n[9] ^ val.n[9])
+	return uint32(subtle.ConstantTimeEq(int32(bits), 0))
+}
+
+// conditionalSet sets the field value equal to the passed value when flag is 1
+// and leaves it unchanged when flag is 0.  The flag must be either 0 or 1.  The
+// time taken and the memory accessed are the same regardless of the flag.
+func (f *fieldVal) conditionalSet(val *fieldVal, flag uint32) {
+	// mask is all ones when flag is 1 and all zeros when it is 0.
+	mask := -flag
+	f.n[0] = f.n[0]&^mask | val.n[0]&mask
+	f.n[1] = f.n[1]&^mask | val.n[1]&mask
+	f.n[2] = f.n[2]&^mask | val.n[2]&mask
+	f.n[3] = f.n[3]&^mask | val.n[3]&mask
+	f.n[4] = f.n[4]&^mask | val.n[4]&mask
+	f.n[5] = f.n[5]&^mask | val.n[5]&mask
+	f.n[6] = f.n[6]&^mask | val.n[6]&mask
+	f.n[7] = f.n[7]&^mask | val.n[7]&mask
+	f.n[8] = f.n[8]&^mask | val.n[8]&mask
+	f.n[9] = f.n[9]&^mask | val.n[9]&mask
+}
+
 // NegateVal negates the passed value and stores the result in f.  The caller
 // must provide the magnitude of the passed value for a correct result.
 //
@@ -507,6 +648,42 @@ func (f *fieldVal) Negate(magnitude uint32) *fieldVal {
 	return f.NegateVal(f, magnitude)
 }
 
+// NegateAuto negates the field value without requiring the caller to track its
+// magnitude.  The existing field value is normalized first, so it is modified
+// even when the result is not used.  The magnitude of the result is 2, so it
+// must be normalized before comparisons or serialization.  Callers that know
+// the magnitude should prefer the faster Negate.
+//
+// The field value is returned to support chaining.  This enables syntax like:
+// f.NegateAuto().Normalize() so that f = -f in normalized form.
+func (f *fieldVal) NegateAuto() *fieldVal {
+	return f.Normalize().Negate(1)
+}
+
+// CondNegate negates the field value when flag is nonzero and leaves it
+// unchanged otherwise.  The existing field value is modified.  The caller must
+// provide the magnitude of the field value for a correct result, and the
+// result must be treated as having a magnitude one larger than that, as is the
+// case for Negate, regardless of the flag.
+//
+// This is a constant time implementation.  The negation is always computed and
+// then selected with a mask derived from the flag, so neither the time taken
+// nor the memory accessed depend on the flag.
+//
+// The field value is returned to support chaining.  This enables syntax like:
+// f.CondNegate(odd, 1).Add(f2) so that f = ±f + f2.
+func (f *fieldVal) CondNegate(flag int, magnitude uint32) *fieldVal {
+	// isNonZero is 1 when the flag is nonzero and 0 otherwise since the
+	// most significant bit of x | -x is set for every nonzero x.
+	x := uint64(flag)
+	isNonZero := uint32((x | -x) >> 63)
+
+	var negated fieldVal
+	negated.NegateVal(f, magnitude)
+	f.conditionalSet(&negated, isNonZero)
+	return f
+}
+
 // AddInt adds the passed integer to the existing field value and stores the
 // result in f.  This is a convenience function since it is fairly common to
 // perform some arithemetic with small native integers.
@@ -569,10 +746,56 @@ func (f *fieldVal) Add2(val *fieldVal, val2 *fieldVal) *fieldVal {
 	return f
 }
 
+// Sub subtracts the passed value from the existing field value and stores the
+// result in f.  The passed value may have any magnitude since a normalized copy
+// of it is subtracted, so it is not modified.  The magnitude of the result is
+// the magnitude of the existing field value plus two.
+//
+// The field value is returned to support chaining.  This enables syntax like:
+// f.Sub(f2).AddInt(1) so that f = f - f2 + 1.
+func (f *fieldVal) Sub(val *fieldVal) *fieldVal {
+	return f.Sub2(f, val)
+}
+
+// Sub2 subtracts the second passed value from the first one and stores the
+// result in f.  The second value may have any magnitude since a normalized copy
+// of it is subtracted, so it is not modified.  The magnitude of the result is
+// the magnitude of the first value plus two.
+//
+// The field value is returned to support chaining.  This enables syntax like:
+// f3.Sub2(f, f2).AddInt(1) so that f3 = f - f2 + 1.
+func (f *fieldVal) Sub2(val *fieldVal, val2 *fieldVal) *fieldVal {
+	// Normalize a copy of the value being subtracted so its magnitude is
+	// known to be 1 regardless of what the caller passed.  Copying first
+	// also allows it to alias the result.
+	var sub fieldVal
+	sub.Set(val2).Normalize()
+
+	// Subtracting the words directly could underflow them, so add twice
+	// the prime as described in NegateVal for a magnitude of 1 before
+	// subtracting.  This keeps every word positive while leaving the
+	// result congruent to the difference of the two values.
+	f.n[0] = val.n[0] + 2*fieldPrimeWordZero - sub.n[0]
+	f.n[1] = val.n[1] + 2*fieldPrimeWordOne - sub.n[1]
+	f.n[2] = val.n[2] + 2*fieldBaseMask - sub.n[2]
+	f.n[3] = val.n[3] + 2*fieldBaseMask - sub.n[3]
+	f.n[4] = val.n[4] + 2*fieldBaseMask - sub.n[4]
+	f.n[5] = val.n[5] + 2*fieldBaseMask - sub.n[5]
+	f.n[6] = val.n[6] + 2*fieldBaseMask - sub.n[6]
+	f.n[7] = val.n[7] + 2*fieldBaseMask - sub.n[7]
+	f.n[8] = val.n[8] + 2*fieldBaseMask - sub.n[8]
+	f.n[9] = val.n[9] + 2*fieldMSBMask - sub.n[9]
+
+	return f
+}
+
 // MulInt multiplies the field value by the passed int and stores the result in
 // f.  Note that this function can overflow if multiplying the value by any of
 // the individual words exceeds a max uint32.  Therefore it is important that
-// the caller ensures no overflows will occur before using this function.
+// the caller ensures no overflows will occur before using this function.  The
+// magnitude of the result is the magnitude of the field value multiplied by
+// the passed int, so, for example, tripling a value with a magnitude of 8
+// results in a magnitude of 24 which can still be safely normalized.
 //
 // The field value is returned to support chaining.  This enables syntax like:
 // f.MulInt(2).Add(f2) so that f = 2 * f + f2.
@@ -899,6 +1122,12 @@ func (f *fieldVal) Square() *fieldVal {
 // The field value is returned to support chaining.  This enables syntax like:
 // f3.SquareVal(f).Mul(f) so that f3 = f^2 * f = f^3.
 func (f *fieldVal) SquareVal(val *fieldVal) *fieldVal {
+	// Use the alternate implementation when it is selected via build tag.
+	// This is a constant, so the branch is removed by the compiler.
+	if useInterleavedSquare {
+		return f.squareValInterleaved(val)
+	}
+
 	// This could be done with a couple of for loops and an array to store
 	// the intermediate terms, but this unrolled version is significantly
 	// faster.
@@ -1116,6 +1345,13 @@ func (f *fieldVal) SquareVal(val *fieldVal) *fieldVal {
 // Inverse finds the modular multiplicative inverse of the field value.  The
 // existing field value is modified.
 //
+// The inverse is computed as f^(p-2) with a fixed addition chain for the
+// secp256k1 prime and the squarings and multiplications involved do not branch
+// on the field value, so the time taken and the memory accessed are
+// independent of it.  This makes it suitable for values derived from secret
+// data, such as the z coordinate converted by fieldJacobianToBigAffine after a
+// scalar multiplication by a private key.  The inverse of zero is zero.
+//
 // The field value is returned to support chaining.  This enables syntax like:
 // f.Inverse().Mul(f2) so that f = f^-1 * f2.
 func (f *fieldVal) Inverse() *fieldVal {
@@ -1221,3 +1457,62 @@ func (f *fieldVal) Inverse() *fieldVal {
 	f.Square().Square().Square().Square().Square() // f = a^(2^256 - 4294968320)
 	return f.Mul(&a45)                             // f = a^(2^256 - 4294968275) = a^(p-2)
 }
+
+// SqrtVal computes the square root of the passed value modulo the field prime
+// and stores the normalized result in f.  It returns whether or not the passed
+// value is a quadratic residue, which is to say whether or not it actually has
+// a square root.  When it does not, f is set to a value whose square is the
+// negation of the passed value instead.
+//
+// Of the two square roots of a quadratic residue, the one returned is itself a
+// quadratic residue.  The parity of the result is arbitrary, so callers that
+// need a specific one, such as when decompressing public keys, must negate it
+// as needed.
+//
+// The receiver may alias the passed value.
+func (f *fieldVal) SqrtVal(val *fieldVal) bool {
+	// Since the secp256k1 prime is congruent to 3 mod 4, the square root of
+	// a quadratic residue a is a^((p+1)/4), which is the same exponent
+	// returned by QPlus1Div4.  See Inverse for the general approach.
+	//
+	// The binary representation of (p+1)/4 has 5 blocks of 1s, with lengths
+	// in the set {2, 22, 223}, so the chain builds a^(2^n - 1) for each of
+	// those lengths and uses them to form the blocks.
+	//
+	// This has a cost of 253 field squarings and 13 field multiplications.
+	var a, x2, x3, x6, x9, x11, x22, x44, x88, x176, x220, x223 fieldVal
+	a.Set(val).Normalize()
+	x2.SquareVal(&a).Mul(&a)                   // x2 = a^(2^2 - 1)
+	x3.SquareVal(&x2).Mul(&a)                  // x3 = a^(2^3 - 1)
+	x6.Set(&x3).Square().Square().Square()     // x6 = a^(2^6 - 8)
+	x6.Mul(&x3)                                // x6 = a^(2^6 - 1)
+	x9.Set(&x6).Square().Square().Square()     // x9 = a^(2^9 - 8)
+	x9.Mul(&x3)                                // x9 = a^(2^9 - 1)
+	x11.Set(&x9).Square().Square().Mul(&x2)    // x11 = a^(2^11 - 1)
+	x22.Set(&x11).squareN(11).Mul(&x11)        // x22 = a^(2^22 - 1)
+	x44.Set(&x22).squareN(22).Mul(&x22)        // x44 = a^(2^44 - 1)
+	x88.Set(&x44).squareN(44).Mul(&x44)        // x88 = a^(2^88 - 1)
+	x176.Set(&x88).squareN(88).Mul(&x88)       // x176 = a^(2^176 - 1)
+	x220.Set(&x176).squareN(44).Mul(&x44)      // x220 = a^(2^220 - 1)
+	x223.Set(&x220).Square().Square().Square() // x223 = a^(2^223 - 8)
+	x223.Mul(&x3)                              // x223 = a^(2^223 - 1)
+	f.Set(&x223).squareN(23).Mul(&x22)         // f = a^(2^246 - 2^22 - 1)
+	f.squareN(6).Mul(&x2)                      // f = a^(2^252 - 2^28 - 2^6 + 3)
+	f.Square().Square().Normalize()            // f = a^((p+1)/4)
+
+	// The result is only a square root when squaring it yields the value.
+	var check fieldVal
+	check.SquareVal(f).Normalize()
+	return check.Equals(&a)
+}
+
+// squareN squares the field value n times in a row.  The existing field value
+// is modified.
+//
+// The field value is returned to support chaining.
+func (f *fieldVal) squareN(n int) *fieldVal {
+	for i := 0; i < n; i++ {
+		f.Square()
+	}
+	return f
+}
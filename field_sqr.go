@@ -0,0 +1,132 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+// squareValInterleaved squares the passed value and stores the result in f.
+// It produces the same result as SquareVal with the same restrictions on the
+// magnitude of the passed value, but it is organized differently.
+//
+// SquareVal accumulates each column of partial products on top of the carry
+// from the previous column, which forms one long dependency chain.  This
+// variant first computes every column independently from words that are
+// loaded and doubled once up front, so the partial products for separate
+// columns can be computed in parallel by the CPU, and only then propagates the
+// carries in a single pass.  The sums can't overflow since the largest column
+// holds 5 doubled products of 29-bit words (magnitude 8), which is less than
+// 2^62.
+//
+// It is selected in place of SquareVal when building with the sqrinterleave
+// build tag.  See BenchmarkFieldSquare and BenchmarkFieldSquareInterleaved
+// for a comparison on a given machine.
+func (f *fieldVal) squareValInterleaved(val *fieldVal) *fieldVal {
+	a0, a1, a2, a3, a4 := uint64(val.n[0]), uint64(val.n[1]),
+		uint64(val.n[2]), uint64(val.n[3]), uint64(val.n[4])
+	a5, a6, a7, a8, a9 := uint64(val.n[5]), uint64(val.n[6]),
+		uint64(val.n[7]), uint64(val.n[8]), uint64(val.n[9])
+	d0, d1, d2, d3, d4 := 2*a0, 2*a1, 2*a2, 2*a3, 2*a4
+	d5, d6, d7, d8 := 2*a5, 2*a6, 2*a7, 2*a8
+
+	// Column sums for each 2^(fieldBase*i).  None of them depend on each
+	// other.
+	c0 := a0 * a0
+	c1 := d0 * a1
+	c2 := d0*a2 + a1*a1
+	c3 := d0*a3 + d1*a2
+	c4 := d0*a4 + d1*a3 + a2*a2
+	c5 := d0*a5 + d1*a4 + d2*a3
+	c6 := d0*a6 + d1*a5 + d2*a4 + a3*a3
+	c7 := d0*a7 + d1*a6 + d2*a5 + d3*a4
+	c8 := d0*a8 + d1*a7 + d2*a6 + d3*a5 + a4*a4
+	c9 := d0*a9 + d1*a8 + d2*a7 + d3*a6 + d4*a5
+	c10 := d1*a9 + d2*a8 + d3*a7 + d4*a6 + a5*a5
+	c11 := d2*a9 + d3*a8 + d4*a7 + d5*a6
+	c12 := d3*a9 + d4*a8 + d5*a7 + a6*a6
+	c13 := d4*a9 + d5*a8 + d6*a7
+	c14 := d5*a9 + d6*a8 + a7*a7
+	c15 := d6*a9 + d7*a8
+	c16 := d7*a9 + a8*a8
+	c17 := d8 * a9
+	c18 := a9 * a9
+
+	// Propagate the carries through the columns.
+	m := c0
+	t0 := m & fieldBaseMask
+	m = (m >> fieldBase) + c1
+	t1 := m & fieldBaseMask
+	m = (m >> fieldBase) + c2
+	t2 := m & fieldBaseMask
+	m = (m >> fieldBase) + c3
+	t3 := m & fieldBaseMask
+	m = (m >> fieldBase) + c4
+	t4 := m & fieldBaseMask
+	m = (m >> fieldBase) + c5
+	t5 := m & fieldBaseMask
+	m = (m >> fieldBase) + c6
+	t6 := m & fieldBaseMask
+	m = (m >> fieldBase) + c7
+	t7 := m & fieldBaseMask
+	m = (m >> fieldBase) + c8
+	t8 := m & fieldBaseMask
+	m = (m >> fieldBase) + c9
+	t9 := m & fieldBaseMask
+	m = (m >> fieldBase) + c10
+	t10 := m & fieldBaseMask
+	m = (m >> fieldBase) + c11
+	t11 := m & fieldBaseMask
+	m = (m >> fieldBase) + c12
+	t12 := m & fieldBaseMask
+	m = (m >> fieldBase) + c13
+	t13 := m & fieldBaseMask
+	m = (m >> fieldBase) + c14
+	t14 := m & fieldBaseMask
+	m = (m >> fieldBase) + c15
+	t15 := m & fieldBaseMask
+	m = (m >> fieldBase) + c16
+	t16 := m & fieldBaseMask
+	m = (m >> fieldBase) + c17
+	t17 := m & fieldBaseMask
+	m = (m >> fieldBase) + c18
+	t18 := m & fieldBaseMask
+	t19 := m >> fieldBase
+
+	// Reduce the upper terms exactly the same way SquareVal does.  See the
+	// comments there for details.
+	m = t0 + t10*15632
+	t0 = m & fieldBaseMask
+	m = (m >> fieldBase) + t1 + t10*1024 + t11*15632
+	t1 = m & fieldBaseMask
+	m = (m >> fieldBase) + t2 + t11*1024 + t12*15632
+	t2 = m & fieldBaseMask
+	m = (m >> fieldBase) + t3 + t12*1024 + t13*15632
+	t3 = m & fieldBaseMask
+	m = (m >> fieldBase) + t4 + t13*1024 + t14*15632
+	t4 = m & fieldBaseMask
+	m = (m >> fieldBase) + t5 + t14*1024 + t15*15632
+	t5 = m & fieldBaseMask
+	m = (m >> fieldBase) + t6 + t15*1024 + t16*15632
+	t6 = m & fieldBaseMask
+	m = (m >> fieldBase) + t7 + t16*1024 + t17*15632
+	t7 = m & fieldBaseMask
+	m = (m >> fieldBase) + t8 + t17*1024 + t18*15632
+	t8 = m & fieldBaseMask
+	m = (m >> fieldBase) + t9 + t18*1024 + t19*68719492368
+	t9 = m & fieldMSBMask
+	m = m >> fieldMSBBits
+
+	n := t0 + m*977
+	f.n[0] = uint32(n & fieldBaseMask)
+	n = (n >> fieldBase) + t1 + m*64
+	f.n[1] = uint32(n & fieldBaseMask)
+	f.n[2] = uint32((n >> fieldBase) + t2)
+	f.n[3] = uint32(t3)
+	f.n[4] = uint32(t4)
+	f.n[5] = uint32(t5)
+	f.n[6] = uint32(t6)
+	f.n[7] = uint32(t7)
+	f.n[8] = uint32(t8)
+	f.n[9] = uint32(t9)
+
+	return f
+}
@@ -0,0 +1,14 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build !sqrinterleave
+// +build !sqrinterleave
+
+package secp256k1
+
+// useInterleavedSquare selects the squaring implementation used by SquareVal.
+// Benchmarks on common amd64 hardware show squareValInterleaved is at best a
+// few percent faster than the default, which is within the noise, so it is
+// only enabled with the sqrinterleave build tag.
+const useInterleavedSquare = false
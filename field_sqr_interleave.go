@@ -0,0 +1,12 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build sqrinterleave
+// +build sqrinterleave
+
+package secp256k1
+
+// useInterleavedSquare selects the squaring implementation used by SquareVal.
+// The sqrinterleave build tag selects squareValInterleaved.
+const useInterleavedSquare = true
@@ -6,10 +6,23 @@
 package secp256k1
 
 import (
+	"math/big"
+	"math/rand"
 	"reflect"
 	"testing"
 )
 
+// randFieldVal returns a random non-normalized field value with the given
+// magnitude read from the passed source.
+func randFieldVal(rng *rand.Rand, magnitude uint32) *fieldVal {
+	var f fieldVal
+	for i := 0; i < fieldWords-1; i++ {
+		f.n[i] = uint32(rng.Int63n(int64(magnitude)*fieldBaseMask + 1))
+	}
+	f.n[fieldWords-1] = uint32(rng.Int63n(int64(magnitude)*fieldMSBMask + 1))
+	return &f
+}
+
 // TestSetInt ensures that setting a field value to various native integers
 // works as expected.
 func TestSetInt(t *testing.T) {
@@ -68,6 +81,63 @@ func TestIsZero(t *testing.T) {
 		t.Errorf("field claims it's not zero when it is - got %v "+
 			"(raw rawints %x)", f, f.n)
 	}
+
+	for _, nonZero := range []string{"2",
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e"} {
+
+		f.SetHex(nonZero).Normalize()
+		if f.IsZero() {
+			t.Errorf("field claims %s is zero", nonZero)
+		}
+	}
+
+	// The prime is only recognized as zero once it is normalized.
+	f.SetHex("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f")
+	if f.IsZero() {
+		t.Errorf("un-normalized prime claims to be zero (rawints %x)", f.n)
+	}
+	if !f.Normalize().IsZero() {
+		t.Errorf("normalized prime claims it's not zero - got %v", f)
+	}
+}
+
+// TestNormalizesToZero ensures un-normalized representations of zero, such as
+// the prime and twice the prime, are reported as zero without modifying the
+// field value, while other values are not.
+func TestNormalizesToZero(t *testing.T) {
+	prime := new(fieldVal).SetHex("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f")
+	twicePrime := new(fieldVal).Set(prime).Add(prime)
+	zeroSum := new(fieldVal).SetInt(5).Add(new(fieldVal).SetInt(5).Negate(1))
+	tests := []struct {
+		name     string
+		f        *fieldVal
+		expected bool
+	}{
+		{"zero", new(fieldVal), true},
+		{"prime", prime, true},
+		{"twice the prime", twicePrime, true},
+		{"5 - 5", zeroSum, true},
+		{"one", new(fieldVal).SetInt(1), false},
+		{"prime plus one", new(fieldVal).Set(prime).AddInt(1), false},
+		{"prime minus one", new(fieldVal).SetHex("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e"), false},
+	}
+
+	for _, test := range tests {
+		before := *test.f
+		if got := test.f.NormalizesToZero(); got != test.expected {
+			t.Errorf("%s: got %v, want %v", test.name, got,
+				test.expected)
+		}
+		if *test.f != before {
+			t.Errorf("%s: field value was modified", test.name)
+		}
+
+		// IsZero agrees once the value is normalized.
+		if got := test.f.Normalize().IsZero(); got != test.expected {
+			t.Errorf("%s: normalized IsZero got %v, want %v",
+				test.name, got, test.expected)
+		}
+	}
 }
 
 // TestStringer ensures the stringer returns the appropriate hex string.
@@ -322,6 +392,120 @@ func TestNormalize(t *testing.T) {
 	}
 }
 
+// TestSetByteSliceChecked ensures that SetByteSliceChecked detects values that
+// are not less than the field prime and otherwise behaves like SetByteSlice.
+func TestSetByteSliceChecked(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string // hex encoded value
+		ok   bool
+		want string // hex encoded resulting field value
+	}{
+		{"empty", "", true, "0"},
+		{"zero", "00", true, "0"},
+		{"one", "01", true, "1"},
+		{"prime-1", "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e",
+			true, "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e"},
+		{"prime", "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f",
+			false, "0"},
+		{"prime+1", "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc30",
+			false, "1"},
+		{"2^256-1", "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			false, "1000003d0"},
+		{"prime-1 with leading zero", "00fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e",
+			true, "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e"},
+		{"more than 32 bytes", "01fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e",
+			false, "0"},
+	}
+
+	for _, test := range tests {
+		var f fieldVal
+		ok := f.SetByteSliceChecked(decodeHex(test.in))
+		if ok != test.ok {
+			t.Errorf("%s: wrong result -- got %v, want %v", test.name,
+				ok, test.ok)
+			continue
+		}
+		want := new(fieldVal).SetHex(test.want).Normalize()
+		if !f.Equals(want) {
+			t.Errorf("%s: wrong field value -- got %v, want %v",
+				test.name, &f, want)
+		}
+	}
+}
+
+// TestPutBytesUnchecked ensures that serializing field values to fixed width
+// byte slices and arrays via PutBytesUnchecked and Bytes32 works as expected,
+// including for values with leading zero bytes and values that are not
+// normalized, and that the results round trip through SetBytes and
+// SetByteSlice.
+func TestPutBytesUnchecked(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string // hex encoded value
+		want string // hex encoded 32-byte serialization
+	}{
+		{"zero", "0", "0000000000000000000000000000000000000000000000000000000000000000"},
+		{"one", "1", "0000000000000000000000000000000000000000000000000000000000000001"},
+		{"leading zero bytes", "00000000ff000000000000000000000000000000000000000000000000000001",
+			"00000000ff000000000000000000000000000000000000000000000000000001"},
+		{"prime-1", "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e",
+			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e"},
+		{"prime (not normalized)", "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f",
+			"0000000000000000000000000000000000000000000000000000000000000000"},
+		{"prime+1 (not normalized)", "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc30",
+			"0000000000000000000000000000000000000000000000000000000000000001"},
+	}
+
+	for _, test := range tests {
+		f := new(fieldVal).SetHex(test.in)
+		orig := *f
+		want := decodeHex(test.want)
+
+		// Only the first 32 bytes of the slice are written.
+		b := make([]byte, 33)
+		b[32] = 0xaa
+		f.PutBytesUnchecked(b)
+		if !reflect.DeepEqual(b[:32], want) || b[32] != 0xaa {
+			t.Errorf("%s: wrong PutBytesUnchecked result -- got %x, "+
+				"want %x", test.name, b, want)
+			continue
+		}
+		got := f.Bytes32()
+		if !reflect.DeepEqual(got[:], want) {
+			t.Errorf("%s: wrong Bytes32 result -- got %x, want %x",
+				test.name, got, want)
+			continue
+		}
+		if f.n != orig.n {
+			t.Errorf("%s: field value was modified", test.name)
+			continue
+		}
+
+		// The serialization must round trip.
+		norm := new(fieldVal).Set(f).Normalize()
+		if !new(fieldVal).SetBytes(&got).Equals(norm) {
+			t.Errorf("%s: SetBytes did not round trip", test.name)
+		}
+		if !new(fieldVal).SetByteSlice(b[:32]).Equals(norm) {
+			t.Errorf("%s: SetByteSlice did not round trip", test.name)
+		}
+	}
+
+	// Random values with a random number of leading zero bytes.
+	rng := rand.New(rand.NewSource(0))
+	for i := 0; i < 100; i++ {
+		var want [32]byte
+		rng.Read(want[i%32:])
+		want[0] &= 0x7f // Ensure the value is less than the prime.
+		f := new(fieldVal).SetByteSlice(want[i%32:])
+		if got := f.Bytes32(); got != want {
+			t.Fatalf("#%d: wrong Bytes32 result -- got %x, want %x", i,
+				got, want)
+		}
+	}
+}
+
 // TestIsOdd ensures that checking if a field value IsOdd works as expected.
 func TestIsOdd(t *testing.T) {
 	tests := []struct {
@@ -335,6 +519,8 @@ func TestIsOdd(t *testing.T) {
 		{"ffffffff", true},
 		// 2^64 - 2
 		{"fffffffffffffffe", false},
+		// secp256k1 prime-1
+		{"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e", false},
 		// secp256k1 prime
 		{"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", true},
 	}
@@ -382,6 +568,34 @@ func TestEquals(t *testing.T) {
 				"got: %v\nwant: %v", i, result, test.expected)
 			continue
 		}
+		if flag := f.equalsFlag(f2) == 1; flag != test.expected {
+			t.Errorf("fieldVal.equalsFlag #%d wrong result\n"+
+				"got: %v\nwant: %v", i, flag, test.expected)
+		}
+	}
+
+	// Equals and equalsFlag must agree with comparing the serialized values
+	// for random values that differ in a single word as well as for equal
+	// ones.
+	rng := rand.New(rand.NewSource(0))
+	for i := 0; i < 1000; i++ {
+		var b [32]byte
+		rng.Read(b[:])
+		f := new(fieldVal).SetBytes(&b).Normalize()
+		f2 := new(fieldVal).Set(f)
+		if i%2 == 1 {
+			f2.n[rng.Intn(fieldWords-1)] ^= 1 << uint(rng.Intn(fieldBase))
+			f2.Normalize()
+		}
+
+		want := *f.Bytes() == *f2.Bytes()
+		if got := f.Equals(f2); got != want {
+			t.Fatalf("random #%d: Equals got %v, want %v", i, got, want)
+		}
+		if got := f.equalsFlag(f2) == 1; got != want {
+			t.Fatalf("random #%d: equalsFlag got %v, want %v", i, got,
+				want)
+		}
 	}
 }
 
@@ -433,6 +647,110 @@ func TestNegate(t *testing.T) {
 	}
 }
 
+// TestNegateAuto ensures negating random field values of various magnitudes
+// via NegateAuto, which does not require the magnitude, matches negation
+// modulo the field prime.
+func TestNegateAuto(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+
+	p := S256().P
+	for i := 0; i < 1000; i++ {
+		magnitude := uint32(i%8) + 1
+		x := randFieldVal(rng, magnitude)
+		xBytes := new(fieldVal).Set(x).Normalize().Bytes()
+		want := new(big.Int).SetBytes(xBytes[:])
+		want.Neg(want).Mod(want, p)
+
+		gotBytes := x.NegateAuto().Normalize().Bytes()
+		if got := new(big.Int).SetBytes(gotBytes[:]); got.Cmp(want) != 0 {
+			t.Fatalf("#%d: mismatched negation (magnitude %d)\ngot: "+
+				"%x\nwant: %x", i, magnitude, got, want)
+		}
+	}
+}
+
+// TestCondNegate ensures that conditionally negating field values via
+// CondNegate works as expected for both zero and nonzero flags and that the
+// resulting limbs are exactly those of the unmodified and negated values,
+// respectively.
+func TestCondNegate(t *testing.T) {
+	tests := []string{
+		"0",
+		"1",
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e",
+		"b3d9aac9c5e43910b4385b53c7e78c21d4cd5f8e683c633aed04c233efc2e120",
+		"45ee6142a7fda884211e93352ed6cb2807800e419533be723a9548823ece8312",
+	}
+	flags := []int{0, 1, -1, 2, 1 << 30, -1 << 31}
+
+	for i, in := range tests {
+		orig := new(fieldVal).SetHex(in).Normalize()
+		negated := new(fieldVal).NegateVal(orig, 1)
+		for _, flag := range flags {
+			want := orig
+			if flag != 0 {
+				want = negated
+			}
+			result := new(fieldVal).Set(orig).CondNegate(flag, 1)
+			if !reflect.DeepEqual(result.n, want.n) {
+				t.Errorf("fieldVal.CondNegate #%d flag %d wrong "+
+					"limbs\ngot: %v\nwant: %v", i, flag,
+					result.n, want.n)
+			}
+		}
+	}
+}
+
+// TestCmp ensures that ordering field values via Cmp works as expected,
+// including for the boundary values and unnormalized values.
+func TestCmp(t *testing.T) {
+	values := []string{
+		"0",
+		"1",
+		"2",
+		"3ffffff",
+		"4000000",
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2d",
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e",
+		"b3d9aac9c5e43910b4385b53c7e78c21d4cd5f8e683c633aed04c233efc2e120",
+		"45ee6142a7fda884211e93352ed6cb2807800e419533be723a9548823ece8312",
+		"b3d9aac9c5e43910b4385b53c7e78c21d4cd5f8e683c633aed04c233efc2e121",
+	}
+
+	for i, in1 := range values {
+		for j, in2 := range values {
+			f := new(fieldVal).SetHex(in1).Normalize()
+			val := new(fieldVal).SetHex(in2).Normalize()
+			want := fromHex(in1).Cmp(fromHex(in2))
+			if got := f.Cmp(val); got != want {
+				t.Errorf("fieldVal.Cmp #%d, #%d: got %d, want %d",
+					i, j, got, want)
+			}
+		}
+	}
+
+	// Unnormalized values are compared by the value they represent and
+	// are not modified.
+	p := new(fieldVal).SetHex("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f")
+	zero := new(fieldVal)
+	pLimbs := p.n
+	if got := p.Cmp(zero); got != 0 {
+		t.Errorf("fieldVal.Cmp: P compared %d to zero, want 0", got)
+	}
+	if p.n != pLimbs {
+		t.Errorf("fieldVal.Cmp: modified the receiver")
+	}
+	one := new(fieldVal).SetInt(1)
+	two := new(fieldVal).Add2(one, one)
+	if got := new(fieldVal).SetInt(3).Cmp(two); got != 1 {
+		t.Errorf("fieldVal.Cmp: 3 compared %d to 1+1, want 1", got)
+	}
+	pMinus1 := new(fieldVal).SetHex(values[6])
+	if got := pMinus1.Cmp(new(fieldVal).Add2(pMinus1, one)); got != 1 {
+		t.Errorf("fieldVal.Cmp: P-1 compared %d to P-1+1, want 1", got)
+	}
+}
+
 // TestAddInt ensures that adding an integer to field values via AddInt works as
 // expected.
 func TestAddInt(t *testing.T) {
@@ -590,6 +908,50 @@ func TestAdd2(t *testing.T) {
 	}
 }
 
+// TestSub ensures that subtracting field values via Sub and Sub2 produces the
+// same results as negating and adding for random values of various magnitudes
+// without the caller providing the magnitude of the value being subtracted.
+func TestSub(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+
+	for i := 0; i < 1000; i++ {
+		aMag, bMag := uint32(i%8)+1, uint32(i/8%8)+1
+		a, b := randFieldVal(rng, aMag), randFieldVal(rng, bMag)
+		bCopy := new(fieldVal).Set(b)
+
+		var want, got, got2 fieldVal
+		want.NegateVal(b, bMag).Add(a).Normalize()
+		got.Set(a).Sub(b).Normalize()
+		got2.Sub2(a, b).Normalize()
+		if !got.Equals(&want) {
+			t.Fatalf("#%d: mismatched Sub of %x and %x (magnitude %d)"+
+				"\ngot: %v\nwant: %v", i, a.n, b.n, bMag, got, want)
+		}
+		if !got2.Equals(&want) {
+			t.Fatalf("#%d: mismatched Sub2 of %x and %x (magnitude %d)"+
+				"\ngot: %v\nwant: %v", i, a.n, b.n, bMag, got2, want)
+		}
+		if !b.Equals(bCopy) {
+			t.Fatalf("#%d: Sub2 modified the subtracted value %x",
+				i, bCopy.n)
+		}
+
+		// The value being subtracted may alias the result.
+		got.Set(b)
+		got.Sub2(a, &got).Normalize()
+		if !got.Equals(&want) {
+			t.Fatalf("#%d: mismatched aliased Sub2 of %x and %x\n"+
+				"got: %v\nwant: %v", i, a.n, b.n, got, want)
+		}
+	}
+
+	// Subtracting a value from itself must result in zero.
+	a := randFieldVal(rng, 8)
+	if !new(fieldVal).Sub2(a, a).Normalize().IsZero() {
+		t.Fatalf("subtracting %x from itself is not zero", a.n)
+	}
+}
+
 // TestMulInt ensures that adding an integer to field values via MulInt works as
 // expected.
 func TestMulInt(t *testing.T) {
@@ -654,6 +1016,42 @@ func TestMulInt(t *testing.T) {
 	}
 }
 
+// TestMulIntRand ensures multiplying random field values of various
+// magnitudes by small integers via MulInt produces the same result as repeated
+// addition and can be normalized without overflowing.
+func TestMulIntRand(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+
+	for i := 0; i < 1000; i++ {
+		magnitude := uint32(i%8) + 1
+		x := randFieldVal(rng, magnitude)
+
+		// 3*x = x + x + x.
+		var got, want fieldVal
+		got.Set(x).MulInt(3).Normalize()
+		want.Set(x).Add(x).Add(x).Normalize()
+		if !got.Equals(&want) {
+			t.Fatalf("#%d: mismatched 3*%x (magnitude %d)\ngot: %v\n"+
+				"want: %v", i, x.n, magnitude, got, want)
+		}
+
+		// Multiply by the largest integer that keeps the magnitude of
+		// the result at 32 or below, which is well within the overflow
+		// bits provided by each word.
+		factor := 32 / magnitude
+		got.Set(x).MulInt(uint(factor)).Normalize()
+		want.Zero()
+		for j := uint32(0); j < factor; j++ {
+			want.Add(x)
+		}
+		want.Normalize()
+		if !got.Equals(&want) {
+			t.Fatalf("#%d: mismatched %d*%x (magnitude %d)\ngot: %v\n"+
+				"want: %v", i, factor, x.n, magnitude, got, want)
+		}
+	}
+}
+
 // TestMul ensures that multiplying two field valuess via Mul works as expected.
 func TestMul(t *testing.T) {
 	tests := []struct {
@@ -768,6 +1166,44 @@ func TestSquare(t *testing.T) {
 	}
 }
 
+// TestSquareInterleaved ensures the interleaved squaring variant produces the
+// same results as the default squaring and multiplication for random values
+// of various magnitudes, including the maximum supported magnitude.
+func TestSquareInterleaved(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+
+	// The largest possible value with a magnitude of 8.
+	var maxVal fieldVal
+	for i := 0; i < fieldWords-1; i++ {
+		maxVal.n[i] = 8 * fieldBaseMask
+	}
+	maxVal.n[fieldWords-1] = 8 * fieldMSBMask
+	inputs := []*fieldVal{new(fieldVal), new(fieldVal).SetInt(1), &maxVal}
+	for i := 0; i < 1000; i++ {
+		inputs = append(inputs, randFieldVal(rng, uint32(i%8)+1))
+	}
+
+	for i, in := range inputs {
+		var got, square, product fieldVal
+		got.squareValInterleaved(in)
+		square.SquareVal(in)
+		product.Mul2(in, in)
+
+		if !useInterleavedSquare && got.n != square.n {
+			t.Fatalf("#%d: mismatched raw words for %x\ngot: %x\n"+
+				"want: %x", i, in.n, got.n, square.n)
+		}
+		got.Normalize()
+		square.Normalize()
+		product.Normalize()
+		if !got.Equals(&square) || !got.Equals(&product) {
+			t.Fatalf("#%d: mismatched square of %x\ngot: %v\n"+
+				"square: %v\nproduct: %v", i, in.n, got, square,
+				product)
+		}
+	}
+}
+
 // TestInverse ensures that finding the multiplicative inverse via Inverse works
 // as expected.
 func TestInverse(t *testing.T) {
@@ -779,6 +1215,8 @@ func TestInverse(t *testing.T) {
 		{"0", "0"},
 		{"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", "0"},
 		{"0", "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f"},
+		// One is its own inverse
+		{"1", "1"},
 		// secp256k1 prime-1
 		{
 			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e",
@@ -819,4 +1257,113 @@ func TestInverse(t *testing.T) {
 			continue
 		}
 	}
+
+	// The product of random nonzero values and their inverses must be one.
+	rng := rand.New(rand.NewSource(0))
+	one := new(fieldVal).SetInt(1)
+	for i := 0; i < 1000; i++ {
+		var f fieldVal
+		for j := 0; j < fieldWords-1; j++ {
+			f.n[j] = uint32(rng.Int63n(fieldBaseMask + 1))
+		}
+		f.n[fieldWords-1] = uint32(rng.Int63n(fieldMSBMask + 1))
+		f.Normalize()
+		if f.IsZero() {
+			continue
+		}
+
+		var product fieldVal
+		product.Set(&f).Inverse().Mul(&f).Normalize()
+		if !product.Equals(one) {
+			t.Fatalf("random #%d: %v times its inverse is %v", i, &f,
+				&product)
+		}
+	}
+}
+
+// TestSqrt ensures that computing square roots via SqrtVal works as expected
+// for the y coordinates of points on the curve and that non-residues are
+// detected.
+func TestSqrt(t *testing.T) {
+	curve := S256()
+
+	// ySquared returns x^3 + 7 for the passed x coordinate.
+	ySquared := func(x *fieldVal) *fieldVal {
+		var rhs fieldVal
+		rhs.SquareVal(x).Mul(x).AddInt(7).Normalize()
+		return &rhs
+	}
+
+	// Check the generator and random points on the curve.  The result may
+	// be either the y coordinate or its negation.
+	xs := []string{curve.Gx.Text(16)}
+	ys := []string{curve.Gy.Text(16)}
+	rng := rand.New(rand.NewSource(0))
+	for i := 0; i < 100; i++ {
+		k := make([]byte, 32)
+		rng.Read(k)
+		x, y := curve.ScalarBaseMult(k)
+		xs = append(xs, x.Text(16))
+		ys = append(ys, y.Text(16))
+	}
+	for i := range xs {
+		x := new(fieldVal).SetHex(xs[i]).Normalize()
+		y := new(fieldVal).SetHex(ys[i]).Normalize()
+		negY := new(fieldVal).NegateVal(y, 1).Normalize()
+
+		var root fieldVal
+		if !root.SqrtVal(ySquared(x)) {
+			t.Fatalf("#%d: no square root for y^2 of point with x %v",
+				i, x)
+		}
+		if !root.Equals(y) && !root.Equals(negY) {
+			t.Fatalf("#%d: wrong square root -- got %v, want %v or %v",
+				i, &root, y, negY)
+		}
+
+		// The receiver may alias the value.
+		root.Set(ySquared(x)).SqrtVal(&root)
+		if !root.Equals(y) && !root.Equals(negY) {
+			t.Fatalf("#%d: wrong aliased square root -- got %v", i,
+				&root)
+		}
+
+		// Since -1 is not a square modulo the prime, the negation of a
+		// nonzero square is not one either.
+		negSquare := new(fieldVal).NegateVal(ySquared(x), 1).Normalize()
+		if root.SqrtVal(negSquare) {
+			t.Fatalf("#%d: square root found for non-residue %v", i,
+				negSquare)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		in      string // hex encoded value
+		want    string // hex encoded square root
+		residue bool
+	}{
+		{"zero", "0", "0", true},
+		{"one", "1", "1", true},
+		{"four", "4", "2", true},
+		{"prime-1", "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e", "", false},
+	}
+	for _, test := range tests {
+		in := new(fieldVal).SetHex(test.in).Normalize()
+		var root fieldVal
+		residue := root.SqrtVal(in)
+		if residue != test.residue {
+			t.Errorf("%s: wrong residue result -- got %v, want %v",
+				test.name, residue, test.residue)
+			continue
+		}
+		if !test.residue {
+			continue
+		}
+		want := new(fieldVal).SetHex(test.want).Normalize()
+		if !root.Equals(want) {
+			t.Errorf("%s: wrong square root -- got %v, want %v",
+				test.name, &root, want)
+		}
+	}
 }
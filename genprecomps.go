@@ -12,6 +12,7 @@ package main
 import (
 	"bytes"
 	"compress/zlib"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
 	"log"
@@ -37,6 +38,9 @@ func main() {
 	}
 	w.Close()
 
+	// Compute the checksum used to detect corruption of the byte points.
+	checksum := sha256.Sum256(serialized)
+
 	// Encode the compressed byte points with base64.
 	encoded := make([]byte, base64.StdEncoding.EncodedLen(compressed.Len()))
 	base64.StdEncoding.Encode(encoded, compressed.Bytes())
@@ -45,11 +49,16 @@ func main() {
 	fmt.Fprintln(fi, "// Use of this source code is governed by an ISC")
 	fmt.Fprintln(fi, "// license that can be found in the LICENSE file.")
 	fmt.Fprintln(fi)
+	fmt.Fprintln(fi, "//go:build !smallbasetable")
+	fmt.Fprintln(fi, "// +build !smallbasetable")
+	fmt.Fprintln(fi)
 	fmt.Fprintln(fi, "package secp256k1")
 	fmt.Fprintln(fi)
 	fmt.Fprintln(fi, "// Auto-generated file (see genprecomps.go)")
 	fmt.Fprintln(fi, "// DO NOT EDIT")
 	fmt.Fprintln(fi)
+	fmt.Fprintf(fi, "var secp256k1BytePointsChecksum = \"%x\"\n", checksum)
+	fmt.Fprintln(fi)
 	fmt.Fprintf(fi, "var secp256k1BytePoints = %q\n", string(encoded))
 
 	a1, b1, a2, b2 := secp256k1.S256().EndomorphismVectors()
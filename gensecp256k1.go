@@ -20,6 +20,10 @@ import (
 // real values can compile.
 var secp256k1BytePoints = ""
 
+// secp256k1BytePointsChecksum is a dummy checksum used so the code which
+// generates the real values can compile.
+var secp256k1BytePointsChecksum = ""
+
 // getDoublingPoints returns all the possible G^(2^i) for i in
 // 0..n-1 where n is the curve's bit size (256 in the case of secp256k1)
 // the coordinates are recorded as Jacobian coordinates.
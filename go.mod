@@ -5,4 +5,5 @@ go 1.12
 require (
 	github.com/btcsuite/fastsha256 v0.0.0-20160815193821-637e65642941
 	github.com/davecgh/go-spew v1.1.1
+	golang.org/x/crypto v0.0.0-20191011191535-87dc89f01550
 )
@@ -0,0 +1,43 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"encoding/binary"
+	"math/big"
+)
+
+// hashToCurveTag is the tag used to derive candidate x coordinates when
+// hashing to the curve.
+const hashToCurveTag = "secp256k1/hash_to_curve"
+
+// HashToCurve deterministically maps the passed message to a point on the
+// secp256k1 curve whose discrete logarithm with respect to the base point is
+// unknown.  This makes it suitable for deriving independent generators and
+// for protocols that hash public data to a point.
+//
+// It uses the try-and-increment method.  The candidate x coordinate is the
+// tagged hash of the message followed by a 4-byte big endian counter that
+// starts at zero, and the counter is incremented until the candidate is the x
+// coordinate of a point on the curve, of which the one with the even y
+// coordinate is returned.  Roughly half of all candidates are valid, so only
+// a couple of attempts are needed on average.
+//
+// Note that the number of attempts depends on the message, so the running
+// time is not constant and this must not be used with secret messages.
+func HashToCurve(msg []byte) *PublicKey {
+	curve := S256()
+	var counter [4]byte
+	for i := uint32(0); ; i++ {
+		binary.BigEndian.PutUint32(counter[:], i)
+		x := new(big.Int).SetBytes(TaggedHash(hashToCurveTag, msg,
+			counter[:]))
+		y, err := decompressPoint(curve, x, false)
+		if err != nil {
+			continue
+		}
+		return &PublicKey{Curve: curve, X: x, Y: y}
+	}
+}
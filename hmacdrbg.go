@@ -0,0 +1,68 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"hash"
+)
+
+// HMACDRBG is a deterministic random bit generator based on HMAC as specified
+// by NIST SP 800-90A.  It produces the same stream of bytes for the same seed,
+// which makes it suitable for deterministic schemes such as the nonce
+// generation of RFC 6979.
+//
+// Reseeding and additional input are not supported since deterministic
+// schemes have no use for them.  It is not safe for concurrent use.
+type HMACDRBG struct {
+	hash func() hash.Hash
+	k    []byte
+	v    []byte
+}
+
+// NewHMACDRBG returns a new HMAC-DRBG that uses the passed hash function and
+// is instantiated with the passed seed material.  The seed is typically the
+// concatenation of the entropy input, nonce, and personalization string.
+func NewHMACDRBG(h func() hash.Hash, seed []byte) *HMACDRBG {
+	size := h().Size()
+	d := &HMACDRBG{
+		hash: h,
+		k:    make([]byte, size),
+		v:    bytes.Repeat(oneInitializer, size),
+	}
+	d.update(seed)
+	return d
+}
+
+// update updates the internal state of the generator with the passed data
+// according to the HMAC_DRBG_Update function of NIST SP 800-90A.
+func (d *HMACDRBG) update(data []byte) {
+	for _, b := range []byte{0x00, 0x01} {
+		m := hmac.New(d.hash, d.k)
+		m.Write(d.v)
+		m.Write([]byte{b})
+		m.Write(data)
+		d.k = m.Sum(nil)
+		d.v = mac(d.hash, d.k, d.v)
+
+		// The second round is only performed when there is data.
+		if len(data) == 0 {
+			break
+		}
+	}
+}
+
+// Generate returns the next n bytes produced by the generator and updates its
+// internal state so that subsequent calls produce new bytes.
+func (d *HMACDRBG) Generate(n int) []byte {
+	out := make([]byte, 0, n+len(d.v))
+	for len(out) < n {
+		d.v = mac(d.hash, d.k, d.v)
+		out = append(out, d.v...)
+	}
+	d.update(nil)
+	return out[:n]
+}
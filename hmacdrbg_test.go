@@ -0,0 +1,63 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"testing"
+)
+
+// TestHMACDRBG ensures the HMAC-DRBG produces the expected output for test
+// vectors from the NIST CAVP HMAC_DRBG test vectors and from RFC 6979.
+func TestHMACDRBG(t *testing.T) {
+	// NIST CAVP HMAC_DRBG.rsp [SHA-256] with no prediction resistance,
+	// personalization string or additional input, COUNT = 0.  The returned
+	// bits are the output of the second call to generate.
+	seed := append(decodeHex("ca851911349384bffe89de1cbdc46e6831e44d34a4f"+
+		"b935ee285dd14b71a7488"), decodeHex("659ba96c601dc69fc902940805ec0ca8")...)
+	want := decodeHex("e528e9abf2dece54d47c7e75e5fe302149f817ea9fb4bee6f" +
+		"4199697d04d5b89d54fbb978a15b5c443c9ec21036d2460b6f73ebad0dc2ab" +
+		"a6e624abf07745bc107694bb7547bb0995f70de25d6b29e2d3011bb19d2767" +
+		"6c07162c8b5ccde0668961df86803482cb37ed6d5c0bb8d50cf1f50d476aa0" +
+		"458bdaba806f48be9dcb8")
+	drbg := NewHMACDRBG(sha256.New, seed)
+	drbg.Generate(len(want))
+	if got := drbg.Generate(len(want)); !bytes.Equal(got, want) {
+		t.Fatalf("mismatched NIST output - got %x, want %x", got, want)
+	}
+
+	// The first candidate nonce of RFC 6979 section A.2.5 for P-256 with
+	// SHA-256 and the message "sample".  The seed is the private key
+	// followed by the hash of the message reduced modulo the group order,
+	// which is already smaller than it.
+	x := decodeHex("c9afa9d845ba75166b5c215767b1d6934e50c3db36e89b127b8a622b120f6721")
+	h := sha256.Sum256([]byte("sample"))
+	if fromHex("af2bdbe1aa9b6ec1e2ade1d694f41fc71a831d0268e9891562113d8a62add1bf").
+		Cmp(elliptic.P256().Params().N) >= 0 {
+
+		t.Fatal("message hash is not reduced")
+	}
+	want = decodeHex("a6e3c57dd01abe90086538398355dd4c3b17aa873382b0f24d6129493d8aad60")
+	drbg = NewHMACDRBG(sha256.New, append(x, h[:]...))
+	if got := drbg.Generate(32); !bytes.Equal(got, want) {
+		t.Fatalf("mismatched RFC 6979 nonce - got %x, want %x", got, want)
+	}
+
+	// Generating the output in pieces must produce a different stream than
+	// generating it at once since the state is updated after each call,
+	// while instantiating with the same seed must be deterministic.
+	a := NewHMACDRBG(sha256.New, seed).Generate(64)
+	b := NewHMACDRBG(sha256.New, seed).Generate(64)
+	if !bytes.Equal(a, b) {
+		t.Fatal("output is not deterministic")
+	}
+	drbg = NewHMACDRBG(sha256.New, seed)
+	pieces := append(drbg.Generate(32), drbg.Generate(32)...)
+	if !bytes.Equal(pieces[:32], a[:32]) || bytes.Equal(pieces[32:], a[32:]) {
+		t.Fatal("state is not updated between calls to generate")
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package bigint provides the fixed-width big integer encoding shared by the
+// schnorr and musig2 packages.
+package bigint
+
+import "math/big"
+
+// Bytes32 returns the passed big integer as a 32-byte big-endian array with
+// leading zero padding.  This is the fixed-width encoding used for scalars and
+// x coordinates by Schnorr signatures and MuSig2.
+//
+// It panics when the integer is negative or does not fit in 256 bits, since
+// truncating such a value would silently produce a wrong key or signature.
+func Bytes32(v *big.Int) [32]byte {
+	if v.Sign() < 0 || v.BitLen() > 256 {
+		panic("bigint: value is negative or wider than 256 bits")
+	}
+	var b [32]byte
+	vBytes := v.Bytes()
+	copy(b[32-len(vBytes):], vBytes)
+	return b
+}
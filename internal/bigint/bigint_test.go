@@ -0,0 +1,53 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bigint
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+// TestBytes32 ensures big integers of all sizes up to 256 bits are written as
+// 32-byte big-endian arrays with leading zero padding.
+func TestBytes32(t *testing.T) {
+	max := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256),
+		big.NewInt(1))
+	tests := []*big.Int{
+		new(big.Int),
+		big.NewInt(1),
+		big.NewInt(0x0102030405060708),
+		new(big.Int).Lsh(big.NewInt(0xab), 200),
+		max,
+	}
+	for i, v := range tests {
+		vBytes := v.Bytes()
+		want := make([]byte, 32-len(vBytes), 32)
+		want = append(want, vBytes...)
+		got := Bytes32(v)
+		if !bytes.Equal(got[:], want) {
+			t.Errorf("#%d: got %x, want %x", i, got, want)
+		}
+	}
+}
+
+// TestBytes32Panics ensures negative integers and integers wider than 256 bits
+// are rejected rather than truncated.
+func TestBytes32Panics(t *testing.T) {
+	tests := []*big.Int{
+		big.NewInt(-1),
+		new(big.Int).Lsh(big.NewInt(1), 256),
+	}
+	for i, v := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("#%d: Bytes32(%x) did not panic", i, v)
+				}
+			}()
+			Bytes32(v)
+		}()
+	}
+}
@@ -0,0 +1,346 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"errors"
+	"math/big"
+)
+
+// JacobianPoint is an element of the secp256k1 group expressed in Jacobian
+// projective coordinates.  For a given affine point (x, y), the Jacobian
+// coordinates are (X, Y, Z) where x = X/Z² and y = Y/Z³.
+//
+// Working with Jacobian points directly avoids the expensive field inversion
+// needed to convert back to affine coordinates after every operation, which
+// makes it possible to chain many group operations and only pay for a single
+// conversion at the end.
+//
+// The point at infinity is represented by any point with a Z coordinate of
+// zero.  NewInfinityJacobian returns the canonical form of it where all of the
+// coordinates are zero.
+type JacobianPoint struct {
+	X, Y, Z fieldVal
+}
+
+// NewInfinityJacobian returns a new Jacobian point set to the point at
+// infinity, which is the identity element of the group.
+func NewInfinityJacobian() *JacobianPoint {
+	return new(JacobianPoint)
+}
+
+// Set sets the Jacobian point equal to the passed point.
+//
+// The point is returned to support chaining.
+func (p *JacobianPoint) Set(q *JacobianPoint) *JacobianPoint {
+	*p = *q
+	return p
+}
+
+// SetAffine sets the Jacobian point to the passed affine point (x, y).  The
+// affine point (0, 0) is treated as the point at infinity in order to match
+// the conventions used by the elliptic.Curve interface.
+//
+// The point is returned to support chaining.
+func (p *JacobianPoint) SetAffine(x, y *big.Int) *JacobianPoint {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return p.setInfinity()
+	}
+
+	p.X.SetByteSlice(x.Bytes())
+	p.Y.SetByteSlice(y.Bytes())
+	p.Z.SetInt(1)
+	return p
+}
+
+// setInfinity sets the point to the canonical representation of the point at
+// infinity.
+func (p *JacobianPoint) setInfinity() *JacobianPoint {
+	p.X.Zero()
+	p.Y.Zero()
+	p.Z.Zero()
+	return p
+}
+
+// IsInfinity returns whether or not the point is the point at infinity.  The
+// point is the point at infinity when its Z coordinate is congruent to zero.
+func (p *JacobianPoint) IsInfinity() bool {
+	return p.Z.NormalizesToZero()
+}
+
+// canonicalize normalizes the coordinates of the point and replaces any
+// representation of the point at infinity with the canonical one.
+func (p *JacobianPoint) canonicalize() *JacobianPoint {
+	p.X.Normalize()
+	p.Y.Normalize()
+	if p.Z.Normalize().IsZero() {
+		return p.setInfinity()
+	}
+	return p
+}
+
+// ToAffine returns the affine coordinates of the point as big integers.  The
+// point at infinity is returned as (0, 0).  The point itself is not modified.
+func (p *JacobianPoint) ToAffine() (*big.Int, *big.Int) {
+	if p.IsInfinity() {
+		return new(big.Int), new(big.Int)
+	}
+
+	var x, y, z fieldVal
+	x.Set(&p.X)
+	y.Set(&p.Y)
+	z.Set(&p.Z)
+	return S256().fieldJacobianToBigAffine(&x, &y, &z)
+}
+
+// JacobianPointLen is the length of a Jacobian point serialized with
+// SerializeJacobian.
+const JacobianPointLen = 96
+
+// SerializeJacobian serializes the Jacobian coordinates of the point as the
+// 32-byte big endian X, Y and Z coordinates in that order without converting
+// the point to affine coordinates.  This is useful for checkpointing long
+// computations and for debugging.
+//
+// The coordinates are normalized first, so the serialization does not capture
+// the magnitudes of the coordinates, and any representation of the point at
+// infinity is serialized as all zeros.  Since a point has many Jacobian
+// representations, two serializations of the same point may still differ in
+// general.  The point itself is not modified.
+func (p *JacobianPoint) SerializeJacobian() []byte {
+	a := *p
+	a.canonicalize()
+
+	b := make([]byte, 0, JacobianPointLen)
+	for _, f := range []*fieldVal{&a.X, &a.Y, &a.Z} {
+		b = append(b, f.Bytes()[:]...)
+	}
+	return b
+}
+
+// ParseJacobianPoint parses a Jacobian point serialized with SerializeJacobian.
+// Parsing the serialization of a point results in exactly the same normalized
+// coordinates as that point.
+//
+// An error is returned when the length is wrong, when any of the coordinates is
+// not less than the field prime, or when the coordinates do not satisfy the
+// curve equation Y² = X³ + 7Z⁶ in Jacobian coordinates.  A Z coordinate of zero
+// is only accepted along with zero X and Y coordinates, which is the canonical
+// point at infinity.
+func ParseJacobianPoint(b []byte) (*JacobianPoint, error) {
+	if len(b) != JacobianPointLen {
+		return nil, errors.New("malformed Jacobian point: wrong length")
+	}
+
+	var p JacobianPoint
+	for i, f := range []*fieldVal{&p.X, &p.Y, &p.Z} {
+		var coord [32]byte
+		copy(coord[:], b[i*32:])
+		f.SetBytes(&coord)
+
+		// Normalizing a value that is not less than the prime changes
+		// it.
+		var normalized fieldVal
+		if !normalized.Set(f).Normalize().Equals(f) {
+			return nil, errors.New("malformed Jacobian point: " +
+				"coordinate is not less than the field prime")
+		}
+	}
+
+	if p.Z.IsZero() {
+		if !p.X.IsZero() || !p.Y.IsZero() {
+			return nil, errors.New("malformed Jacobian point: " +
+				"non-canonical point at infinity")
+		}
+		return &p, nil
+	}
+
+	if !p.isOnCurve() {
+		return nil, errors.New("malformed Jacobian point: point is " +
+			"not on the curve")
+	}
+	return &p, nil
+}
+
+// isOnCurve returns whether or not the point satisfies the curve equation
+// y² = x³ + 7 in Jacobian coordinates, which is Y² = X³ + 7Z⁶ after
+// substituting x = X/Z² and y = Y/Z³ and multiplying by Z⁶.  This avoids the
+// field inversion needed to convert the point to affine coordinates.  The
+// coordinates must have a magnitude of 1.
+func (p *JacobianPoint) isOnCurve() bool {
+	var y2, x3, z2, rhs fieldVal
+	y2.SquareVal(&p.Y).Normalize()
+	x3.SquareVal(&p.X).Mul(&p.X)
+	z2.SquareVal(&p.Z)
+	rhs.SquareVal(&z2).Mul(&z2).MulInt(7).Add(&x3).Normalize()
+	return y2.Equals(&rhs)
+}
+
+// ValidateJacobian normalizes the coordinates of the passed Jacobian point,
+// which makes any representation of the point at infinity canonical, and
+// returns ErrPointNotOnCurve when the point is not on the curve.  The point at
+// infinity is valid.
+//
+// Unlike converting the point to affine coordinates and calling IsOnCurve, the
+// curve equation is checked directly in Jacobian coordinates, so no field
+// inversion is needed.  This makes it suitable for validating points built from
+// untrusted data.
+func ValidateJacobian(j *JacobianPoint) error {
+	if j.canonicalize().Z.IsZero() {
+		return nil
+	}
+	if !j.isOnCurve() {
+		return ErrPointNotOnCurve
+	}
+	return nil
+}
+
+// AddNonConst adds the passed Jacobian points together and stores the result
+// in p.  That is to say p = p1 + p2.  Either of the passed points may be the
+// point at infinity and either of them may alias p.
+//
+// The point is returned to support chaining.
+//
+// NOTE: The NonConst suffix means the running time depends on the values of
+// the points, so it must not be used with secret data.
+func (p *JacobianPoint) AddNonConst(p1, p2 *JacobianPoint) *JacobianPoint {
+	// The addition routines normalize their inputs in place, so work with
+	// copies to leave the passed points untouched.
+	a, b := *p1, *p2
+	a.canonicalize()
+	b.canonicalize()
+
+	var result JacobianPoint
+	S256().addJacobian(&a.X, &a.Y, &a.Z, &b.X, &b.Y, &b.Z, &result.X,
+		&result.Y, &result.Z)
+	*p = result
+	return p.canonicalize()
+}
+
+// DoubleNonConst doubles the passed Jacobian point and stores the result in p.
+// That is to say p = 2*p1.  Doubling the point at infinity results in the
+// point at infinity.  The passed point may alias p.
+//
+// The point is returned to support chaining.
+//
+// NOTE: The NonConst suffix means the running time depends on the value of
+// the point, so it must not be used with secret data.
+func (p *JacobianPoint) DoubleNonConst(p1 *JacobianPoint) *JacobianPoint {
+	a := *p1
+	a.canonicalize()
+
+	var result JacobianPoint
+	S256().doubleJacobian(&a.X, &a.Y, &a.Z, &result.X, &result.Y,
+		&result.Z)
+	*p = result
+	return p.canonicalize()
+}
+
+// ScalarMultNonConst multiplies the passed Jacobian point by the big endian
+// integer k and stores the result in p.  That is to say p = k*p1.  The passed
+// point may alias p.
+//
+// The point is returned to support chaining.
+//
+// NOTE: The NonConst suffix means the running time depends on the value of k
+// and the point, so it must not be used with secret data.
+func (p *JacobianPoint) ScalarMultNonConst(k []byte, p1 *JacobianPoint) *JacobianPoint {
+	a := *p1
+	if a.canonicalize().Z.IsZero() {
+		return p.setInfinity()
+	}
+
+	var result JacobianPoint
+	S256().scalarMultJacobian(k, &a.X, &a.Y, &a.Z, &result.X, &result.Y,
+		&result.Z)
+	*p = result
+	return p.canonicalize()
+}
+
+// OddMultiples returns the first count odd multiples of the passed point,
+// which are [P, 3P, 5P, ..., (2*count-1)P].  These are the pre-computed points
+// needed by windowed scalar multiplication methods such as wNAF, where each
+// nonzero digit of the scalar is odd.  Nil is returned when count is not
+// positive.
+//
+// Only a single doubling is needed to compute 2P, after which each odd
+// multiple is obtained from the previous one with a single addition.
+//
+// NOTE: The running time depends on the value of the point, so it must not be
+// used with secret points.
+func OddMultiples(point *JacobianPoint, count int) []*JacobianPoint {
+	if count <= 0 {
+		return nil
+	}
+
+	var twoP JacobianPoint
+	twoP.DoubleNonConst(point)
+
+	multiples := make([]*JacobianPoint, count)
+	multiples[0] = new(JacobianPoint).Set(point).canonicalize()
+	for i := 1; i < count; i++ {
+		multiples[i] = new(JacobianPoint).AddNonConst(multiples[i-1], &twoP)
+	}
+	return multiples
+}
+
+// constantTimeIndexEq returns 1 when the two passed table indices are equal and
+// 0 otherwise without branching on either of them.  The full width of the
+// indices is compared, so indices that only agree in their low bits, such as
+// ones that differ by 2^32, are not equal.
+func constantTimeIndexEq(i, index int) uint32 {
+	// x | -x has its most significant bit set for every nonzero x.
+	x := uint64(i) ^ uint64(index)
+	return uint32((x|-x)>>63) ^ 1
+}
+
+// selectPoint returns a copy of the point at the passed index in the table.
+// Every entry of the table is read and conditionally copied regardless of the
+// index, so neither the time taken nor the memory access pattern reveal which
+// entry was selected.  This makes it suitable for looking up points by secret
+// values, such as the windows of a private scalar.
+//
+// The point at infinity is returned when the index is not in the table.
+func selectPoint(table []JacobianPoint, index int) *JacobianPoint {
+	var result JacobianPoint
+	for i := range table {
+		flag := constantTimeIndexEq(i, index)
+		result.X.conditionalSet(&table[i].X, flag)
+		result.Y.conditionalSet(&table[i].Y, flag)
+		result.Z.conditionalSet(&table[i].Z, flag)
+	}
+	return &result
+}
+
+// PointAccumulator accumulates a linear combination of points, such as
+// k1*P1 + k2*P2 + ... + kn*Pn, one term at a time.  The running sum is kept in
+// Jacobian coordinates so the expensive conversion back to affine coordinates
+// is only done once when the result is requested, instead of once for each
+// term as is the case when summing the results of ScalarMult with Add.
+//
+// The zero value is an empty accumulator whose result is the point at
+// infinity.
+type PointAccumulator struct {
+	sum JacobianPoint
+}
+
+// AddScalarMult adds k*(x, y) to the accumulated sum where k is a big endian
+// integer.
+//
+// NOTE: The running time depends on the values of k and the point, so it must
+// not be used with secret data.
+func (a *PointAccumulator) AddScalarMult(x, y *big.Int, k []byte) {
+	var p JacobianPoint
+	p.SetAffine(x, y)
+	p.ScalarMultNonConst(k, &p)
+	a.sum.AddNonConst(&a.sum, &p)
+}
+
+// Result returns the affine coordinates of the accumulated sum.  The point at
+// infinity is returned as (0, 0).  The accumulator is not modified, so more
+// terms may be added afterwards.
+func (a *PointAccumulator) Result() (*big.Int, *big.Int) {
+	return a.sum.ToAffine()
+}
@@ -0,0 +1,412 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"math/bits"
+	"testing"
+)
+
+// TestJacobianInfinity ensures the canonical point at infinity is recognized
+// and behaves as the identity for the Jacobian point operations.
+func TestJacobianInfinity(t *testing.T) {
+	curve := S256()
+	inf := NewInfinityJacobian()
+	if !inf.IsInfinity() {
+		t.Fatal("NewInfinityJacobian is not the point at infinity")
+	}
+
+	// Any point with a Z coordinate congruent to zero is the point at
+	// infinity, including non-normalized representations.
+	var nonCanonical JacobianPoint
+	nonCanonical.X.SetInt(5)
+	nonCanonical.Y.SetInt(7)
+	nonCanonical.Z.SetHex("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f")
+	if !nonCanonical.IsInfinity() {
+		t.Fatal("point with Z = P is not the point at infinity")
+	}
+
+	var g JacobianPoint
+	g.SetAffine(curve.Gx, curve.Gy)
+	if g.IsInfinity() {
+		t.Fatal("generator is the point at infinity")
+	}
+
+	// ∞ + G = G and G + ∞ = G.
+	var sum JacobianPoint
+	for i, p := range [][2]*JacobianPoint{{inf, &g}, {&g, inf},
+		{&nonCanonical, &g}} {
+
+		sum.AddNonConst(p[0], p[1])
+		x, y := sum.ToAffine()
+		if x.Cmp(curve.Gx) != 0 || y.Cmp(curve.Gy) != 0 {
+			t.Errorf("#%d: adding infinity changed the point - got "+
+				"(%x, %x), want (%x, %x)", i, x, y, curve.Gx,
+				curve.Gy)
+		}
+	}
+
+	// ∞ + ∞ = ∞.
+	if !sum.AddNonConst(inf, &nonCanonical).IsInfinity() {
+		t.Error("sum of two points at infinity is not infinity")
+	}
+
+	// 2∞ = ∞.
+	var doubled JacobianPoint
+	if !doubled.DoubleNonConst(inf).IsInfinity() {
+		t.Error("doubling infinity is not infinity")
+	}
+	if !doubled.DoubleNonConst(&nonCanonical).IsInfinity() {
+		t.Error("doubling non-canonical infinity is not infinity")
+	}
+
+	// k∞ = ∞.
+	var product JacobianPoint
+	if !product.ScalarMultNonConst([]byte{0x05}, inf).IsInfinity() {
+		t.Error("scalar multiple of infinity is not infinity")
+	}
+
+	// G + -G = ∞ and N*G = ∞ must produce the canonical form.
+	var negG JacobianPoint
+	negG.SetAffine(curve.Gx, new(big.Int).Sub(curve.P, curve.Gy))
+	sum.AddNonConst(&g, &negG)
+	if !sum.IsInfinity() || !sum.X.IsZero() || !sum.Y.IsZero() {
+		t.Errorf("G + -G is not the canonical point at infinity: %v",
+			sum)
+	}
+	product.ScalarMultNonConst(curve.N.Bytes(), &g)
+	if !product.IsInfinity() || !product.X.IsZero() || !product.Y.IsZero() {
+		t.Errorf("N*G is not the canonical point at infinity: %v",
+			product)
+	}
+
+	// The affine form of infinity is (0, 0) and converting it back must
+	// yield infinity again.
+	x, y := inf.ToAffine()
+	if x.Sign() != 0 || y.Sign() != 0 {
+		t.Errorf("affine infinity is (%x, %x), want (0, 0)", x, y)
+	}
+	if !new(JacobianPoint).SetAffine(x, y).IsInfinity() {
+		t.Error("affine (0, 0) does not convert to infinity")
+	}
+}
+
+// TestJacobianScalarMult ensures multiplying a Jacobian point with a Z
+// coordinate other than one agrees with the affine ScalarMult.
+func TestJacobianScalarMult(t *testing.T) {
+	curve := S256()
+	k := fromHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575")
+
+	// 2G has a Z coordinate other than one.
+	var g, g2 JacobianPoint
+	g.SetAffine(curve.Gx, curve.Gy)
+	g2.DoubleNonConst(&g)
+	if g2.Z.Equals(fieldOne) {
+		t.Fatal("doubled point unexpectedly has Z = 1")
+	}
+
+	var product JacobianPoint
+	x, y := product.ScalarMultNonConst(k.Bytes(), &g2).ToAffine()
+	g2x, g2y := curve.Double(curve.Gx, curve.Gy)
+	wantX, wantY := curve.ScalarMult(g2x, g2y, k.Bytes())
+	if x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+		t.Fatalf("mismatched product - got (%x, %x), want (%x, %x)", x,
+			y, wantX, wantY)
+	}
+}
+
+// TestJacobianDoubleAndAdd ensures chaining Jacobian doublings and additions in
+// a left-to-right double-and-add loop, with a single conversion to affine
+// coordinates at the end, reproduces ScalarMult.
+func TestJacobianDoubleAndAdd(t *testing.T) {
+	curve := S256()
+	x7, y7 := curve.ScalarBaseMult([]byte{0x07})
+	scalars := []*big.Int{
+		big.NewInt(1),
+		big.NewInt(2),
+		big.NewInt(0x5a5a),
+		fromHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575"),
+		new(big.Int).Sub(curve.N, one),
+	}
+
+	for _, point := range [][2]*big.Int{{curve.Gx, curve.Gy}, {x7, y7}} {
+		var p JacobianPoint
+		p.SetAffine(point[0], point[1])
+		for _, k := range scalars {
+			acc := NewInfinityJacobian()
+			for i := k.BitLen() - 1; i >= 0; i-- {
+				acc.DoubleNonConst(acc)
+				if k.Bit(i) == 1 {
+					acc.AddNonConst(acc, &p)
+				}
+			}
+			if acc.IsInfinity() {
+				t.Fatalf("%x*(%x, %x) is the point at infinity", k,
+					point[0], point[1])
+			}
+
+			x, y := acc.ToAffine()
+			wantX, wantY := curve.ScalarMult(point[0], point[1], k.Bytes())
+			if x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+				t.Fatalf("%x*(%x, %x): got (%x, %x), want (%x, %x)",
+					k, point[0], point[1], x, y, wantX, wantY)
+			}
+		}
+	}
+}
+
+// TestOddMultiples ensures the odd multiples of a point match multiplying the
+// point by the odd scalars independently with ScalarMult.
+func TestOddMultiples(t *testing.T) {
+	curve := S256()
+
+	// Use 3G as the base so the point has a Z coordinate other than one.
+	var g, p JacobianPoint
+	g.SetAffine(curve.Gx, curve.Gy)
+	p.ScalarMultNonConst([]byte{0x03}, &g)
+	px, py := p.ToAffine()
+
+	const count = 16
+	multiples := OddMultiples(&p, count)
+	if len(multiples) != count {
+		t.Fatalf("got %d multiples, want %d", len(multiples), count)
+	}
+	for k, multiple := range multiples {
+		x, y := multiple.ToAffine()
+		wantX, wantY := curve.ScalarMult(px, py, []byte{byte(2*k + 1)})
+		if x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+			t.Fatalf("multiple %d: got (%x, %x), want (%x, %x)", 2*k+1,
+				x, y, wantX, wantY)
+		}
+	}
+
+	// The passed point must not be modified.
+	if x, y := p.ToAffine(); x.Cmp(px) != 0 || y.Cmp(py) != 0 {
+		t.Fatal("point was modified")
+	}
+
+	for _, count := range []int{0, -1} {
+		if got := OddMultiples(&p, count); got != nil {
+			t.Fatalf("count %d: got %d multiples, want nil", count,
+				len(got))
+		}
+	}
+	for i, multiple := range OddMultiples(NewInfinityJacobian(), 4) {
+		if !multiple.IsInfinity() {
+			t.Fatalf("multiple %d of infinity is not infinity", 2*i+1)
+		}
+	}
+}
+
+// TestSerializeJacobian ensures Jacobian points round trip through their
+// serialization with the same normalized coordinates and affine point and that
+// malformed serializations are rejected.
+func TestSerializeJacobian(t *testing.T) {
+	curve := S256()
+
+	// Points with Z coordinates other than one along with the point at
+	// infinity.
+	var g JacobianPoint
+	g.SetAffine(curve.Gx, curve.Gy)
+	points := []*JacobianPoint{NewInfinityJacobian(), &g}
+	for _, multiple := range OddMultiples(&g, 4)[1:] {
+		points = append(points, multiple)
+	}
+	k := fromHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575")
+	points = append(points, new(JacobianPoint).ScalarMultNonConst(k.Bytes(), &g))
+
+	for i, p := range points {
+		serialized := p.SerializeJacobian()
+		if len(serialized) != JacobianPointLen {
+			t.Fatalf("#%d: serialized length %d, want %d", i,
+				len(serialized), JacobianPointLen)
+		}
+		parsed, err := ParseJacobianPoint(serialized)
+		if err != nil {
+			t.Fatalf("#%d: unexpected error: %v", i, err)
+		}
+		want := *p
+		want.canonicalize()
+		if *parsed != want {
+			t.Fatalf("#%d: mismatched coordinates - got %v, want %v",
+				i, parsed, want)
+		}
+
+		x, y := parsed.ToAffine()
+		wantX, wantY := p.ToAffine()
+		if x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+			t.Fatalf("#%d: mismatched affine point - got (%x, %x), "+
+				"want (%x, %x)", i, x, y, wantX, wantY)
+		}
+	}
+
+	// Malformed serializations.
+	valid := points[2].SerializeJacobian()
+	notOnCurve := append([]byte(nil), valid...)
+	notOnCurve[95] ^= 0x01
+	overflow := append([]byte(nil), valid...)
+	copy(overflow[32:64], curve.P.Bytes())
+	badInfinity := make([]byte, JacobianPointLen)
+	badInfinity[31] = 1
+
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{"empty", nil},
+		{"short", valid[:95]},
+		{"long", append(append([]byte(nil), valid...), 0)},
+		{"not on curve", notOnCurve},
+		{"coordinate equal to prime", overflow},
+		{"non-canonical infinity", badInfinity},
+	}
+	for _, test := range tests {
+		if _, err := ParseJacobianPoint(test.b); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
+
+// TestValidateJacobian ensures valid points are accepted regardless of their Z
+// coordinate and that points with tampered coordinates are rejected.
+func TestValidateJacobian(t *testing.T) {
+	curve := S256()
+
+	// scaled returns the Jacobian representation of the affine point (x, y)
+	// with the passed Z coordinate, which is (x*z², y*z³, z).
+	scaled := func(x, y *big.Int, z *fieldVal) JacobianPoint {
+		var p JacobianPoint
+		var z2 fieldVal
+		z2.SquareVal(z)
+		p.X.SetByteSlice(x.Bytes()).Mul(&z2)
+		p.Y.SetByteSlice(y.Bytes()).Mul(&z2).Mul(z)
+		p.Z.Set(z)
+		return p
+	}
+
+	x7, y7 := curve.ScalarBaseMult([]byte{0x07})
+	zs := []*fieldVal{
+		new(fieldVal).SetInt(1),
+		new(fieldVal).SetInt(2),
+		new(fieldVal).SetHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575"),
+		new(fieldVal).SetHex("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e"),
+	}
+	for i, z := range zs {
+		for _, affine := range [][2]*big.Int{{curve.Gx, curve.Gy}, {x7, y7}} {
+			p := scaled(affine[0], affine[1], z)
+			if err := ValidateJacobian(&p); err != nil {
+				t.Errorf("#%d: valid point rejected: %v", i, err)
+				continue
+			}
+			if x, y := p.ToAffine(); x.Cmp(affine[0]) != 0 ||
+				y.Cmp(affine[1]) != 0 {
+				t.Errorf("#%d: validation changed the point", i)
+			}
+
+			tampered := []struct {
+				name   string
+				tamper func(q *JacobianPoint)
+			}{
+				{"X", func(q *JacobianPoint) { q.X.AddInt(1) }},
+				{"Y", func(q *JacobianPoint) { q.Y.AddInt(1) }},
+				{"Z", func(q *JacobianPoint) { q.Z.MulInt(2) }},
+			}
+			for _, test := range tampered {
+				q := p
+				test.tamper(&q)
+				if err := ValidateJacobian(&q); err != ErrPointNotOnCurve {
+					t.Errorf("#%d: tampered %s -- got %v, want %v",
+						i, test.name, err, ErrPointNotOnCurve)
+				}
+			}
+		}
+	}
+
+	// Any representation of the point at infinity is valid and made
+	// canonical.
+	var inf JacobianPoint
+	inf.X.SetInt(5)
+	inf.Y.SetInt(6)
+	if err := ValidateJacobian(&inf); err != nil {
+		t.Fatalf("point at infinity rejected: %v", err)
+	}
+	if inf != *NewInfinityJacobian() {
+		t.Fatalf("point at infinity not canonical: %v", inf)
+	}
+}
+
+// TestSelectPoint ensures the constant-time table lookup returns the correct
+// entry for every index and the point at infinity for indices that are not in
+// the table.
+func TestSelectPoint(t *testing.T) {
+	curve := S256()
+	var g JacobianPoint
+	g.SetAffine(curve.Gx, curve.Gy)
+
+	// table[i] = (i+1)*G, which have Z coordinates other than one after the
+	// first entry.
+	table := make([]JacobianPoint, 16)
+	table[0].Set(&g)
+	for i := 1; i < len(table); i++ {
+		table[i].AddNonConst(&table[i-1], &g)
+	}
+
+	for i := range table {
+		got := selectPoint(table, i)
+		if *got != table[i] {
+			t.Fatalf("index %d: got %v, want %v", i, got, table[i])
+		}
+	}
+	outOfRange := []int{-1, len(table), 1 << 20}
+	if bits.UintSize == 64 {
+		// Indices that only differ from entries of the table above the
+		// low 32 bits must not select those entries.
+		wide := uint64(1) << 32
+		outOfRange = append(outOfRange, int(wide), int(wide+3))
+	}
+	for _, index := range outOfRange {
+		if got := selectPoint(table, index); !got.IsInfinity() {
+			t.Fatalf("index %d: got %v, want the point at infinity",
+				index, got)
+		}
+	}
+}
+
+// TestPointAccumulator ensures accumulating a linear combination of points in
+// Jacobian coordinates matches summing the individually converted results of
+// ScalarMult.
+func TestPointAccumulator(t *testing.T) {
+	curve := S256()
+
+	var acc PointAccumulator
+	if x, y := acc.Result(); x.Sign() != 0 || y.Sign() != 0 {
+		t.Fatalf("empty accumulator is (%x, %x), want (0, 0)", x, y)
+	}
+
+	wantX, wantY := new(big.Int), new(big.Int)
+	for i := 0; i < 8; i++ {
+		hash := sha256.Sum256([]byte{byte(i)})
+		px, py := curve.ScalarBaseMult(hash[:])
+		k := sha256.Sum256(hash[:])
+
+		acc.AddScalarMult(px, py, k[:])
+		kpx, kpy := curve.ScalarMult(px, py, k[:])
+		wantX, wantY = curve.Add(wantX, wantY, kpx, kpy)
+
+		x, y := acc.Result()
+		if x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+			t.Fatalf("#%d: mismatched sum - got (%x, %x), want "+
+				"(%x, %x)", i, x, y, wantX, wantY)
+		}
+	}
+
+	// Adding the negation of the sum must result in the point at infinity.
+	acc.AddScalarMult(wantX, new(big.Int).Sub(curve.P, wantY), []byte{0x01})
+	if x, y := acc.Result(); x.Sign() != 0 || y.Sign() != 0 {
+		t.Fatalf("sum minus itself is (%x, %x), want (0, 0)", x, y)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// decodeKeyHex decodes a hex encoded key as typically entered by users, which
+// may be surrounded by whitespace and prefixed with 0x or 0X.
+func decodeKeyHex(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		s = s[2:]
+	}
+	if len(s)%2 != 0 {
+		return nil, fmt.Errorf("malformed hex key: odd length %d", len(s))
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("malformed hex key: %v", err)
+	}
+	return b, nil
+}
+
+// ParsePrivKeyHex parses a hex encoded 32-byte private key.  Surrounding
+// whitespace and an optional 0x prefix are ignored.  An error is returned when
+// the string is not valid hex, is not 32 bytes long, or when the private key is
+// not in the range [1, N-1].
+func ParsePrivKeyHex(s string) (*PrivateKey, error) {
+	pk, err := decodeKeyHex(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(pk) != PrivKeyBytesLen {
+		return nil, fmt.Errorf("malformed private key: got %d bytes, "+
+			"want %d", len(pk), PrivKeyBytesLen)
+	}
+	d := new(big.Int).SetBytes(pk)
+	if d.Sign() == 0 || d.Cmp(S256().N) >= 0 {
+		return nil, errors.New("private key is not in the range [1, N-1]")
+	}
+
+	priv, _ := PrivKeyFromBytes(S256(), pk)
+	return priv, nil
+}
+
+// ParsePubKeyHex parses a hex encoded public key in any of the formats
+// supported by ParsePubKey.  Surrounding whitespace and an optional 0x prefix
+// are ignored.
+func ParsePubKeyHex(s string) (*PublicKey, error) {
+	pubKeyStr, err := decodeKeyHex(s)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePubKey(pubKeyStr, S256())
+}
+
+// RecoverAndSerialize recovers the public key that produced the passed compact
+// signature of the hash, as created by SignCompact, and returns it hex encoded
+// in the compressed format when compressed is true and in the uncompressed
+// format otherwise.  This is intended for tools that display the signer of a
+// message, such as those verifying signed messages from the command line.
+//
+// The requested format takes precedence over the one indicated by the header
+// byte of the signature, which only affects the format of the key that is
+// used to derive addresses and not the key itself.  An error is returned when
+// the signature is malformed or no public key can be recovered from it.
+func RecoverAndSerialize(hash, compactSig []byte, compressed bool) (string, error) {
+	pubKey, _, err := RecoverCompact(S256(), compactSig, hash)
+	if err != nil {
+		return "", err
+	}
+	if compressed {
+		return hex.EncodeToString(pubKey.SerializeCompressed()), nil
+	}
+	return hex.EncodeToString(pubKey.SerializeUncompressed()), nil
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// TestParseKeyHex ensures hex encoded private and public keys are parsed with
+// and without 0x prefixes and surrounding whitespace and that malformed input
+// is rejected.
+func TestParseKeyHex(t *testing.T) {
+	const privHex = "eaf02ca348c524e6392655ba4d29603cd1a7347d9d65cfe93ce1ebffdca22694"
+	wantPriv, wantPub := PrivKeyFromBytes(S256(), decodeHex(privHex))
+	pubHex := hex.EncodeToString(wantPub.SerializeCompressed())
+
+	privTests := []struct {
+		name  string
+		s     string
+		valid bool
+	}{
+		{"plain", privHex, true},
+		{"0x prefix", "0x" + privHex, true},
+		{"0X prefix", "0X" + privHex, true},
+		{"surrounding whitespace", " \t0x" + privHex + "\n", true},
+		{"odd length", privHex[1:], false},
+		{"invalid character", "zz" + privHex[2:], false},
+		{"short", privHex[2:], false},
+		{"prefix only", "0x", false},
+		{"zero", "00000000000000000000000000000000" +
+			"00000000000000000000000000000000", false},
+		{"group order", "fffffffffffffffffffffffffffffffe" +
+			"baaedce6af48a03bbfd25e8cd0364141", false},
+	}
+	for _, test := range privTests {
+		priv, err := ParsePrivKeyHex(test.s)
+		if (err == nil) != test.valid {
+			t.Errorf("%s: unexpected error - got %v, want valid %v",
+				test.name, err, test.valid)
+			continue
+		}
+		if test.valid && (priv.D.Cmp(wantPriv.D) != 0 ||
+			!priv.PubKey().IsEqual(wantPub)) {
+
+			t.Errorf("%s: mismatched private key", test.name)
+		}
+	}
+
+	uncompressed := hex.EncodeToString(wantPub.SerializeUncompressed())
+	pubTests := []struct {
+		name  string
+		s     string
+		valid bool
+	}{
+		{"compressed", pubHex, true},
+		{"uncompressed", uncompressed, true},
+		{"0x prefix", "0x" + pubHex, true},
+		{"surrounding whitespace", "  " + uncompressed + " \n", true},
+		{"odd length", pubHex[1:], false},
+		{"invalid character", pubHex[:10] + "g" + pubHex[11:], false},
+		{"bad format", "05" + pubHex[2:], false},
+	}
+	for _, test := range pubTests {
+		pub, err := ParsePubKeyHex(test.s)
+		if (err == nil) != test.valid {
+			t.Errorf("%s: unexpected error - got %v, want valid %v",
+				test.name, err, test.valid)
+			continue
+		}
+		if test.valid && !pub.IsEqual(wantPub) {
+			t.Errorf("%s: mismatched public key", test.name)
+		}
+	}
+}
+
+// TestRecoverAndSerialize ensures the public key recovered from a known compact
+// signature is returned hex encoded in the requested format regardless of the
+// format indicated by the header byte, and that invalid signatures are
+// rejected.
+func TestRecoverAndSerialize(t *testing.T) {
+	hash := decodeHex("ce0677bb30baa8cf067c88db9811f4333d131bf8bcf12fe7065d211dce971008")
+	sig := decodeHex("1c90f27b8b488db00b00606796d2987f6a5f59ae62ea05effe84fef5b8" +
+		"b0e549984a691139ad57a3f0b906637673aa2f63d1f55cb1a69199d4009eea23ce" +
+		"addc93")
+	const (
+		wantCompressed = "02e32df42865e97135acfb65f3bae71bdc86f4d49150ad6a440b6" +
+			"f15878109880a"
+		wantUncompressed = "04e32df42865e97135acfb65f3bae71bdc86f4d49150ad6a44" +
+			"0b6f15878109880a0a2b2667f7e725ceea70c673093bf67663e0312623c8e091" +
+			"b13cf2c0f11ef652"
+	)
+
+	// The same key is recovered when the header byte indicates a
+	// compressed key.
+	sigCompressed := append([]byte(nil), sig...)
+	sigCompressed[0] += 4
+
+	for _, s := range [][]byte{sig, sigCompressed} {
+		got, err := RecoverAndSerialize(hash, s, true)
+		if err != nil {
+			t.Fatalf("header %d: unexpected error: %v", s[0], err)
+		}
+		if got != wantCompressed {
+			t.Errorf("header %d: got compressed key %s, want %s", s[0],
+				got, wantCompressed)
+		}
+		got, err = RecoverAndSerialize(hash, s, false)
+		if err != nil {
+			t.Fatalf("header %d: unexpected error: %v", s[0], err)
+		}
+		if got != wantUncompressed {
+			t.Errorf("header %d: got uncompressed key %s, want %s",
+				s[0], got, wantUncompressed)
+		}
+	}
+
+	if _, err := RecoverAndSerialize(hash, sig[:64], true); err == nil {
+		t.Error("expected error for truncated signature")
+	}
+	bad := append([]byte(nil), sig...)
+	copy(bad[1:33], make([]byte, 32))
+	if _, err := RecoverAndSerialize(hash, bad, true); err == nil {
+		t.Error("expected error for signature with R = 0")
+	}
+}
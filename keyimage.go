@@ -0,0 +1,33 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"errors"
+)
+
+// KeyImage returns the key image I = x*Hp(P) of the passed private key x with
+// public key P, where Hp is HashToCurve applied to the compressed
+// serialization of P.
+//
+// Key images are used by linkable ring signatures.  Since the image is
+// determined by the private key, two signatures made with the same key can be
+// linked by their images, while nobody knows the discrete logarithm of Hp(P)
+// with respect to the base point, so the image does not reveal which public
+// key it belongs to.
+//
+// An error is returned when the private key is not in the range [1, N-1].
+func KeyImage(priv *PrivateKey) (*PublicKey, error) {
+	curve := S256()
+	if priv == nil || priv.D == nil || priv.D.Sign() <= 0 ||
+		priv.D.Cmp(curve.N) >= 0 {
+
+		return nil, errors.New("private key is not in the range [1, N-1]")
+	}
+
+	hp := HashToCurve(priv.PubKey().SerializeCompressed())
+	x, y := curve.ScalarMult(hp.X, hp.Y, priv.D.Bytes())
+	return &PublicKey{Curve: curve, X: x, Y: y}, nil
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"math/big"
+	"testing"
+)
+
+// TestKeyImage ensures key images are deterministic, valid curve points, and
+// distinct for distinct keys.
+func TestKeyImage(t *testing.T) {
+	curve := S256()
+	priv1, _ := PrivKeyFromBytes(curve, decodeHex("01"))
+	priv2, _ := PrivKeyFromBytes(curve, decodeHex("eaf02ca348c524e6"+
+		"392655ba4d29603cd1a7347d9d65cfe93ce1ebffdca22694"))
+
+	image1, err := KeyImage(priv1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	image2, err := KeyImage(priv2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, image := range []*PublicKey{image1, image2} {
+		if !curve.IsOnCurve(image.X, image.Y) {
+			t.Fatalf("%d: key image is not on the curve", i)
+		}
+	}
+	if image1.IsEqual(image2) {
+		t.Fatal("different keys produced the same key image")
+	}
+
+	again, err := KeyImage(priv1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !again.IsEqual(image1) {
+		t.Fatal("key image is not deterministic")
+	}
+
+	// The image of the key 1 is the hash of its public key itself.
+	hp := HashToCurve(priv1.PubKey().SerializeCompressed())
+	if !image1.IsEqual(hp) {
+		t.Fatal("key image of 1 does not equal the hashed public key")
+	}
+
+	// Out of range keys must be rejected.
+	for _, d := range []*big.Int{new(big.Int), curve.N} {
+		priv := &PrivateKey{PublicKey: priv1.PublicKey, D: d}
+		if _, err := KeyImage(priv); err == nil {
+			t.Errorf("expected error for private key %x", d)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package musig2 implements the verification side of n-of-n MuSig2
+// multisignatures over the secp256k1 curve as specified by BIP327.
+//
+// Only untweaked key aggregation is supported.  The aggregated key of a
+// signing session is an ordinary BIP340 x-only public key, so the final
+// signature is verified with Verify, while PartialVerify checks the
+// contribution of an individual signer before the partial signatures are
+// summed.
+package musig2
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/sammyne/secp256k1"
+	"github.com/sammyne/secp256k1/internal/bigint"
+	"github.com/sammyne/secp256k1/schnorr"
+)
+
+const (
+	// keyAggListTag is the tag used to hash the list of public keys that
+	// are aggregated.
+	keyAggListTag = "KeyAgg list"
+
+	// keyAggCoeffTag is the tag used to derive the coefficient of each
+	// public key in the aggregate.
+	keyAggCoeffTag = "KeyAgg coefficient"
+
+	// nonceCoeffTag is the tag used to derive the coefficient that binds
+	// the second nonce of each signer to the session.
+	nonceCoeffTag = "MuSig/noncecoef"
+)
+
+// AggregateKey is the result of aggregating the public keys of the signers
+// of a MuSig2 session.  It retains the values needed to recompute the
+// coefficient of each individual key.
+type AggregateKey struct {
+	// Q is the aggregated public key.
+	Q *secp256k1.PublicKey
+
+	listHash []byte
+	second   [33]byte
+}
+
+// XOnly returns the 32-byte x coordinate of the aggregated public key, which
+// is the BIP340 public key that the final signature verifies against.
+func (k *AggregateKey) XOnly() [32]byte {
+	return bigint.Bytes32(k.Q.X)
+}
+
+// coefficient returns the key aggregation coefficient of the passed
+// compressed public key.  The second distinct key in the list gets the
+// coefficient one, which saves a scalar multiplication without affecting
+// security.
+func (k *AggregateKey) coefficient(pubKey [33]byte) *big.Int {
+	if pubKey == k.second {
+		return big.NewInt(1)
+	}
+	h := secp256k1.TaggedHash(keyAggCoeffTag, k.listHash, pubKey[:])
+	a := new(big.Int).SetBytes(h)
+	return a.Mod(a, secp256k1.S256().N)
+}
+
+// AggregateKeys aggregates the passed compressed public keys, in the order
+// given, into a single key as Q = a_1*P_1 + ... + a_n*P_n where each a_i is
+// the coefficient derived from the hash of the whole list and P_i itself.
+//
+// An error naming the offending signer is returned when any of the keys is
+// not a valid compressed public key, and an error is returned when the
+// aggregated key is the point at infinity.
+func AggregateKeys(pubKeys [][33]byte) (*AggregateKey, error) {
+	if len(pubKeys) == 0 {
+		return nil, fmt.Errorf("no public keys to aggregate")
+	}
+
+	curve := secp256k1.S256()
+	points := make([]*secp256k1.PublicKey, len(pubKeys))
+	for i := range pubKeys {
+		x, y, err := parseCompressed(pubKeys[i][:], false)
+		if err != nil {
+			return nil, fmt.Errorf("signer %d provided an invalid "+
+				"public key: %v", i, err)
+		}
+		points[i] = &secp256k1.PublicKey{Curve: curve, X: x, Y: y}
+	}
+
+	key := new(AggregateKey)
+	var list bytes.Buffer
+	for i := range pubKeys {
+		list.Write(pubKeys[i][:])
+	}
+	key.listHash = secp256k1.TaggedHash(keyAggListTag, list.Bytes())
+	for i := 1; i < len(pubKeys); i++ {
+		if pubKeys[i] != pubKeys[0] {
+			key.second = pubKeys[i]
+			break
+		}
+	}
+
+	qx, qy := new(big.Int), new(big.Int)
+	for i, point := range points {
+		a := key.coefficient(pubKeys[i])
+		x, y := curve.ScalarMult(point.X, point.Y, a.Bytes())
+		qx, qy = curve.Add(qx, qy, x, y)
+	}
+	if qx.Sign() == 0 && qy.Sign() == 0 {
+		return nil, fmt.Errorf("aggregated public key is the point at " +
+			"infinity")
+	}
+	key.Q = &secp256k1.PublicKey{Curve: curve, X: qx, Y: qy}
+	return key, nil
+}
+
+// Verify returns whether or not the passed 64-byte signature is a valid
+// MuSig2 signature of the message for the x-only aggregated public key.
+// Since MuSig2 signatures are indistinguishable from single signer ones, this
+// is exactly BIP340 verification.
+func Verify(aggPubX, msg [32]byte, sig [64]byte) bool {
+	return schnorr.Verify(aggPubX, msg, sig)
+}
+
+// PartialVerify returns whether or not the passed partial signature is a
+// valid contribution of the signer with the compressed public key signerKey
+// to the MuSig2 signature of the message for the aggregated key.  pubNonce is
+// the public nonce of the signer and aggNonce the aggregate of the public
+// nonces of all signers, each of which is the concatenation of two compressed
+// points.  The halves of the aggregate nonce may be 33 zero bytes to encode
+// the point at infinity.
+//
+// With b the nonce coefficient, R = R_1 + b*R_2 the final nonce of the
+// session, e the BIP340 challenge of R, Q and the message, a the key
+// aggregation coefficient of the signer and g either 1 or -1 depending on
+// whether Q has an even y coordinate, the partial signature s is valid when
+//
+//	s*G = R'_1 + b*R'_2 + e*a*g*P
+//
+// where R'_1 and R'_2 are the nonces of the signer, negated when R has an odd
+// y coordinate.
+//
+// A false result with a nil error means the partial signature is invalid.  An
+// error is returned when the nonces or the public key of the signer are
+// malformed, which identifies the signer as faulty rather than the signature.
+func PartialVerify(partialSig [32]byte, pubNonce, aggNonce [66]byte,
+	aggKey *AggregateKey, signerKey [33]byte, msg []byte) (bool, error) {
+
+	curve := secp256k1.S256()
+	pubX, pubY, err := parseCompressed(signerKey[:], false)
+	if err != nil {
+		return false, fmt.Errorf("invalid signer public key: %v", err)
+	}
+	r1x, r1y, err := parseCompressed(pubNonce[:33], false)
+	if err != nil {
+		return false, fmt.Errorf("invalid public nonce: %v", err)
+	}
+	r2x, r2y, err := parseCompressed(pubNonce[33:], false)
+	if err != nil {
+		return false, fmt.Errorf("invalid public nonce: %v", err)
+	}
+	agg1x, agg1y, err := parseCompressed(aggNonce[:33], true)
+	if err != nil {
+		return false, fmt.Errorf("invalid aggregate nonce: %v", err)
+	}
+	agg2x, agg2y, err := parseCompressed(aggNonce[33:], true)
+	if err != nil {
+		return false, fmt.Errorf("invalid aggregate nonce: %v", err)
+	}
+
+	s := new(big.Int).SetBytes(partialSig[:])
+	if s.Cmp(curve.N) >= 0 {
+		return false, nil
+	}
+
+	// Derive the final nonce of the session, which is replaced by the
+	// generator in the negligibly likely case it is the point at infinity.
+	qX := aggKey.XOnly()
+	h := secp256k1.TaggedHash(nonceCoeffTag, aggNonce[:], qX[:], msg)
+	b := new(big.Int).SetBytes(h)
+	b.Mod(b, curve.N)
+	x, y := curve.ScalarMult(agg2x, agg2y, b.Bytes())
+	rx, ry := curve.Add(agg1x, agg1y, x, y)
+	if rx.Sign() == 0 && ry.Sign() == 0 {
+		rx, ry = curve.Gx, curve.Gy
+	}
+
+	rX := bigint.Bytes32(rx)
+	h = secp256k1.TaggedHash(schnorr.ChallengeTag, rX[:], qX[:], msg)
+	e := new(big.Int).SetBytes(h)
+	e.Mod(e, curve.N)
+
+	// The effective nonce of the signer, negated along with the final
+	// nonce when the latter has an odd y coordinate.
+	x, y = curve.ScalarMult(r2x, r2y, b.Bytes())
+	rex, rey := curve.Add(r1x, r1y, x, y)
+	if ry.Bit(0) == 1 && rey.Sign() != 0 {
+		rey = new(big.Int).Sub(curve.P, rey)
+	}
+
+	// The challenge is multiplied by the coefficient of the signer and
+	// negated when the aggregated key has an odd y coordinate.
+	ea := new(big.Int).Mul(e, aggKey.coefficient(signerKey))
+	if aggKey.Q.Y.Bit(0) == 1 {
+		ea.Neg(ea)
+	}
+	ea.Mod(ea, curve.N)
+
+	lhsX, lhsY := curve.ScalarBaseMult(s.Bytes())
+	x, y = curve.ScalarMult(pubX, pubY, ea.Bytes())
+	rhsX, rhsY := curve.Add(rex, rey, x, y)
+	return lhsX.Cmp(rhsX) == 0 && lhsY.Cmp(rhsY) == 0, nil
+}
+
+// parseCompressed parses a 33-byte compressed point such as a public key or
+// one half of a nonce.  When allowInfinity
+// is true, 33 zero bytes are accepted as the encoding of the point at
+// infinity, which is returned as (0, 0).
+func parseCompressed(b []byte, allowInfinity bool) (*big.Int, *big.Int, error) {
+	if allowInfinity && bytes.Equal(b, make([]byte, 33)) {
+		return new(big.Int), new(big.Int), nil
+	}
+	if b[0] != 0x02 && b[0] != 0x03 {
+		return nil, nil, fmt.Errorf("invalid point format byte 0x%02x",
+			b[0])
+	}
+	point, err := secp256k1.ParsePubKey(b, secp256k1.S256())
+	if err != nil {
+		return nil, nil, err
+	}
+	return point.X, point.Y, nil
+}
@@ -0,0 +1,275 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package musig2
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/sammyne/secp256k1"
+)
+
+// The test vectors in the testdata directory are the reference vectors of
+// BIP327.  Only the cases that do not involve tweaks are exercised.
+
+// loadVectors reads the named JSON file from the testdata directory and
+// decodes it into v.
+func loadVectors(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("failed to decode %s: %v", name, err)
+	}
+}
+
+// decodeHex decodes the passed hex string and panics on failure.  It is only
+// intended for use with hard-coded test vectors.
+func decodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic("invalid hex in test source: " + s)
+	}
+	return b
+}
+
+// pubKeyList returns the compressed public keys selected by indices.  Entries
+// that are not 33 bytes long are zero padded so that they fail to parse.
+func pubKeyList(pubKeys []string, indices []int) [][33]byte {
+	keys := make([][33]byte, len(indices))
+	for i, idx := range indices {
+		copy(keys[i][:], decodeHex(pubKeys[idx]))
+	}
+	return keys
+}
+
+// nonce66 returns the passed hex encoded nonce as a 66-byte array, zero
+// padding it when it is shorter.
+func nonce66(s string) [66]byte {
+	var n [66]byte
+	copy(n[:], decodeHex(s))
+	return n
+}
+
+// TestAggregateKeys ensures key aggregation produces the expected x-only keys
+// and rejects invalid public keys.
+func TestAggregateKeys(t *testing.T) {
+	var vectors struct {
+		PubKeys    []string `json:"pubkeys"`
+		ValidCases []struct {
+			KeyIndices []int  `json:"key_indices"`
+			Expected   string `json:"expected"`
+		} `json:"valid_test_cases"`
+		ErrorCases []struct {
+			KeyIndices   []int  `json:"key_indices"`
+			TweakIndices []int  `json:"tweak_indices"`
+			Comment      string `json:"comment"`
+		} `json:"error_test_cases"`
+	}
+	loadVectors(t, "key_agg_vectors.json", &vectors)
+
+	for i, test := range vectors.ValidCases {
+		aggKey, err := AggregateKeys(pubKeyList(vectors.PubKeys,
+			test.KeyIndices))
+		if err != nil {
+			t.Errorf("valid case #%d: unexpected error: %v", i, err)
+			continue
+		}
+		got := aggKey.XOnly()
+		if want := decodeHex(test.Expected); string(got[:]) != string(want) {
+			t.Errorf("valid case #%d: mismatched key -- got %x, want %x",
+				i, got, want)
+		}
+	}
+
+	for _, test := range vectors.ErrorCases {
+		if len(test.TweakIndices) != 0 {
+			continue
+		}
+		_, err := AggregateKeys(pubKeyList(vectors.PubKeys, test.KeyIndices))
+		if err == nil {
+			t.Errorf("%s: expected error", test.Comment)
+		}
+	}
+}
+
+// TestPartialVerify ensures partial signatures are verified against the
+// reference vectors, including those that must fail to verify and those with
+// malformed contributions.
+func TestPartialVerify(t *testing.T) {
+	var vectors struct {
+		SecKey     string   `json:"sk"`
+		PubKeys    []string `json:"pubkeys"`
+		PubNonces  []string `json:"pnonces"`
+		AggNonces  []string `json:"aggnonces"`
+		Msgs       []string `json:"msgs"`
+		ValidCases []struct {
+			KeyIndices    []int  `json:"key_indices"`
+			NonceIndices  []int  `json:"nonce_indices"`
+			AggNonceIndex int    `json:"aggnonce_index"`
+			MsgIndex      int    `json:"msg_index"`
+			SignerIndex   int    `json:"signer_index"`
+			Expected      string `json:"expected"`
+		} `json:"valid_test_cases"`
+		FailCases []struct {
+			Sig          string `json:"sig"`
+			KeyIndices   []int  `json:"key_indices"`
+			NonceIndices []int  `json:"nonce_indices"`
+			MsgIndex     int    `json:"msg_index"`
+			SignerIndex  int    `json:"signer_index"`
+			Comment      string `json:"comment"`
+		} `json:"verify_fail_test_cases"`
+		ErrorCases []struct {
+			Sig          string `json:"sig"`
+			KeyIndices   []int  `json:"key_indices"`
+			NonceIndices []int  `json:"nonce_indices"`
+			MsgIndex     int    `json:"msg_index"`
+			SignerIndex  int    `json:"signer_index"`
+			Comment      string `json:"comment"`
+		} `json:"verify_error_test_cases"`
+	}
+	loadVectors(t, "sign_verify_vectors.json", &vectors)
+
+	// The partial signatures in the vectors are all produced with the
+	// secret key of the vectors.
+	_, pub := secp256k1.PrivKeyFromBytes(secp256k1.S256(),
+		decodeHex(vectors.SecKey))
+	var signerKey [33]byte
+	copy(signerKey[:], pub.SerializeCompressed())
+
+	for i, test := range vectors.ValidCases {
+		aggKey, err := AggregateKeys(pubKeyList(vectors.PubKeys,
+			test.KeyIndices))
+		if err != nil {
+			t.Errorf("valid case #%d: unexpected error: %v", i, err)
+			continue
+		}
+		var psig [32]byte
+		copy(psig[:], decodeHex(test.Expected))
+		pubNonce := nonce66(vectors.PubNonces[test.NonceIndices[test.SignerIndex]])
+		aggNonce := nonce66(vectors.AggNonces[test.AggNonceIndex])
+		msg := decodeHex(vectors.Msgs[test.MsgIndex])
+
+		ok, err := PartialVerify(psig, pubNonce, aggNonce, aggKey,
+			signerKey, msg)
+		if err != nil || !ok {
+			t.Errorf("valid case #%d: failed to verify (err %v)", i, err)
+		}
+	}
+
+	// The fail and error cases do not name the aggregate nonce, which is the
+	// first one of the vectors for all of them.
+	aggNonce := nonce66(vectors.AggNonces[0])
+	for _, test := range vectors.FailCases {
+		keys := pubKeyList(vectors.PubKeys, test.KeyIndices)
+		aggKey, err := AggregateKeys(keys)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.Comment, err)
+			continue
+		}
+		var psig [32]byte
+		copy(psig[:], decodeHex(test.Sig))
+		pubNonce := nonce66(vectors.PubNonces[test.NonceIndices[test.SignerIndex]])
+		msg := decodeHex(vectors.Msgs[test.MsgIndex])
+
+		ok, err := PartialVerify(psig, pubNonce, aggNonce, aggKey,
+			keys[test.SignerIndex], msg)
+		if err != nil || ok {
+			t.Errorf("%s: got (%v, %v), want (false, nil)", test.Comment,
+				ok, err)
+		}
+	}
+
+	for _, test := range vectors.ErrorCases {
+		keys := pubKeyList(vectors.PubKeys, test.KeyIndices)
+		var psig [32]byte
+		copy(psig[:], decodeHex(test.Sig))
+		pubNonce := nonce66(vectors.PubNonces[test.NonceIndices[test.SignerIndex]])
+		msg := decodeHex(vectors.Msgs[test.MsgIndex])
+
+		// An invalid public key is already rejected by key aggregation.
+		aggKey, err := AggregateKeys(keys)
+		if err != nil {
+			continue
+		}
+		_, err = PartialVerify(psig, pubNonce, aggNonce, aggKey,
+			keys[test.SignerIndex], msg)
+		if err == nil {
+			t.Errorf("%s: expected error", test.Comment)
+		}
+	}
+}
+
+// TestVerify ensures the aggregated signatures of the reference vectors verify
+// against the aggregated keys and that each of the partial signatures they
+// are made of verify too.
+func TestVerify(t *testing.T) {
+	var vectors struct {
+		PubKeys    []string `json:"pubkeys"`
+		PubNonces  []string `json:"pnonces"`
+		PartialSig []string `json:"psigs"`
+		Msg        string   `json:"msg"`
+		ValidCases []struct {
+			AggNonce     string `json:"aggnonce"`
+			NonceIndices []int  `json:"nonce_indices"`
+			KeyIndices   []int  `json:"key_indices"`
+			TweakIndices []int  `json:"tweak_indices"`
+			PSigIndices  []int  `json:"psig_indices"`
+			Expected     string `json:"expected"`
+		} `json:"valid_test_cases"`
+	}
+	loadVectors(t, "sig_agg_vectors.json", &vectors)
+
+	var msg [32]byte
+	copy(msg[:], decodeHex(vectors.Msg))
+	tested := 0
+	for i, test := range vectors.ValidCases {
+		if len(test.TweakIndices) != 0 {
+			continue
+		}
+		tested++
+
+		keys := pubKeyList(vectors.PubKeys, test.KeyIndices)
+		aggKey, err := AggregateKeys(keys)
+		if err != nil {
+			t.Errorf("valid case #%d: unexpected error: %v", i, err)
+			continue
+		}
+		var sig [64]byte
+		copy(sig[:], decodeHex(test.Expected))
+		if !Verify(aggKey.XOnly(), msg, sig) {
+			t.Errorf("valid case #%d: signature failed to verify", i)
+		}
+
+		// Tampering with the message must invalidate the signature.
+		badMsg := msg
+		badMsg[0] ^= 0x01
+		if Verify(aggKey.XOnly(), badMsg, sig) {
+			t.Errorf("valid case #%d: signature verified for wrong "+
+				"message", i)
+		}
+
+		aggNonce := nonce66(test.AggNonce)
+		for j, idx := range test.PSigIndices {
+			var psig [32]byte
+			copy(psig[:], decodeHex(vectors.PartialSig[idx]))
+			pubNonce := nonce66(vectors.PubNonces[test.NonceIndices[j]])
+			ok, err := PartialVerify(psig, pubNonce, aggNonce, aggKey,
+				keys[j], msg[:])
+			if err != nil || !ok {
+				t.Errorf("valid case #%d: partial signature %d failed "+
+					"to verify (err %v)", i, j, err)
+			}
+		}
+	}
+	if tested == 0 {
+		t.Fatal("no untweaked test cases")
+	}
+}
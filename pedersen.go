@@ -0,0 +1,143 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"math/big"
+	"sync"
+)
+
+const (
+	// openingNonceTag is the tag used to deterministically derive the
+	// nonces of a commitment opening proof.
+	openingNonceTag = "Pedersen/opening/nonce"
+
+	// openingChallengeTag is the tag used to compute the challenge of a
+	// commitment opening proof.
+	openingChallengeTag = "Pedersen/opening/challenge"
+)
+
+// pedersenH is the second generator used by Pedersen commitments.  It is
+// derived by hashing the compressed base point to the curve, so nobody knows
+// its discrete logarithm with respect to the base point.
+var (
+	pedersenH     *PublicKey
+	pedersenHOnce sync.Once
+)
+
+// PedersenH returns the second generator H used by Pedersen commitments.  It
+// is the result of HashToCurve applied to the compressed serialization of the
+// base point G.
+func PedersenH() *PublicKey {
+	pedersenHOnce.Do(func() {
+		curve := S256()
+		g := PublicKey{Curve: curve, X: curve.Gx, Y: curve.Gy}
+		pedersenH = HashToCurve(g.SerializeCompressed())
+	})
+	return &PublicKey{Curve: pedersenH.Curve, X: pedersenH.X, Y: pedersenH.Y}
+}
+
+// linearCombination returns a*G + b*H where G is the base point and H is the
+// second Pedersen generator.
+func linearCombination(a, b *big.Int) (*big.Int, *big.Int) {
+	curve := S256()
+	h := PedersenH()
+	ax, ay := curve.ScalarBaseMult(a.Bytes())
+	bx, by := curve.ScalarMult(h.X, h.Y, b.Bytes())
+	return curve.Add(ax, ay, bx, by)
+}
+
+// Commit returns the Pedersen commitment value*G + blinding*H to the passed
+// value.  The commitment hides the value as long as the blinding factor is
+// random and secret, and binds the committer to it since nobody knows the
+// discrete logarithm of H.  See DeriveBlinding for a way to derive blinding
+// factors deterministically.
+func Commit(value, blinding *big.Int) *PublicKey {
+	N := S256().N
+	v := new(big.Int).Mod(value, N)
+	r := new(big.Int).Mod(blinding, N)
+	x, y := linearCombination(v, r)
+	return &PublicKey{Curve: S256(), X: x, Y: y}
+}
+
+// OpeningProof is a non-interactive proof of knowledge of the value and
+// blinding factor that open a Pedersen commitment.  It consists of the nonce
+// commitment A and the responses Z1 and Z2 for the value and blinding factor
+// respectively.
+type OpeningProof struct {
+	A  *PublicKey
+	Z1 *big.Int
+	Z2 *big.Int
+}
+
+// openingChallenge computes the challenge of an opening proof which commits to
+// the commitment and the nonce commitment.
+func openingChallenge(c, a *PublicKey) *big.Int {
+	e := new(big.Int).SetBytes(TaggedHash(openingChallengeTag,
+		c.SerializeCompressed(), a.SerializeCompressed()))
+	return e.Mod(e, S256().N)
+}
+
+// ProveOpening returns a proof that the prover knows the value and blinding
+// factor that open the commitment Commit(value, blinding) without revealing
+// either of them.
+//
+// This is a sigma protocol made non-interactive via the Fiat-Shamir heuristic.
+// The prover commits to A = k1*G + k2*H, derives the challenge
+// e = H(C || A) and responds with z1 = k1 + e*value and z2 = k2 + e*blinding.
+// The nonces are derived deterministically from the value and blinding factor.
+func ProveOpening(value, blinding *big.Int) OpeningProof {
+	curve := S256()
+	N := curve.N
+	v := new(big.Int).Mod(value, N)
+	r := new(big.Int).Mod(blinding, N)
+	c := Commit(v, r)
+
+	vBytes := paddedAppend(PrivKeyBytesLen, nil, v.Bytes())
+	rBytes := paddedAppend(PrivKeyBytesLen, nil, r.Bytes())
+	k1 := new(big.Int).SetBytes(TaggedHash(openingNonceTag, []byte{1},
+		vBytes, rBytes))
+	k1.Mod(k1, N)
+	k2 := new(big.Int).SetBytes(TaggedHash(openingNonceTag, []byte{2},
+		vBytes, rBytes))
+	k2.Mod(k2, N)
+
+	ax, ay := linearCombination(k1, k2)
+	a := &PublicKey{Curve: curve, X: ax, Y: ay}
+	e := openingChallenge(c, a)
+
+	z1 := new(big.Int).Mul(e, v)
+	z1.Add(z1, k1)
+	z1.Mod(z1, N)
+	z2 := new(big.Int).Mul(e, r)
+	z2.Add(z2, k2)
+	z2.Mod(z2, N)
+
+	return OpeningProof{A: a, Z1: z1, Z2: z2}
+}
+
+// VerifyOpening returns whether or not the passed proof shows knowledge of an
+// opening of the commitment c.  That is the case when
+// z1*G + z2*H == A + e*C where e = H(C || A).
+func VerifyOpening(c *PublicKey, proof OpeningProof) bool {
+	curve := S256()
+	if c == nil || proof.A == nil || proof.Z1 == nil || proof.Z2 == nil {
+		return false
+	}
+	if !curve.IsOnCurve(c.X, c.Y) || !curve.IsOnCurve(proof.A.X, proof.A.Y) {
+		return false
+	}
+	if proof.Z1.Sign() < 0 || proof.Z1.Cmp(curve.N) >= 0 ||
+		proof.Z2.Sign() < 0 || proof.Z2.Cmp(curve.N) >= 0 {
+
+		return false
+	}
+
+	e := openingChallenge(c, proof.A)
+	lhsX, lhsY := linearCombination(proof.Z1, proof.Z2)
+	ecx, ecy := curve.ScalarMult(c.X, c.Y, e.Bytes())
+	rhsX, rhsY := curve.Add(proof.A.X, proof.A.Y, ecx, ecy)
+	return lhsX.Cmp(rhsX) == 0 && lhsY.Cmp(rhsY) == 0
+}
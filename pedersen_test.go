@@ -0,0 +1,104 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"math/big"
+	"testing"
+)
+
+// TestHashToCurve ensures HashToCurve is deterministic, produces valid points
+// with an even y coordinate, and maps different messages to different points.
+func TestHashToCurve(t *testing.T) {
+	curve := S256()
+	seen := make(map[string]struct{})
+	for i := 0; i < 64; i++ {
+		msg := []byte{byte(i)}
+		p := HashToCurve(msg)
+		if !curve.IsOnCurve(p.X, p.Y) {
+			t.Fatalf("#%d: point is not on the curve", i)
+		}
+		if p.YIsOdd() {
+			t.Fatalf("#%d: point has an odd y coordinate", i)
+		}
+		if !p.IsEqual(HashToCurve(msg)) {
+			t.Fatalf("#%d: point is not deterministic", i)
+		}
+		key := string(p.SerializeCompressed())
+		if _, ok := seen[key]; ok {
+			t.Fatalf("#%d: duplicate point %x", i, key)
+		}
+		seen[key] = struct{}{}
+	}
+}
+
+// TestCommitmentOpening ensures opening proofs for Pedersen commitments verify
+// for the commitment they were made for and fail for any other commitment or
+// when tampered with.
+func TestCommitmentOpening(t *testing.T) {
+	curve := S256()
+	h := PedersenH()
+	if !curve.IsOnCurve(h.X, h.Y) || (h.X.Cmp(curve.Gx) == 0) {
+		t.Fatal("second generator is not an independent point")
+	}
+
+	// Commitments are additively homomorphic.
+	c1 := Commit(big.NewInt(5), DeriveBlinding([]byte("seed"), 1))
+	c2 := Commit(big.NewInt(7), DeriveBlinding([]byte("seed"), 2))
+	sumBlinding := new(big.Int).Add(DeriveBlinding([]byte("seed"), 1),
+		DeriveBlinding([]byte("seed"), 2))
+	sum := Commit(big.NewInt(12), sumBlinding)
+	sx, sy := curve.Add(c1.X, c1.Y, c2.X, c2.Y)
+	if sx.Cmp(sum.X) != 0 || sy.Cmp(sum.Y) != 0 {
+		t.Fatal("commitments are not additively homomorphic")
+	}
+
+	tests := []struct {
+		name     string
+		value    *big.Int
+		blinding *big.Int
+	}{
+		{"small value", big.NewInt(42), DeriveBlinding([]byte("seed"), 0)},
+		{"zero value", big.NewInt(0), DeriveBlinding([]byte("seed"), 3)},
+		{"large value", fromHex("c9afa9d845ba75166b5c215767b1d6934e50c3" +
+			"db36e89b127b8a622b120f6721"), DeriveBlinding([]byte("x"), 9)},
+	}
+	for _, test := range tests {
+		c := Commit(test.value, test.blinding)
+		proof := ProveOpening(test.value, test.blinding)
+		if !VerifyOpening(c, proof) {
+			t.Errorf("%s: valid proof failed to verify", test.name)
+			continue
+		}
+
+		// The proof must not verify for a commitment to a different
+		// value or with a different blinding factor.
+		otherValue := Commit(new(big.Int).Add(test.value, one),
+			test.blinding)
+		if VerifyOpening(otherValue, proof) {
+			t.Errorf("%s: proof verified for a different value",
+				test.name)
+		}
+		otherBlinding := Commit(test.value,
+			new(big.Int).Add(test.blinding, one))
+		if VerifyOpening(otherBlinding, proof) {
+			t.Errorf("%s: proof verified for a different blinding "+
+				"factor", test.name)
+		}
+
+		tampered := proof
+		tampered.Z1 = new(big.Int).Add(proof.Z1, one)
+		if VerifyOpening(c, tampered) {
+			t.Errorf("%s: proof with tampered response verified",
+				test.name)
+		}
+		tampered = proof
+		tampered.A = c
+		if VerifyOpening(c, tampered) {
+			t.Errorf("%s: proof with tampered nonce commitment "+
+				"verified", test.name)
+		}
+	}
+}
@@ -2,12 +2,18 @@
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
+//go:build !smallbasetable
+// +build !smallbasetable
+
 package secp256k1
 
 import (
 	"compress/zlib"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"io/ioutil"
 	"strings"
 )
@@ -28,7 +34,7 @@ func loadS256BytePoints() error {
 	}
 
 	// Decompress the pre-computed table used to accelerate scalar base
-	// multiplication.
+	// multiplication and ensure it matches the expected checksum.
 	decoder := base64.NewDecoder(base64.StdEncoding, strings.NewReader(bp))
 	r, err := zlib.NewReader(decoder)
 	if err != nil {
@@ -38,6 +44,10 @@ func loadS256BytePoints() error {
 	if err != nil {
 		return err
 	}
+	err = checkBytePoints(serialized, secp256k1BytePointsChecksum)
+	if err != nil {
+		return err
+	}
 
 	// Deserialize the precomputed byte points and set the curve to them.
 	offset := 0
@@ -62,6 +72,27 @@ func loadS256BytePoints() error {
 			}
 		}
 	}
-	secp256k1.bytePoints = &bytePoints
+	secp256k1.bytePoints = bytePoints[:]
+	return nil
+}
+
+// checkBytePoints returns an error if the passed serialized pre-computed byte
+// points are not the expected size or their SHA-256 hash does not match the
+// passed hex encoded checksum.  This ensures any corruption of the embedded
+// table is detected instead of silently producing incorrect results.
+func checkBytePoints(serialized []byte, checksum string) error {
+	const wantLen = 32 * 256 * 3 * 10 * 4
+	if len(serialized) != wantLen {
+		return errors.New("pre-computed byte points have an invalid size")
+	}
+
+	want, err := hex.DecodeString(checksum)
+	if err != nil {
+		return err
+	}
+	got := sha256.Sum256(serialized)
+	if !ConstantTimeEqual(got[:], want) {
+		return errors.New("pre-computed byte points checksum mismatch")
+	}
 	return nil
 }
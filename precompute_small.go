@@ -0,0 +1,54 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build smallbasetable
+// +build smallbasetable
+
+package secp256k1
+
+// smallBaseTableWindows is the number of the least significant 8-bit windows
+// covered by the pre-computed byte points when the package is built with the
+// smallbasetable build tag.  The points for the remaining windows are computed
+// on demand during scalar base multiplication.
+const smallBaseTableWindows = 4
+
+// loadS256BytePoints computes the pre-computed byte points for the least
+// significant smallBaseTableWindows windows used to accelerate scalar base
+// multiplication for the secp256k1 curve.
+//
+// This is used instead of the embedded table of all windows when building with
+// the smallbasetable build tag, which significantly reduces both the binary
+// size and the memory used for the table in exchange for slower scalar base
+// multiplication.
+func loadS256BytePoints() error {
+	curve := &secp256k1
+	bytePoints := make([][256][3]fieldVal, smallBaseTableWindows)
+
+	// px, py, pz hold 2^i * G as i iterates through the bits of the covered
+	// windows, starting with the least significant one.
+	px, py := curve.bigAffineToField(curve.Gx, curve.Gy)
+	pz := new(fieldVal).SetInt(1)
+	for window := smallBaseTableWindows - 1; window >= 0; window-- {
+		// Each point in the window is the sum of the doubling points
+		// for the set bits of its index.
+		var doublingPoints [8][3]fieldVal
+		for j := 0; j < 8; j++ {
+			doublingPoints[j] = [3]fieldVal{*px, *py, *pz}
+			curve.doubleJacobian(px, py, pz, px, py, pz)
+		}
+		for i := 0; i < 256; i++ {
+			qx, qy, qz := new(fieldVal), new(fieldVal), new(fieldVal)
+			for j := 0; j < 8; j++ {
+				if i>>uint(j)&1 == 1 {
+					p := doublingPoints[j]
+					curve.addJacobian(qx, qy, qz, &p[0], &p[1],
+						&p[2], qx, qy, qz)
+				}
+			}
+			bytePoints[window][i] = [3]fieldVal{*qx, *qy, *qz}
+		}
+	}
+	curve.bytePoints = bytePoints
+	return nil
+}
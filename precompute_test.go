@@ -0,0 +1,51 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build !smallbasetable
+// +build !smallbasetable
+
+package secp256k1
+
+import (
+	"compress/zlib"
+	"encoding/base64"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// TestCheckBytePoints ensures the checksum of the embedded pre-computed byte
+// points is valid and that corruption of the table is detected.
+func TestCheckBytePoints(t *testing.T) {
+	if err := TryInit(); err != nil {
+		t.Fatalf("failed to initialize the curve: %v", err)
+	}
+
+	decoder := base64.NewDecoder(base64.StdEncoding,
+		strings.NewReader(secp256k1BytePoints))
+	r, err := zlib.NewReader(decoder)
+	if err != nil {
+		t.Fatalf("failed to decompress byte points: %v", err)
+	}
+	serialized, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to decompress byte points: %v", err)
+	}
+
+	err = checkBytePoints(serialized, secp256k1BytePointsChecksum)
+	if err != nil {
+		t.Fatalf("unexpected error for valid byte points: %v", err)
+	}
+
+	// Flip a single bit of a byte in the table.
+	serialized[len(serialized)/2] ^= 0x01
+	if checkBytePoints(serialized, secp256k1BytePointsChecksum) == nil {
+		t.Fatal("corrupted byte points passed the checksum")
+	}
+	serialized[len(serialized)/2] ^= 0x01
+
+	if checkBytePoints(serialized[1:], secp256k1BytePointsChecksum) == nil {
+		t.Fatal("truncated byte points passed the checksum")
+	}
+}
@@ -8,9 +8,23 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"errors"
+	"io"
 	"math/big"
 )
 
+// MaxKeyGenAttempts is the maximum number of candidates GeneratePrivateKey
+// draws before giving up.  A uniformly random candidate is out of range with a
+// probability of about 2^-128, so reaching the limit with a working source of
+// randomness is practically impossible.
+const MaxKeyGenAttempts = 64
+
+// ErrKeyGenAttempts is returned by GeneratePrivateKey when none of the drawn
+// candidates is a valid private key, which indicates a broken source of
+// randomness.
+var ErrKeyGenAttempts = errors.New("exceeded the maximum number of attempts " +
+	"to generate a private key")
+
 // PrivateKey wraps an ecdsa.PrivateKey as a convenience mainly for signing
 // things with the the private key without having to directly import the ecdsa
 // package.
@@ -44,6 +58,48 @@ func NewPrivateKey(curve elliptic.Curve) (*PrivateKey, error) {
 	return (*PrivateKey)(key), nil
 }
 
+// GeneratePrivateKey generates a new private key by rejection sampling 32-byte
+// candidates read from the passed source of randomness until one is in the
+// range [1, N-1].  Unlike NewPrivateKey, the number of attempts is bounded by
+// MaxKeyGenAttempts, after which ErrKeyGenAttempts is returned, so the worst
+// case running time is bounded as well.
+func GeneratePrivateKey(r io.Reader) (*PrivateKey, error) {
+	curve := S256()
+	var b [PrivKeyBytesLen]byte
+	for i := 0; i < MaxKeyGenAttempts; i++ {
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return nil, err
+		}
+		d := new(big.Int).SetBytes(b[:])
+		if d.Sign() == 0 || d.Cmp(curve.N) >= 0 {
+			continue
+		}
+		priv, _ := PrivKeyFromBytes(curve, b[:])
+		return priv, nil
+	}
+	return nil, ErrKeyGenAttempts
+}
+
+// GenerateEvenYKey generates a new private key using the passed source of
+// randomness whose public key has an even Y coordinate, which is the implicit
+// parity of x-only public keys such as those used by Taproot.
+//
+// When the generated public key has an odd Y coordinate, the private key is
+// negated (d -> N-d), which negates the public key and therefore flips the
+// parity of its Y coordinate while leaving the X coordinate unchanged.
+func GenerateEvenYKey(r io.Reader) (*PrivateKey, error) {
+	curve := S256()
+	key, err := ecdsa.GenerateKey(curve, r)
+	if err != nil {
+		return nil, err
+	}
+	if isOdd(key.Y) {
+		key.D.Sub(curve.N, key.D)
+		key.Y.Sub(curve.P, key.Y)
+	}
+	return (*PrivateKey)(key), nil
+}
+
 // PubKey returns the PublicKey corresponding to this private key.
 func (p *PrivateKey) PubKey() *PublicKey {
 	return (*PublicKey)(&p.PublicKey)
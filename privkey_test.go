@@ -6,6 +6,7 @@ package secp256k1_test
 
 import (
 	"bytes"
+	"crypto/rand"
 	"testing"
 
 	"github.com/sammyne/secp256k1"
@@ -56,3 +57,77 @@ func TestPrivKeys(t *testing.T) {
 		}
 	}
 }
+
+// TestGenerateEvenYKey ensures generated keys always have public keys with an
+// even Y coordinate that are still valid for their private keys.
+func TestGenerateEvenYKey(t *testing.T) {
+	curve := secp256k1.S256()
+	for i := 0; i < 256; i++ {
+		priv, err := secp256k1.GenerateEvenYKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("#%d: unexpected error: %v", i, err)
+		}
+		pub := priv.PubKey()
+		if pub.YIsOdd() {
+			t.Fatalf("#%d: public key %x has an odd Y coordinate", i,
+				pub.SerializeCompressed())
+		}
+		if priv.D.Sign() <= 0 || priv.D.Cmp(curve.N) >= 0 {
+			t.Fatalf("#%d: private key %x is out of range", i, priv.D)
+		}
+
+		// The public key must still match the private key and be a
+		// valid point.
+		x, y := curve.ScalarBaseMult(priv.Serialize())
+		if x.Cmp(pub.X) != 0 || y.Cmp(pub.Y) != 0 {
+			t.Fatalf("#%d: public key does not match private key", i)
+		}
+		if _, err := secp256k1.ParsePubKey(pub.SerializeUncompressed(),
+			curve); err != nil {
+			t.Fatalf("#%d: invalid public key: %v", i, err)
+		}
+	}
+}
+
+// repeatReader is an io.Reader which endlessly produces the same byte.
+type repeatReader byte
+
+func (r repeatReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+	return len(p), nil
+}
+
+func TestGeneratePrivateKey(t *testing.T) {
+	key, err := secp256k1.GeneratePrivateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key.D.Sign() <= 0 || key.D.Cmp(secp256k1.S256().N) >= 0 {
+		t.Fatalf("generated key %x is out of range", key.D)
+	}
+	x, y := secp256k1.S256().ScalarBaseMult(key.D.Bytes())
+	if x.Cmp(key.X) != 0 || y.Cmp(key.Y) != 0 {
+		t.Fatal("public key does not match the private key")
+	}
+
+	// Sources which only produce out of range candidates must fail once
+	// the attempts are exhausted instead of looping forever.
+	for _, b := range []byte{0x00, 0xff} {
+		_, err := secp256k1.GeneratePrivateKey(repeatReader(b))
+		if err != secp256k1.ErrKeyGenAttempts {
+			t.Errorf("%#x: got error %v, want %v", b, err,
+				secp256k1.ErrKeyGenAttempts)
+		}
+	}
+
+	// A candidate in range is accepted as is.
+	key, err = secp256k1.GeneratePrivateKey(repeatReader(0x01))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(key.Serialize(), bytes.Repeat([]byte{0x01}, 32)) {
+		t.Fatalf("got key %x", key.Serialize())
+	}
+}
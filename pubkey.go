@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"math/bits"
 )
 
 // These constants define the lengths of serialized public keys.
@@ -25,38 +26,85 @@ func isOdd(a *big.Int) bool {
 // decompressPoint decompresses a point on the given curve given the X point and
 // the solution to use.
 func decompressPoint(curve *KoblitzCurve, x *big.Int, ybit bool) (*big.Int, error) {
+	y, err := decompressPointFunc(curve, x, func(y0, y1 *big.Int) *big.Int {
+		if ybit {
+			return y1
+		}
+		return y0
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify that y-coord has expected parity.
+	if ybit != isOdd(y) {
+		return nil, fmt.Errorf("ybit doesn't match oddness")
+	}
+
+	return y, nil
+}
+
+// DecompressPointFunc returns the y coordinate of a point on the secp256k1
+// curve with the passed x coordinate.  Since there are two such points, the
+// passed function is used to choose between the two possible y coordinates.  It
+// is called with the even one as y0 and the odd one as y1 and must return one
+// of them.  For example, it may choose based on parity, like the compressed
+// public key format, or choose the smaller of the two values.
+//
+// An error is returned without calling the function when x is not the x
+// coordinate of a point on the curve.  An error is also returned when the
+// function returns anything other than one of the two y coordinates.
+func DecompressPointFunc(x *big.Int, choose func(y0, y1 *big.Int) *big.Int) (*big.Int, error) {
+	return decompressPointFunc(S256(), x, choose)
+}
+
+// decompressPointFunc decompresses a point on the given curve given the X point
+// and a function to choose which of the two solutions to use.  See
+// DecompressPointFunc for details.
+func decompressPointFunc(curve *KoblitzCurve, x *big.Int,
+	choose func(y0, y1 *big.Int) *big.Int) (*big.Int, error) {
+
 	// TODO: This will probably only work for secp256k1 due to
 	// optimizations.
+	P := curve.Params().P
 
 	// Y = +-sqrt(x^3 + B)
+	//
+	// The square root is computed even when x is not in the field so that
+	// x coordinates which are not valid take a similar amount of time to
+	// reject as valid ones take to decompress.
 	x3 := new(big.Int).Mul(x, x)
 	x3.Mul(x3, x)
 	x3.Add(x3, curve.Params().B)
-	x3.Mod(x3, curve.Params().P)
+	x3.Mod(x3, P)
 
 	// Now calculate sqrt mod p of x^3 + B
 	// This code used to do a full sqrt based on tonelli/shanks,
 	// but this was replaced by the algorithms referenced in
 	// https://bitcointalk.org/index.php?topic=162805.msg1712294#msg1712294
-	y := new(big.Int).Exp(x3, curve.QPlus1Div4(), curve.Params().P)
-
-	if ybit != isOdd(y) {
-		y.Sub(curve.Params().P, y)
-	}
+	y := new(big.Int).Exp(x3, curve.QPlus1Div4(), P)
 
 	// Check that y is a square root of x^3 + B.
 	y2 := new(big.Int).Mul(y, y)
-	y2.Mod(y2, curve.Params().P)
+	y2.Mod(y2, P)
+	if x.Sign() < 0 || x.Cmp(P) >= 0 {
+		return nil, fmt.Errorf("x coordinate is not in the field")
+	}
 	if y2.Cmp(x3) != 0 {
 		return nil, fmt.Errorf("invalid square root")
 	}
 
-	// Verify that y-coord has expected parity.
-	if ybit != isOdd(y) {
-		return nil, fmt.Errorf("ybit doesn't match oddness")
+	// The two solutions are y and -y, exactly one of which is odd.
+	y0, y1 := y, new(big.Int).Sub(P, y)
+	if isOdd(y0) {
+		y0, y1 = y1, y0
 	}
-
-	return y, nil
+	chosen := choose(new(big.Int).Set(y0), new(big.Int).Set(y1))
+	if chosen == nil || (chosen.Cmp(y0) != 0 && chosen.Cmp(y1) != 0) {
+		return nil, fmt.Errorf("chosen value is not a y coordinate " +
+			"for the x coordinate")
+	}
+	return new(big.Int).Set(chosen), nil
 }
 
 const (
@@ -74,9 +122,40 @@ func IsCompressedPubKey(pubKey []byte) bool {
 		(pubKey[0]&^byte(0x1) == pubkeyCompressed)
 }
 
+// IsMinimalPubKeyEncoding returns whether or not the passed serialized public
+// key has exactly the length required by the format indicated by its first
+// byte.  That is 33 bytes for the compressed format and 65 bytes for the
+// uncompressed and hybrid formats.  Encodings with an unknown format byte, or
+// which are padded with extra bytes or truncated, are rejected.
+//
+// Only the encoding is checked, so this does not imply the key is valid.  Use
+// ParsePubKey to fully validate it.
+func IsMinimalPubKeyEncoding(b []byte) bool {
+	if len(b) == 0 {
+		return false
+	}
+	switch b[0] {
+	case pubkeyCompressed, pubkeyCompressed | 0x1:
+		return len(b) == PubKeyBytesLenCompressed
+	case pubkeyUncompressed, pubkeyHybrid, pubkeyHybrid | 0x1:
+		return len(b) == PubKeyBytesLenUncompressed
+	default:
+		return false
+	}
+}
+
 // ParsePubKey parses a public key for a koblitz curve from a bytestring into a
 // ecdsa.Publickey, verifying that it is valid. It supports compressed,
 // uncompressed and hybrid signature formats.
+//
+// The square root of x^3 + B is computed for every compressed key, including
+// those whose X coordinate is not in the field, and it dominates the time taken
+// to parse one, so rejecting a compressed key whose X coordinate is not on the
+// curve takes a similar amount of time to parsing a valid key.  The range and
+// curve checks done for valid keys afterwards are skipped when it is rejected,
+// so this is not a constant time guarantee, and neither is the underlying big
+// integer arithmetic.  Keys with an invalid length or format byte are rejected
+// immediately since those are apparent from the serialization itself.
 func ParsePubKey(pubKeyStr []byte, curve *KoblitzCurve) (key *PublicKey, err error) {
 	pubkey := PublicKey{}
 	pubkey.Curve = curve
@@ -132,6 +211,176 @@ func ParsePubKey(pubKeyStr []byte, curve *KoblitzCurve) (key *PublicKey, err err
 	return &pubkey, nil
 }
 
+// PublicKeyFromXAndParity reconstructs a secp256k1 public key from its X
+// coordinate and the format byte of its compressed serialization, as returned
+// by ParityByte.  This is useful for protocols which store the parity of the
+// key separately from the X coordinate.
+//
+// An error is returned when the parity byte is not 0x02 or 0x03, or when there
+// is no point on the curve with the given X coordinate.
+func PublicKeyFromXAndParity(x [32]byte, parity byte) (*PublicKey, error) {
+	if parity&^byte(0x1) != pubkeyCompressed {
+		return nil, fmt.Errorf("invalid parity byte: %#x", parity)
+	}
+
+	var compressed [PubKeyBytesLenCompressed]byte
+	compressed[0] = parity
+	copy(compressed[1:], x[:])
+	return ParsePubKey(compressed[:], S256())
+}
+
+// ValidatePublicKeys returns whether or not each of the passed public keys is
+// a valid secp256k1 point.  The result at each index corresponds to the key at
+// the same index.  A key is valid when it is not nil, both of its coordinates
+// are in the range [0, P), and it satisfies the curve equation.
+//
+// This is intended for callers such as nodes that need to validate a large
+// number of keys at once.  Since public keys are stored in affine coordinates,
+// no field inversions are required, so the work per key is limited to a range
+// check followed by the curve equation, which is skipped entirely as soon as
+// the range check fails.  The field values used for the arithmetic are reused
+// across all keys and the coordinates are loaded into them without allocating,
+// so the returned slice is the only allocation.
+func ValidatePublicKeys(keys []*PublicKey) []bool {
+	p := S256().P
+	valid := make([]bool, len(keys))
+
+	var b [32]byte
+	var x, y, y2, x3 fieldVal
+	for i, key := range keys {
+		if key == nil || key.X == nil || key.Y == nil {
+			continue
+		}
+		if key.X.Sign() < 0 || key.X.Cmp(p) >= 0 ||
+			key.Y.Sign() < 0 || key.Y.Cmp(p) >= 0 {
+			continue
+		}
+
+		// Elliptic curve equation for secp256k1 is: y^2 = x^3 + 7
+		x.SetBytes(bigIntToBytes32(key.X, &b))
+		y.SetBytes(bigIntToBytes32(key.Y, &b))
+		y2.SquareVal(&y).Normalize()
+		x3.SquareVal(&x).Mul(&x).AddInt(7).Normalize()
+		valid[i] = y2.Equals(&x3)
+	}
+
+	return valid
+}
+
+// bigIntToBytes32 writes the passed big integer, which must be non-negative and
+// fit in 256 bits, into the passed array as a 32-byte big-endian value with
+// leading zero padding.  The array is returned for convenience.  It reads the
+// words of the integer directly to avoid the allocation made by big.Int.Bytes.
+func bigIntToBytes32(v *big.Int, b *[32]byte) *[32]byte {
+	const wordBytes = bits.UintSize / 8
+	*b = [32]byte{}
+	for i, word := range v.Bits() {
+		for j := 0; j < wordBytes; j++ {
+			pos := 31 - i*wordBytes - j
+			if pos < 0 {
+				return b
+			}
+			b[pos] = byte(word >> uint(8*j))
+		}
+	}
+	return b
+}
+
+// EnumerateSmallMultiples returns the first n multiples of the secp256k1 base
+// point.  That is to say G, 2G, ..., nG, where element i of the returned slice
+// is (i+1)G.  Nil is returned when n is not positive.
+//
+// The multiples are computed by repeated addition, independently of the
+// scalar multiplication routines, which makes them well suited to checking
+// properties such as 3G + 5G = 8G in tests.
+func EnumerateSmallMultiples(n int) []*PublicKey {
+	if n <= 0 {
+		return nil
+	}
+
+	curve := S256()
+	var g, acc JacobianPoint
+	g.SetAffine(curve.Gx, curve.Gy)
+	acc.Set(&g)
+
+	multiples := make([]*PublicKey, 0, n)
+	for i := 0; i < n; i++ {
+		x, y := acc.ToAffine()
+		multiples = append(multiples, &PublicKey{Curve: curve, X: x, Y: y})
+		acc.AddNonConst(&acc, &g)
+	}
+	return multiples
+}
+
+// MaxSmallMultiple is the largest bound IsSmallMultipleOfG searches up to.
+// Larger bounds are clamped to it.
+const MaxSmallMultiple = 1 << 16
+
+// IsSmallMultipleOfG returns the integer k such that the passed public key is
+// k*G, where G is the base point of the group, along with true when k is in
+// the range [1, max].  Otherwise it returns false, including when the key or
+// either of its coordinates is nil.  Bounds larger than MaxSmallMultiple are
+// clamped to it, so multiples beyond it are never detected.
+//
+// This is useful for detecting keys that were derived from tiny private keys,
+// which are trivially recoverable, in tests and fixtures.  The multiples of G
+// are walked in Jacobian coordinates and compared against the key as they are
+// produced, which avoids a field inversion per multiple and stops at the first
+// match.  No state is kept between calls.
+func IsSmallMultipleOfG(pub *PublicKey, max int) (int, bool) {
+	if pub == nil || pub.X == nil || pub.Y == nil {
+		return 0, false
+	}
+	prime := S256().P
+	if pub.X.Sign() < 0 || pub.X.Cmp(prime) >= 0 || pub.Y.Sign() < 0 ||
+		pub.Y.Cmp(prime) >= 0 {
+
+		return 0, false
+	}
+	if max > MaxSmallMultiple {
+		max = MaxSmallMultiple
+	}
+	var x, y fieldVal
+	x.SetByteSlice(pub.X.Bytes())
+	y.SetByteSlice(pub.Y.Bytes())
+
+	// The affine point (x, y) is equal to the Jacobian point (X, Y, Z) when
+	// X = x*Z^2 and Y = y*Z^3.
+	var g, acc JacobianPoint
+	g.SetAffine(S256().Gx, S256().Gy)
+	acc.Set(&g)
+	for k := 1; k <= max; k++ {
+		var z2, xz, yz fieldVal
+		z2.SquareVal(&acc.Z)
+		xz.Mul2(&x, &z2).Normalize()
+		yz.Mul2(&y, &z2).Mul(&acc.Z).Normalize()
+		if xz.Equals(&acc.X) && yz.Equals(&acc.Y) {
+			return k, true
+		}
+		acc.AddNonConst(&acc, &g)
+	}
+	return 0, false
+}
+
+// IsInverse returns whether or not the passed public keys are the inverses of
+// each other, that is p = -q, which is the case when their X coordinates are
+// equal and the sum of their Y coordinates is zero modulo the field prime.  The
+// coordinates are reduced modulo the prime before they are compared.
+//
+// This is cheaper than adding the points and checking for the point at
+// infinity.  Since the group has prime order, no point on the curve is its own
+// inverse, so passing the same key twice always returns false.
+func IsInverse(p, q *PublicKey) bool {
+	prime := S256().P
+	px := new(big.Int).Mod(p.X, prime)
+	qx := new(big.Int).Mod(q.X, prime)
+	if px.Cmp(qx) != 0 {
+		return false
+	}
+	ySum := new(big.Int).Add(p.Y, q.Y)
+	return ySum.Mod(ySum, prime).Sign() == 0
+}
+
 // PublicKey is an ecdsa.PublicKey with additional functions to
 // serialize in uncompressed, compressed, and hybrid formats.
 type PublicKey ecdsa.PublicKey
@@ -153,12 +402,44 @@ func (p *PublicKey) SerializeUncompressed() []byte {
 // SerializeCompressed serializes a public key in a 33-byte compressed format.
 func (p *PublicKey) SerializeCompressed() []byte {
 	b := make([]byte, 0, PubKeyBytesLenCompressed)
+	b = append(b, p.ParityByte())
+	return paddedAppend(32, b, p.X.Bytes())
+}
+
+// PutCompressed writes the public key in the 33-byte compressed format of
+// SerializeCompressed into the start of the passed buffer and returns the number
+// of bytes written.  Unlike SerializeCompressed, it does not allocate, which
+// suits encoders that write into a shared buffer.  An error is returned and
+// nothing is written when the buffer is shorter than 33 bytes.
+func (p *PublicKey) PutCompressed(out []byte) (int, error) {
+	if len(out) < PubKeyBytesLenCompressed {
+		return 0, fmt.Errorf("buffer of %d bytes is too short for a "+
+			"compressed public key of %d bytes", len(out),
+			PubKeyBytesLenCompressed)
+	}
+
+	var x [32]byte
+	out[0] = p.ParityByte()
+	copy(out[1:PubKeyBytesLenCompressed], bigIntToBytes32(p.X, &x)[:])
+	return PubKeyBytesLenCompressed, nil
+}
+
+// ParityByte returns the format byte the public key has in its compressed
+// serialization.  That is 0x02 when the Y coordinate is even and 0x03 when it
+// is odd.  Combined with the X coordinate, it is enough to reconstruct the full
+// public key via PublicKeyFromXAndParity.
+func (p *PublicKey) ParityByte() byte {
 	format := pubkeyCompressed
 	if isOdd(p.Y) {
 		format |= 0x1
 	}
-	b = append(b, format)
-	return paddedAppend(32, b, p.X.Bytes())
+	return format
+}
+
+// YIsOdd returns whether or not the Y coordinate of the public key is odd.
+// BIP340 x-only public keys implicitly have an even Y coordinate.
+func (p *PublicKey) YIsOdd() bool {
+	return isOdd(p.Y)
 }
 
 // SerializeHybrid serializes a public key in a 65-byte hybrid format.
@@ -6,6 +6,7 @@ package secp256k1
 
 import (
 	"bytes"
+	"math/big"
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
@@ -294,3 +295,511 @@ func TestIsCompressed(t *testing.T) {
 		}
 	}
 }
+
+// TestIsMinimalPubKeyEncoding ensures correctly sized encodings are accepted
+// and padded, truncated, or unknown encodings are rejected.
+func TestIsMinimalPubKeyEncoding(t *testing.T) {
+	_, pub := PrivKeyFromBytes(S256(), []byte{0x07})
+	compressed := pub.SerializeCompressed()
+	uncompressed := pub.SerializeUncompressed()
+	hybrid := pub.SerializeHybrid()
+	pad := func(b []byte) []byte {
+		return append(append([]byte(nil), b...), 0x00)
+	}
+	withFormat := func(b []byte, format byte) []byte {
+		b = append([]byte(nil), b...)
+		b[0] = format
+		return b
+	}
+
+	tests := []struct {
+		name string
+		key  []byte
+		want bool
+	}{
+		{"compressed", compressed, true},
+		{"uncompressed", uncompressed, true},
+		{"hybrid", hybrid, true},
+		{"padded compressed", pad(compressed), false},
+		{"padded uncompressed", pad(uncompressed), false},
+		{"padded hybrid", pad(hybrid), false},
+		{"truncated compressed", compressed[:32], false},
+		{"truncated uncompressed", uncompressed[:64], false},
+		{"compressed format with 65 bytes", withFormat(uncompressed, 0x02), false},
+		{"uncompressed format with 33 bytes", withFormat(compressed, 0x04), false},
+		{"format 0x05", withFormat(uncompressed, 0x05), false},
+		{"format 0x00", withFormat(compressed, 0x00), false},
+		{"empty", nil, false},
+	}
+	for _, test := range tests {
+		if got := IsMinimalPubKeyEncoding(test.key); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// TestPublicKeyFromXAndParity ensures public keys round trip through their X
+// coordinate and parity byte and that invalid inputs are rejected.
+func TestPublicKeyFromXAndParity(t *testing.T) {
+	for _, test := range pubKeyTests {
+		if !test.isValid {
+			continue
+		}
+		pk, err := ParsePubKey(test.key, S256())
+		if err != nil {
+			t.Fatalf("%s: unexpected parse error: %v", test.name, err)
+		}
+
+		var x [32]byte
+		copy(x[:], pk.SerializeCompressed()[1:])
+		got, err := PublicKeyFromXAndParity(x, pk.ParityByte())
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !got.IsEqual(pk) {
+			t.Errorf("%s: mismatched key - got %x, want %x",
+				test.name, got.SerializeCompressed(),
+				pk.SerializeCompressed())
+		}
+		if !bytes.Equal(got.SerializeCompressed(),
+			pk.SerializeCompressed()) {
+			t.Errorf("%s: mismatched compressed serialization",
+				test.name)
+		}
+
+		// The opposite parity must select the negation of the key.
+		negated, err := PublicKeyFromXAndParity(x, pk.ParityByte()^0x1)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		negY := new(big.Int).Sub(S256().P, pk.Y)
+		if negated.X.Cmp(pk.X) != 0 || negated.Y.Cmp(negY) != 0 {
+			t.Errorf("%s: opposite parity - got (%x, %x), want "+
+				"(%x, %x)", test.name, negated.X, negated.Y, pk.X,
+				negY)
+		}
+	}
+
+	var gx [32]byte
+	copy(gx[:], S256().Gx.Bytes())
+	invalidParity := []byte{0x00, 0x01, 0x04, 0x05, 0x06, 0x07, 0xff}
+	for _, parity := range invalidParity {
+		if _, err := PublicKeyFromXAndParity(gx, parity); err == nil {
+			t.Errorf("parity byte %#x was accepted", parity)
+		}
+	}
+
+	// There is no point on the curve with x = 5, and x = P is out of range.
+	var notOnCurve, overflow [32]byte
+	notOnCurve[31] = 0x05
+	copy(overflow[:], S256().P.Bytes())
+	for _, x := range [][32]byte{notOnCurve, overflow} {
+		if _, err := PublicKeyFromXAndParity(x, 0x02); err == nil {
+			t.Errorf("x coordinate %x was accepted", x)
+		}
+	}
+}
+
+// TestValidatePublicKeys ensures the bulk validation agrees with validating
+// each key individually.
+func TestValidatePublicKeys(t *testing.T) {
+	curve := S256()
+	var keys []*PublicKey
+	for _, test := range pubKeyTests {
+		if !test.isValid {
+			continue
+		}
+		pk, err := ParsePubKey(test.key, curve)
+		if err != nil {
+			t.Fatalf("%s: unexpected parse error: %v", test.name, err)
+		}
+		keys = append(keys, pk)
+	}
+
+	// Add some invalid keys including off-curve points, out of range
+	// coordinates, and nil values.
+	g := &PublicKey{Curve: curve, X: curve.Gx, Y: curve.Gy}
+	offCurve := &PublicKey{Curve: curve, X: curve.Gx,
+		Y: new(big.Int).Add(curve.Gy, big.NewInt(1))}
+	xOverflow := &PublicKey{Curve: curve,
+		X: new(big.Int).Add(curve.Gx, curve.P), Y: curve.Gy}
+	yOverflow := &PublicKey{Curve: curve, X: curve.Gx,
+		Y: new(big.Int).Add(curve.Gy, curve.P)}
+	keys = append(keys, g, offCurve, xOverflow, yOverflow, nil,
+		&PublicKey{Curve: curve})
+	want := make([]bool, len(keys))
+	for i := 0; i < len(keys)-6; i++ {
+		want[i] = true
+	}
+	want[len(keys)-6] = true
+
+	got := ValidatePublicKeys(keys)
+	if len(got) != len(keys) {
+		t.Fatalf("got %d results, want %d", len(got), len(keys))
+	}
+	for i := range keys {
+		if got[i] != want[i] {
+			t.Errorf("#%d: got %v, want %v", i, got[i], want[i])
+		}
+		if want[i] && !curve.IsOnCurve(keys[i].X, keys[i].Y) {
+			t.Errorf("#%d: IsOnCurve disagrees", i)
+		}
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		ValidatePublicKeys(keys)
+	})
+	if allocs != 1 {
+		t.Fatalf("%v allocations, want 1", allocs)
+	}
+}
+
+// TestSmallMultiplesProperties ensures the group operations agree with each
+// other on the small multiples of the base point.  That is to say addition is
+// commutative and associative and agrees with doubling and scalar
+// multiplication, for example 3G + 5G = 8G.
+func TestSmallMultiplesProperties(t *testing.T) {
+	if EnumerateSmallMultiples(0) != nil {
+		t.Fatal("enumerating zero multiples did not return nil")
+	}
+
+	const n = 20
+	curve := S256()
+	mults := EnumerateSmallMultiples(n)
+	if len(mults) != n {
+		t.Fatalf("unexpected number of multiples - got %d, want %d",
+			len(mults), n)
+	}
+
+	// mult returns kG for 1 <= k <= n.
+	mult := func(k int) *PublicKey { return mults[k-1] }
+	isMult := func(x, y *big.Int, k int) bool {
+		return x.Cmp(mult(k).X) == 0 && y.Cmp(mult(k).Y) == 0
+	}
+
+	if !isMult(curve.Gx, curve.Gy, 1) {
+		t.Fatal("first multiple is not the base point")
+	}
+	for i := 1; i <= n; i++ {
+		if !curve.IsOnCurve(mult(i).X, mult(i).Y) {
+			t.Fatalf("%dG is not on the curve", i)
+		}
+
+		k := big.NewInt(int64(i)).Bytes()
+		if x, y := curve.ScalarBaseMult(k); !isMult(x, y, i) {
+			t.Fatalf("ScalarBaseMult(%d) != %dG", i, i)
+		}
+		if x, y := curve.ScalarMult(curve.Gx, curve.Gy, k); !isMult(x, y, i) {
+			t.Fatalf("ScalarMult(G, %d) != %dG", i, i)
+		}
+		if 2*i <= n {
+			x, y := curve.Double(mult(i).X, mult(i).Y)
+			if !isMult(x, y, 2*i) {
+				t.Fatalf("2*%dG != %dG", i, 2*i)
+			}
+		}
+
+		for j := 1; i+j <= n; j++ {
+			x, y := curve.Add(mult(i).X, mult(i).Y, mult(j).X, mult(j).Y)
+			if !isMult(x, y, i+j) {
+				t.Fatalf("%dG + %dG != %dG", i, j, i+j)
+			}
+			x, y = curve.Add(mult(j).X, mult(j).Y, mult(i).X, mult(i).Y)
+			if !isMult(x, y, i+j) {
+				t.Fatalf("%dG + %dG != %dG", j, i, i+j)
+			}
+
+			for k := 1; i+j+k <= n; k++ {
+				// (iG + jG) + kG = iG + (jG + kG).
+				lx, ly := curve.Add(mult(i).X, mult(i).Y,
+					mult(j).X, mult(j).Y)
+				lx, ly = curve.Add(lx, ly, mult(k).X, mult(k).Y)
+				rx, ry := curve.Add(mult(j).X, mult(j).Y,
+					mult(k).X, mult(k).Y)
+				rx, ry = curve.Add(mult(i).X, mult(i).Y, rx, ry)
+				if !isMult(lx, ly, i+j+k) || !isMult(rx, ry, i+j+k) {
+					t.Fatalf("(%dG + %dG) + %dG != %dG + (%dG + "+
+						"%dG)", i, j, k, i, j, k)
+				}
+			}
+		}
+	}
+}
+
+// TestIsSmallMultipleOfG ensures public keys that are small multiples of the
+// base point are detected along with the correct multiple while other keys are
+// not.
+func TestIsSmallMultipleOfG(t *testing.T) {
+	curve := S256()
+	for _, k := range []int{1, 2, 7, 100} {
+		x, y := curve.ScalarBaseMult(big.NewInt(int64(k)).Bytes())
+		pub := &PublicKey{Curve: curve, X: x, Y: y}
+		got, ok := IsSmallMultipleOfG(pub, 100)
+		if !ok || got != k {
+			t.Errorf("%d*G: got (%d, %v), want (%d, true)", k, got, ok,
+				k)
+		}
+
+		// The negation of k*G is (N-k)*G and must not be detected.
+		neg := &PublicKey{Curve: curve, X: x, Y: new(big.Int).Sub(curve.P, y)}
+		if got, ok := IsSmallMultipleOfG(neg, 100); ok {
+			t.Errorf("-%d*G: detected as %d*G", k, got)
+		}
+	}
+
+	// Multiples beyond the bound must not be detected.
+	x, y := curve.ScalarBaseMult([]byte{101})
+	if got, ok := IsSmallMultipleOfG(&PublicKey{Curve: curve, X: x, Y: y}, 100); ok {
+		t.Errorf("101*G: detected as %d*G with a bound of 100", got)
+	}
+
+	privKey, err := NewPrivateKey(curve)
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+	if got, ok := IsSmallMultipleOfG(privKey.PubKey(), 100); ok {
+		t.Errorf("random key detected as %d*G", got)
+	}
+	if got, ok := IsSmallMultipleOfG(privKey.PubKey(), 0); ok {
+		t.Errorf("random key detected as %d*G with a bound of 0", got)
+	}
+
+	// Bounds above MaxSmallMultiple are clamped to it.
+	for _, k := range []int64{MaxSmallMultiple, MaxSmallMultiple + 1} {
+		x, y := curve.ScalarBaseMult(big.NewInt(k).Bytes())
+		pub := &PublicKey{Curve: curve, X: x, Y: y}
+		got, ok := IsSmallMultipleOfG(pub, 1<<30)
+		if wantOK := k <= MaxSmallMultiple; ok != wantOK ||
+			(ok && int64(got) != k) {
+
+			t.Errorf("%d*G: got (%d, %v) with an oversized bound", k,
+				got, ok)
+		}
+	}
+
+	// Nil keys and keys with nil coordinates are not small multiples.
+	g := &PublicKey{Curve: curve, X: curve.Gx, Y: curve.Gy}
+	for i, pub := range []*PublicKey{nil, {Curve: curve, Y: g.Y},
+		{Curve: curve, X: g.X}} {
+
+		if got, ok := IsSmallMultipleOfG(pub, 100); ok {
+			t.Errorf("#%d: nil key detected as %d*G", i, got)
+		}
+	}
+}
+
+// TestPutCompressed ensures PutCompressed writes the same bytes as
+// SerializeCompressed without allocating and leaves short buffers untouched.
+func TestPutCompressed(t *testing.T) {
+	curve := S256()
+	keys := []*PublicKey{{Curve: curve, X: curve.Gx, Y: curve.Gy}}
+	for _, k := range [][]byte{{0x02}, {0x07}, decodeHex("d74bf844b0862475" +
+		"103d96a611cf2d898447e288d34b360bc885cb8ce7c00575")} {
+
+		x, y := curve.ScalarBaseMult(k)
+		keys = append(keys, &PublicKey{Curve: curve, X: x, Y: y})
+	}
+
+	// A key with a small X coordinate exercises the leading zero padding.
+	// It only needs to be serialized, so it need not be on the curve.
+	keys = append(keys, &PublicKey{Curve: curve, X: big.NewInt(0x0102),
+		Y: big.NewInt(3)})
+
+	for i, key := range keys {
+		want := key.SerializeCompressed()
+		buf := bytes.Repeat([]byte{0xaa}, PubKeyBytesLenCompressed+7)
+		n, err := key.PutCompressed(buf)
+		if err != nil {
+			t.Fatalf("#%d: unexpected error: %v", i, err)
+		}
+		if n != PubKeyBytesLenCompressed {
+			t.Fatalf("#%d: wrote %d bytes, want %d", i, n,
+				PubKeyBytesLenCompressed)
+		}
+		if !bytes.Equal(buf[:n], want) {
+			t.Fatalf("#%d: got %x, want %x", i, buf[:n], want)
+		}
+		if !bytes.Equal(buf[n:], bytes.Repeat([]byte{0xaa}, 7)) {
+			t.Fatalf("#%d: bytes past the key were modified", i)
+		}
+
+		allocs := testing.AllocsPerRun(10, func() {
+			key.PutCompressed(buf)
+		})
+		if allocs != 0 {
+			t.Fatalf("#%d: %v allocations, want 0", i, allocs)
+		}
+	}
+
+	short := bytes.Repeat([]byte{0xaa}, PubKeyBytesLenCompressed-1)
+	n, err := keys[0].PutCompressed(short)
+	if err == nil {
+		t.Fatal("short buffer accepted")
+	}
+	if n != 0 {
+		t.Fatalf("wrote %d bytes to short buffer, want 0", n)
+	}
+	if !bytes.Equal(short, bytes.Repeat([]byte{0xaa}, len(short))) {
+		t.Fatal("short buffer was modified")
+	}
+}
+
+// TestIsInverse ensures public keys are only reported as inverses of each other
+// when one is the negation of the other.
+func TestIsInverse(t *testing.T) {
+	curve := S256()
+	privKey, err := NewPrivateKey(curve)
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+	x, y := curve.ScalarBaseMult([]byte{0x07})
+	keys := []*PublicKey{
+		{Curve: curve, X: curve.Gx, Y: curve.Gy},
+		{Curve: curve, X: x, Y: y},
+		privKey.PubKey(),
+	}
+
+	for i, key := range keys {
+		negX, negY := curve.Negate(key.X, key.Y)
+		neg := &PublicKey{Curve: curve, X: negX, Y: negY}
+		if !IsInverse(key, neg) || !IsInverse(neg, key) {
+			t.Errorf("#%d: key and its negation are not inverses", i)
+		}
+
+		// The Y coordinate offset by the prime is the same field
+		// element.
+		unreduced := &PublicKey{Curve: curve, X: negX,
+			Y: new(big.Int).Add(negY, curve.P)}
+		if !IsInverse(key, unreduced) {
+			t.Errorf("#%d: key and its unreduced negation are not "+
+				"inverses", i)
+		}
+
+		// No point on the curve is its own inverse.
+		if IsInverse(key, key) {
+			t.Errorf("#%d: key is its own inverse", i)
+		}
+
+		// Unrelated keys are not inverses.
+		for j, other := range keys {
+			if j != i && IsInverse(key, other) {
+				t.Errorf("#%d: key is the inverse of key #%d", i, j)
+			}
+		}
+	}
+}
+
+// TestDecompressPointFunc ensures the y coordinate chosen by the passed
+// function is returned and that invalid x coordinates are rejected before the
+// function is called.
+func TestDecompressPointFunc(t *testing.T) {
+	curve := S256()
+	parity := func(odd bool) func(y0, y1 *big.Int) *big.Int {
+		return func(y0, y1 *big.Int) *big.Int {
+			if odd {
+				return y1
+			}
+			return y0
+		}
+	}
+	smallest := func(y0, y1 *big.Int) *big.Int {
+		if y0.Cmp(y1) < 0 {
+			return y0
+		}
+		return y1
+	}
+
+	for i := 1; i <= 16; i++ {
+		x, y := curve.ScalarBaseMult([]byte{byte(i)})
+		negY := new(big.Int).Sub(curve.P, y)
+
+		for _, odd := range []bool{false, true} {
+			got, err := DecompressPointFunc(x, parity(odd))
+			if err != nil {
+				t.Fatalf("%d*G: unexpected error: %v", i, err)
+			}
+			want := y
+			if isOdd(y) != odd {
+				want = negY
+			}
+			if got.Cmp(want) != 0 {
+				t.Fatalf("%d*G (odd %v): got %x, want %x", i, odd,
+					got, want)
+			}
+		}
+
+		got, err := DecompressPointFunc(x, smallest)
+		if err != nil {
+			t.Fatalf("%d*G: unexpected error: %v", i, err)
+		}
+		want := y
+		if negY.Cmp(y) < 0 {
+			want = negY
+		}
+		if got.Cmp(want) != 0 {
+			t.Fatalf("%d*G (smallest): got %x, want %x", i, got, want)
+		}
+
+		// The chosen value must be one of the y coordinates.
+		_, err = DecompressPointFunc(x, func(y0, y1 *big.Int) *big.Int {
+			return new(big.Int).Add(y0, one)
+		})
+		if err == nil {
+			t.Fatalf("%d*G: invalid choice was accepted", i)
+		}
+	}
+
+	// Invalid x coordinates must be rejected without calling the function.
+	for _, x := range []*big.Int{big.NewInt(5), curve.P, big.NewInt(-1)} {
+		var called bool
+		_, err := DecompressPointFunc(x, func(y0, y1 *big.Int) *big.Int {
+			called = true
+			return y0
+		})
+		if err == nil {
+			t.Fatalf("x = %x: invalid x coordinate was accepted", x)
+		}
+		if called {
+			t.Fatalf("x = %x: choose was called for an invalid x "+
+				"coordinate", x)
+		}
+	}
+}
+
+// compressedPathKeys returns a valid compressed public key along with two
+// invalid ones close to it, one whose X coordinate has no square root and one
+// whose X coordinate is not in the field.
+func compressedPathKeys(curve *KoblitzCurve) (valid, noSqrt, notInField []byte) {
+	valid = (&PublicKey{Curve: curve, X: curve.Gx, Y: curve.Gy}).SerializeCompressed()
+
+	// Find an X coordinate close to that of the generator for which x^3 + 7
+	// has no square root.
+	noSqrt = append([]byte(nil), valid...)
+	for {
+		noSqrt[32]++
+		x := new(big.Int).SetBytes(noSqrt[1:])
+		if _, err := decompressPoint(curve, x, false); err != nil {
+			break
+		}
+	}
+	notInField = append([]byte{pubkeyCompressed}, curve.P.Bytes()...)
+	return valid, noSqrt, notInField
+}
+
+// TestParsePubKeyCompressedPaths ensures compressed public keys are accepted
+// when their X coordinate is on the curve and rejected when it has no square
+// root or is not in the field.
+func TestParsePubKeyCompressedPaths(t *testing.T) {
+	curve := S256()
+	valid, noSqrt, notInField := compressedPathKeys(curve)
+	if _, err := ParsePubKey(valid, curve); err != nil {
+		t.Fatalf("failed to parse valid key: %v", err)
+	}
+	for _, invalid := range [][]byte{noSqrt, notInField} {
+		if _, err := ParsePubKey(invalid, curve); err == nil {
+			t.Fatalf("parsed invalid key %x", invalid)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"math/bits"
+)
+
+// PrecomputedPubKey is a public key along with a table of its multiples that
+// accelerates the verification of many signatures made with the same key.
+// The table is structured like the one used for scalar base multiplication:
+// it holds all 256 multiples of the key for each 8-bit window of a scalar, so
+// multiplying the key by a scalar only requires adding one table entry per
+// byte of the scalar instead of a full scalar multiplication.
+//
+// The table consumes roughly 1 MiB of memory, so it is only worthwhile for
+// keys that are used to verify a large number of signatures.  It is safe for
+// concurrent use once created.
+type PrecomputedPubKey struct {
+	pubKey     *PublicKey
+	bytePoints [32][256][3]fieldVal
+}
+
+// Precompute builds the table of multiples of the public key used to
+// accelerate signature verification.  The returned value should be cached and
+// reused for all verifications with the key.
+//
+// The table is returned rather than cached on the public key itself since
+// PublicKey is defined as an ecdsa.PublicKey, which has no room for it, and
+// adding a field would break the conversions to and from ecdsa.PublicKey that
+// callers rely on.
+func (p *PublicKey) Precompute() *PrecomputedPubKey {
+	curve := S256()
+	table := &PrecomputedPubKey{pubKey: p}
+
+	// px, py, pz hold 2^i * Q as i iterates through the bits of the
+	// windows, starting with the least significant one.
+	px, py := curve.bigAffineToField(p.X, p.Y)
+	pz := new(fieldVal).SetInt(1)
+	for window := len(table.bytePoints) - 1; window >= 0; window-- {
+		var doublingPoints [8][3]fieldVal
+		for j := 0; j < 8; j++ {
+			doublingPoints[j] = [3]fieldVal{*px, *py, *pz}
+			curve.doubleJacobian(px, py, pz, px, py, pz)
+		}
+
+		// Each point in the window is the point for its index with the
+		// lowest set bit cleared plus the doubling point for that bit,
+		// so every point only takes a single addition to compute.
+		points := &table.bytePoints[window]
+		for i := 1; i < 256; i++ {
+			prev := &points[i&(i-1)]
+			d := &doublingPoints[bits.TrailingZeros(uint(i))]
+			q := &points[i]
+			curve.addJacobian(&prev[0], &prev[1], &prev[2], &d[0], &d[1],
+				&d[2], &q[0], &q[1], &q[2])
+		}
+	}
+	return table
+}
+
+// PubKey returns the public key the table was built for.
+func (p *PrecomputedPubKey) PubKey() *PublicKey {
+	return p.pubKey
+}
+
+// scalarMultJacobian multiplies the public key by the big endian integer k,
+// which must be less than the group order, and stores the result in
+// (qx, qy, qz).
+func (p *PrecomputedPubKey) scalarMultJacobian(k []byte, qx, qy, qz *fieldVal) {
+	curve := S256()
+	diff := len(p.bytePoints) - len(k)
+
+	// Point Q = ∞ (point at infinity).
+	qx.SetInt(0)
+	qy.SetInt(0)
+	qz.SetInt(0)
+	for i, byteVal := range k {
+		pt := &p.bytePoints[diff+i][byteVal]
+		curve.addJacobian(qx, qy, qz, &pt[0], &pt[1], &pt[2], qx, qy, qz)
+	}
+}
+
+// Verify verifies the signature of hash using the public key the table was
+// built for.  It returns the same results as Signature.Verify, but computes
+// the multiple of the public key needed for verification with the table.
+func (p *PrecomputedPubKey) Verify(hash []byte, sig *Signature) bool {
+	return verifyProjective(p.pubKey, hashToInt(hash, S256()), sig.R, sig.S,
+		p.scalarMultJacobian)
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+// TestPrecomputedPubKeyVerify ensures verifying signatures with a precomputed
+// public key table produces the same results as regular verification.
+func TestPrecomputedPubKeyVerify(t *testing.T) {
+	privKey, err := NewPrivateKey(S256())
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	table := privKey.PubKey().Precompute()
+	if !table.PubKey().IsEqual(privKey.PubKey()) {
+		t.Fatal("table is for the wrong public key")
+	}
+
+	for i := 0; i < 64; i++ {
+		hash := sha256.Sum256([]byte(fmt.Sprintf("precomputed %d", i)))
+		sig, err := privKey.Sign(hash[:])
+		if err != nil {
+			t.Fatalf("%d: failed to sign: %v", i, err)
+		}
+		otherHash := sha256.Sum256(hash[:])
+		tampered := &Signature{R: sig.R, S: new(big.Int).Add(sig.S, one)}
+
+		tests := []struct {
+			name string
+			hash []byte
+			sig  *Signature
+		}{
+			{"valid", hash[:], sig},
+			{"wrong hash", otherHash[:], sig},
+			{"tampered", hash[:], tampered},
+		}
+		for _, test := range tests {
+			want := test.sig.Verify(test.hash, privKey.PubKey())
+			if got := table.Verify(test.hash, test.sig); got != want {
+				t.Fatalf("%d: %s: got %v, want %v", i, test.name,
+					got, want)
+			}
+			if test.name == "valid" && !want {
+				t.Fatalf("%d: valid signature failed to verify", i)
+			}
+		}
+	}
+}
+
+// BenchmarkVerifyPrecomputed benchmarks verifying a signature with a
+// precomputed public key table, excluding the cost of building the table.
+func BenchmarkVerifyPrecomputed(b *testing.B) {
+	privKey, _ := PrivKeyFromBytes(S256(), decodeHex("eaf02ca348c524e6"+
+		"392655ba4d29603cd1a7347d9d65cfe93ce1ebffdca22694"))
+	hash := sha256.Sum256([]byte("benchmark"))
+	sig, err := privKey.Sign(hash[:])
+	if err != nil {
+		b.Fatalf("failed to sign: %v", err)
+	}
+
+	b.Run("regular", func(b *testing.B) {
+		pubKey := privKey.PubKey()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sig.Verify(hash[:], pubKey)
+		}
+	})
+	b.Run("precomputed", func(b *testing.B) {
+		table := privKey.PubKey().Precompute()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			table.Verify(hash[:], sig)
+		}
+	})
+}
+
+// BenchmarkPrecompute benchmarks building a precomputed public key table.
+func BenchmarkPrecompute(b *testing.B) {
+	_, pubKey := PrivKeyFromBytes(S256(), decodeHex("eaf02ca348c524e6"+
+		"392655ba4d29603cd1a7347d9d65cfe93ce1ebffdca22694"))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		pubKey.Precompute()
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package ring implements linkable spontaneous anonymous group (LSAG) ring
+// signatures over the secp256k1 curve.
+//
+// A ring signature proves that the message was signed by the private key of
+// one of the public keys in a ring without revealing which one.  Every
+// signature carries the key image of the signing key as computed by
+// secp256k1.KeyImage, so two signatures made with the same key can be linked
+// by comparing their key images, regardless of the rings or messages used.
+package ring
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/sammyne/secp256k1"
+)
+
+const (
+	// ringTag is the tag used to commit to the ring, the key image and the
+	// message that all challenges of a signature are bound to.
+	ringTag = "LSAG/ring"
+
+	// challengeTag is the tag used to compute the challenge of each ring
+	// member.
+	challengeTag = "LSAG/challenge"
+
+	// nonceTag is the tag used to deterministically derive the nonce of
+	// the signer and the responses of the other ring members.
+	nonceTag = "LSAG/nonce"
+)
+
+// RingSignature is an LSAG ring signature.  It consists of the challenge C0 of
+// the first ring member, one response per ring member, and the key image of
+// the signing key.
+type RingSignature struct {
+	C0       *big.Int
+	S        []*big.Int
+	KeyImage *secp256k1.PublicKey
+}
+
+// commitment returns the hash binding the challenges of a signature to the
+// ring, the key image and the message.
+func commitment(ring []*secp256k1.PublicKey, image *secp256k1.PublicKey, msg []byte) []byte {
+	parts := make([][]byte, 0, len(ring)+2)
+	for _, pub := range ring {
+		parts = append(parts, pub.SerializeCompressed())
+	}
+	parts = append(parts, image.SerializeCompressed(), msg)
+	return secp256k1.TaggedHash(ringTag, parts...)
+}
+
+// challenge returns the challenge that follows the ring member with the
+// commitments L and R.
+func challenge(commit []byte, lx, ly, rx, ry *big.Int) *big.Int {
+	curve := secp256k1.S256()
+	l := secp256k1.PublicKey{Curve: curve, X: lx, Y: ly}
+	r := secp256k1.PublicKey{Curve: curve, X: rx, Y: ry}
+	c := new(big.Int).SetBytes(secp256k1.TaggedHash(challengeTag, commit,
+		l.SerializeCompressed(), r.SerializeCompressed()))
+	return c.Mod(c, curve.N)
+}
+
+// commitments returns L = s*G + c*P and R = s*Hp(P) + c*I for the ring member
+// with public key P, where Hp is secp256k1.HashToCurve applied to the
+// compressed serialization of P.
+func commitments(pub, image *secp256k1.PublicKey, s, c *big.Int) (lx, ly, rx, ry *big.Int) {
+	curve := secp256k1.S256()
+	hp := secp256k1.HashToCurve(pub.SerializeCompressed())
+
+	sgx, sgy := curve.ScalarBaseMult(s.Bytes())
+	cpx, cpy := curve.ScalarMult(pub.X, pub.Y, c.Bytes())
+	lx, ly = curve.Add(sgx, sgy, cpx, cpy)
+
+	shx, shy := curve.ScalarMult(hp.X, hp.Y, s.Bytes())
+	cix, ciy := curve.ScalarMult(image.X, image.Y, c.Bytes())
+	rx, ry = curve.Add(shx, shy, cix, ciy)
+	return lx, ly, rx, ry
+}
+
+// Sign produces a ring signature of the passed message by the private key on
+// behalf of the ring, which must contain the public key of the private key.
+//
+// The nonce of the signer and the responses of the other ring members are
+// derived deterministically from the private key, the ring and the message, so
+// signing the same message for the same ring twice yields the same signature.
+//
+// An error is returned when the private key is not in the range [1, N-1], the
+// ring contains a key that is not on the curve, or the public key of the
+// private key is not in the ring.
+func Sign(priv *secp256k1.PrivateKey, ring []*secp256k1.PublicKey, msg []byte) (*RingSignature, error) {
+	curve := secp256k1.S256()
+	image, err := secp256k1.KeyImage(priv)
+	if err != nil {
+		return nil, err
+	}
+
+	pub := priv.PubKey()
+	signer := -1
+	for i, member := range ring {
+		if member == nil || !curve.IsOnCurve(member.X, member.Y) {
+			return nil, fmt.Errorf("ring member %d is not on the curve", i)
+		}
+		if signer == -1 && member.IsEqual(pub) {
+			signer = i
+		}
+	}
+	if signer == -1 {
+		return nil, errors.New("public key of the signer is not in the ring")
+	}
+
+	commit := commitment(ring, image, msg)
+	xBytes := make([]byte, 32)
+	dBytes := priv.D.Bytes()
+	copy(xBytes[32-len(dBytes):], dBytes)
+	seed := secp256k1.TaggedHash(nonceTag, xBytes, commit)
+
+	// derive returns the i-th deterministic scalar of the signature.
+	derive := func(i int) *big.Int {
+		k := new(big.Int).SetBytes(secp256k1.TaggedHash(nonceTag, seed,
+			big.NewInt(int64(i)).Bytes()))
+		return k.Mod(k, curve.N)
+	}
+
+	// Start the ring at the signer with L = k*G and R = k*Hp(P), then walk
+	// around it with the derived responses until the challenge of the
+	// signer is known.
+	n := len(ring)
+	s := make([]*big.Int, n)
+	c := make([]*big.Int, n)
+	k := derive(signer)
+	hp := secp256k1.HashToCurve(pub.SerializeCompressed())
+	lx, ly := curve.ScalarBaseMult(k.Bytes())
+	rx, ry := curve.ScalarMult(hp.X, hp.Y, k.Bytes())
+	c[(signer+1)%n] = challenge(commit, lx, ly, rx, ry)
+	for i := (signer + 1) % n; i != signer; i = (i + 1) % n {
+		s[i] = derive(i)
+		lx, ly, rx, ry = commitments(ring[i], image, s[i], c[i])
+		c[(i+1)%n] = challenge(commit, lx, ly, rx, ry)
+	}
+
+	// Close the ring with s = k - c*x so the commitments of the signer
+	// match the ones the walk started with.
+	sig := new(big.Int).Mul(c[signer], priv.D)
+	sig.Sub(k, sig)
+	s[signer] = sig.Mod(sig, curve.N)
+
+	return &RingSignature{C0: c[0], S: s, KeyImage: image}, nil
+}
+
+// Verify returns whether or not the passed ring signature of the message was
+// produced by the private key of one of the public keys of the ring.
+func Verify(ring []*secp256k1.PublicKey, msg []byte, sig *RingSignature) bool {
+	curve := secp256k1.S256()
+	if len(ring) == 0 || sig == nil || len(sig.S) != len(ring) {
+		return false
+	}
+	for _, pub := range append([]*secp256k1.PublicKey{sig.KeyImage}, ring...) {
+		if pub == nil || !curve.IsOnCurve(pub.X, pub.Y) {
+			return false
+		}
+	}
+	for _, v := range append([]*big.Int{sig.C0}, sig.S...) {
+		if v == nil || v.Sign() < 0 || v.Cmp(curve.N) >= 0 {
+			return false
+		}
+	}
+
+	// Walk around the ring recomputing each challenge from the previous
+	// one.  The signature is valid when the walk ends at C0 again.
+	commit := commitment(ring, sig.KeyImage, msg)
+	c := sig.C0
+	for i, pub := range ring {
+		lx, ly, rx, ry := commitments(pub, sig.KeyImage, sig.S[i], c)
+		c = challenge(commit, lx, ly, rx, ry)
+	}
+	return c.Cmp(sig.C0) == 0
+}
+
+// Linked returns whether or not the passed ring signatures were produced with
+// the same private key, which is the case when their key images are equal.
+func Linked(a, b *RingSignature) bool {
+	return a.KeyImage.IsEqual(b.KeyImage)
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ring
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/sammyne/secp256k1"
+)
+
+// newKeys returns n freshly generated private keys along with the ring of
+// their public keys.
+func newKeys(t *testing.T, n int) ([]*secp256k1.PrivateKey, []*secp256k1.PublicKey) {
+	privs := make([]*secp256k1.PrivateKey, n)
+	ring := make([]*secp256k1.PublicKey, n)
+	for i := range privs {
+		priv, err := secp256k1.NewPrivateKey(secp256k1.S256())
+		if err != nil {
+			t.Fatalf("failed to generate private key: %v", err)
+		}
+		privs[i], ring[i] = priv, priv.PubKey()
+	}
+	return privs, ring
+}
+
+// TestSignVerify ensures ring signatures made by every member of rings of
+// various sizes verify and that signatures with tampered values, messages or
+// rings do not.
+func TestSignVerify(t *testing.T) {
+	msg := []byte("ring signature test message")
+	for _, n := range []int{1, 2, 5} {
+		privs, ring := newKeys(t, n)
+		for i, priv := range privs {
+			sig, err := Sign(priv, ring, msg)
+			if err != nil {
+				t.Fatalf("ring of %d, signer %d: unexpected error: %v", n,
+					i, err)
+			}
+			if !Verify(ring, msg, sig) {
+				t.Fatalf("ring of %d, signer %d: valid signature "+
+					"rejected", n, i)
+			}
+
+			// copySig returns a deep copy of the signature.
+			copySig := func() *RingSignature {
+				s := make([]*big.Int, len(sig.S))
+				for j := range s {
+					s[j] = new(big.Int).Set(sig.S[j])
+				}
+				return &RingSignature{C0: new(big.Int).Set(sig.C0),
+					S: s, KeyImage: sig.KeyImage}
+			}
+
+			tamperedC0 := copySig()
+			tamperedC0.C0.Add(tamperedC0.C0, big.NewInt(1))
+			tamperedS := copySig()
+			tamperedS.S[(i+1)%n].Add(tamperedS.S[(i+1)%n], big.NewInt(1))
+			_, other := newKeys(t, 1)
+			otherImage := copySig()
+			otherImage.KeyImage = other[0]
+			otherRing := append([]*secp256k1.PublicKey(nil), ring...)
+			otherRing[(i+1)%n] = other[0]
+
+			tests := []struct {
+				name string
+				ring []*secp256k1.PublicKey
+				msg  []byte
+				sig  *RingSignature
+			}{
+				{"tampered C0", ring, msg, tamperedC0},
+				{"tampered response", ring, msg, tamperedS},
+				{"other key image", ring, msg, otherImage},
+				{"other message", ring, []byte("other message"), sig},
+				{"other ring", otherRing, msg, sig},
+				{"missing response", ring, msg, &RingSignature{C0: sig.C0,
+					S: sig.S[1:], KeyImage: sig.KeyImage}},
+				{"empty ring", nil, msg, sig},
+				{"nil signature", ring, msg, nil},
+			}
+			for _, test := range tests {
+				if Verify(test.ring, test.msg, test.sig) {
+					t.Errorf("ring of %d, signer %d: %s: signature "+
+						"accepted", n, i, test.name)
+				}
+			}
+		}
+	}
+}
+
+// TestLinkability ensures signatures made with the same key share a key image
+// regardless of the ring and message while signatures made with different keys
+// do not.
+func TestLinkability(t *testing.T) {
+	privs, ring := newKeys(t, 4)
+	_, others := newKeys(t, 2)
+	otherRing := append([]*secp256k1.PublicKey{privs[0].PubKey()}, others...)
+
+	sig1, err := Sign(privs[0], ring, []byte("first message"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sig2, err := Sign(privs[0], otherRing, []byte("second message"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sig3, err := Sign(privs[1], ring, []byte("first message"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !Linked(sig1, sig2) {
+		t.Fatal("signatures by the same key are not linked")
+	}
+	if Linked(sig1, sig3) {
+		t.Fatal("signatures by different keys are linked")
+	}
+	want, err := secp256k1.KeyImage(privs[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !sig1.KeyImage.IsEqual(want) {
+		t.Fatal("key image of signature does not match KeyImage")
+	}
+}
+
+// TestSignErrors ensures signing fails when the signer is not in the ring or
+// the ring contains keys that are not on the curve.
+func TestSignErrors(t *testing.T) {
+	privs, ring := newKeys(t, 3)
+	curve := secp256k1.S256()
+	offCurve := &secp256k1.PublicKey{Curve: curve, X: curve.Gx,
+		Y: new(big.Int).Add(curve.Gy, big.NewInt(1))}
+
+	tests := []struct {
+		name string
+		ring []*secp256k1.PublicKey
+	}{
+		{"signer not in ring", ring[1:]},
+		{"empty ring", nil},
+		{"key not on curve", append([]*secp256k1.PublicKey{offCurve}, ring...)},
+		{"nil key", append([]*secp256k1.PublicKey{nil}, ring...)},
+	}
+	for _, test := range tests {
+		if _, err := Sign(privs[0], test.ring, []byte("msg")); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// InvertScalar returns the multiplicative inverse of the big endian integer k
+// modulo the group order N as a 32-byte big endian integer.  An error is
+// returned when k is congruent to zero modulo N since it has no inverse.
+//
+// The inverse is computed via Fermat's little theorem as k^(N-2) mod N rather
+// than with the extended Euclidean algorithm, whose number of steps depends on
+// k.  The exponentiation works on fixed-width 32-bit words: the sequence of
+// squarings and multiplications is determined by the public exponent N-2
+// alone, and each multiplication reduces its product modulo N with a fixed
+// number of folds and a masked final subtraction.  This makes it suitable for
+// secret values such as nonces and blinding factors.
+//
+// NOTE: Only the length of k is allowed to affect the running time.  Scalars
+// longer than 64 bytes are reduced modulo N first in variable time.
+func InvertScalar(k []byte) ([]byte, error) {
+	if len(k) > 64 {
+		kMod := new(big.Int).SetBytes(k)
+		k = kMod.Mod(kMod, S256().N).Bytes()
+	}
+	var kBytes [64]byte
+	copy(kBytes[64-len(k):], k)
+	var words [wideWords]uint32
+	for i := 0; i < 16; i++ {
+		words[i] = binary.BigEndian.Uint32(kBytes[60-4*i:])
+	}
+	base := reduceWideConst(&words)
+	if base.IsZero() {
+		return nil, errors.New("scalar is zero modulo the group order")
+	}
+
+	// inv = k^(N-2) by left-to-right square and multiply over the bits of
+	// the public exponent.
+	exponent := orderWords
+	exponent[0] -= 2
+	inv := ModNScalar{n: [8]uint32{1}}
+	for i := 255; i >= 0; i-- {
+		inv.mulConst(&inv, &inv)
+		if exponent[i/32]>>(uint(i)%32)&1 == 1 {
+			inv.mulConst(&inv, &base)
+		}
+	}
+	invBytes := inv.Bytes()
+	return invBytes[:], nil
+}
+
+// ScalarAdd returns the sum of the big endian integers a and b modulo the
+// group order N as a 32-byte big endian integer.  The inputs are reduced
+// modulo N first, so they may be N or larger.  An error is returned when
+// either input is longer than 32 bytes.
+func ScalarAdd(a, b []byte) ([]byte, error) {
+	aMod, bMod, err := reduceScalarPair(a, b)
+	if err != nil {
+		return nil, err
+	}
+	sum := aMod.Add(aMod, bMod)
+	sum.Mod(sum, S256().N)
+	return paddedAppend(PrivKeyBytesLen, nil, sum.Bytes()), nil
+}
+
+// ScalarSub returns the difference a - b of the big endian integers a and b
+// modulo the group order N as a 32-byte big endian integer.  The result wraps
+// around modulo N when a is less than b, so it is never negative, and the
+// inputs are reduced modulo N first, so they may be N or larger.  An error is
+// returned when either input is longer than 32 bytes.
+//
+// This is the inverse of ScalarAdd, which is useful for recovering the secret
+// t = s - s' of an adaptor signature from the adapted and pre-signature s
+// values.
+func ScalarSub(a, b []byte) ([]byte, error) {
+	aMod, bMod, err := reduceScalarPair(a, b)
+	if err != nil {
+		return nil, err
+	}
+	diff := aMod.Sub(aMod, bMod)
+	diff.Mod(diff, S256().N)
+	return paddedAppend(PrivKeyBytesLen, nil, diff.Bytes()), nil
+}
+
+// reduceScalarPair returns the passed big endian integers reduced modulo the
+// group order N.  An error is returned when either is longer than 32 bytes.
+func reduceScalarPair(a, b []byte) (*big.Int, *big.Int, error) {
+	if len(a) > PrivKeyBytesLen || len(b) > PrivKeyBytesLen {
+		return nil, nil, errors.New("scalar is longer than 32 bytes")
+	}
+	N := S256().N
+	aMod := new(big.Int).SetBytes(a)
+	bMod := new(big.Int).SetBytes(b)
+	return aMod.Mod(aMod, N), bMod.Mod(bMod, N), nil
+}
+
+var (
+	// orderWords is the group order N as little endian 32-bit words.
+	orderWords = [8]uint32{
+		0xd0364141, 0xbfd25e8c, 0xaf48a03b, 0xbaaedce6,
+		0xfffffffe, 0xffffffff, 0xffffffff, 0xffffffff,
+	}
+
+	// orderComplementWords is 2^256 - N as little endian 32-bit words.
+	// Since 2^256 is congruent to it modulo N, multiplying the part of a
+	// value above 2^256 by it folds that part into a smaller value with
+	// the same residue.
+	orderComplementWords = [5]uint32{
+		0x2fc9bebf, 0x402da173, 0x50b75fc4, 0x45512319, 0x00000001,
+	}
+)
+
+// ModNScalar is an integer modulo the group order N.  It is stored as eight
+// little endian 32-bit words and is always fully reduced.
+type ModNScalar struct {
+	n [8]uint32
+}
+
+// Bytes returns the scalar as a 32-byte big endian integer.
+func (s *ModNScalar) Bytes() [32]byte {
+	var b [32]byte
+	for i, word := range s.n {
+		binary.BigEndian.PutUint32(b[28-4*i:], word)
+	}
+	return b
+}
+
+// IsZero returns whether or not the scalar is zero.
+func (s *ModNScalar) IsZero() bool {
+	bits := s.n[0] | s.n[1] | s.n[2] | s.n[3] | s.n[4] | s.n[5] | s.n[6] |
+		s.n[7]
+	return bits == 0
+}
+
+// ReduceMod512 returns the 512-bit big endian integer hi || lo reduced modulo
+// the group order N, such as the output of HMAC-SHA512 or a wide hash that is
+// to be converted to a uniformly distributed scalar.
+//
+// The reduction is done on 32-bit words without big.Int and in constant time
+// by the same code that reduces the products of scalar multiplications, which
+// folds the part of the value above 2^256 back in since 2^256 is congruent to
+// 2^256 - N modulo N.  Note that BIP32 does not reduce the left half of its
+// HMAC-SHA512 output but rejects it instead when it is not less than N, so
+// this is not a replacement for that check.
+func ReduceMod512(hi, lo [32]byte) *ModNScalar {
+	var words [wideWords]uint32
+	for i := 0; i < 8; i++ {
+		words[i] = binary.BigEndian.Uint32(lo[28-4*i:])
+		words[i+8] = binary.BigEndian.Uint32(hi[28-4*i:])
+	}
+	s := reduceWideConst(&words)
+	return &s
+}
+
+// wideWords is the number of words used by the constant time reduction modulo
+// N.  It holds the 512-bit product of two scalars along with the carries of
+// folding it.
+const wideWords = 17
+
+// foldWideConst replaces the passed words with lo + hi*(2^256 - N) where lo is
+// the first eight words and hi is the rest of them, which is congruent modulo
+// N.  Every word is processed regardless of the value and the result is not
+// trimmed, so the time taken does not depend on the value.
+func foldWideConst(words *[wideWords]uint32) {
+	var out [wideWords]uint32
+	copy(out[:8], words[:8])
+	for i, h := range words[8:] {
+		var carry uint64
+		for j, c := range orderComplementWords {
+			t := uint64(out[i+j]) + uint64(h)*uint64(c) + carry
+			out[i+j] = uint32(t)
+			carry = t >> 32
+		}
+		for k := i + len(orderComplementWords); k < wideWords; k++ {
+			t := uint64(out[k]) + carry
+			out[k] = uint32(t)
+			carry = t >> 32
+		}
+	}
+	*words = out
+}
+
+// reduceWideConst returns the passed value of less than 2^512 reduced modulo N
+// in constant time.
+func reduceWideConst(words *[wideWords]uint32) ModNScalar {
+	// Since 2^256 - N is less than 2^129, the folds shrink the value to
+	// below 2^386, 2^260, 2^256 + 2^133, and 2^256 + 2^129 respectively.
+	// When the last of those still has a bit above 2^256, the rest of it
+	// is less than 2^129, so a fifth fold brings it below 2^256.
+	for i := 0; i < 5; i++ {
+		foldWideConst(words)
+	}
+
+	// The value is now less than 2^256 < 2N, so subtract N and keep the
+	// difference when it did not borrow, selecting it with a mask.
+	var diff [8]uint32
+	var borrow uint64
+	for i := range diff {
+		t := uint64(words[i]) - uint64(orderWords[i]) - borrow
+		diff[i] = uint32(t)
+		borrow = (t >> 32) & 1
+	}
+	keepDiff := uint32(borrow) - 1
+	var s ModNScalar
+	for i := range s.n {
+		s.n[i] = diff[i]&keepDiff | words[i]&^keepDiff
+	}
+	return s
+}
+
+// mulConst sets s to a*b modulo N in constant time.  The passed scalars may
+// alias s.
+func (s *ModNScalar) mulConst(a, b *ModNScalar) {
+	var product [wideWords]uint32
+	for i := range a.n {
+		var carry uint64
+		for j := range b.n {
+			t := uint64(product[i+j]) + uint64(a.n[i])*uint64(b.n[j]) +
+				carry
+			product[i+j] = uint32(t)
+			carry = t >> 32
+		}
+		product[i+len(b.n)] = uint32(carry)
+	}
+	*s = reduceWideConst(&product)
+}
@@ -0,0 +1,255 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// TestInvertScalar ensures InvertScalar returns the inverse of random scalars
+// modulo the group order and rejects scalars congruent to zero.
+func TestInvertScalar(t *testing.T) {
+	N := S256().N
+	seed := time.Now().Unix()
+	rng := rand.New(rand.NewSource(seed))
+	for i := 0; i < 100; i++ {
+		k := make([]byte, 32)
+		rng.Read(k)
+		inv, err := InvertScalar(k)
+		if err != nil {
+			if new(big.Int).Mod(new(big.Int).SetBytes(k), N).Sign() == 0 {
+				continue
+			}
+			t.Fatalf("unexpected error inverting %x (seed %d): %v", k,
+				seed, err)
+		}
+		if len(inv) != 32 {
+			t.Fatalf("inverse of %x (seed %d) is %d bytes, want 32", k,
+				seed, len(inv))
+		}
+
+		product := new(big.Int).SetBytes(k)
+		product.Mul(product, new(big.Int).SetBytes(inv))
+		product.Mod(product, N)
+		if product.Cmp(one) != 0 {
+			t.Fatalf("k * InvertScalar(k) = %x for k = %x (seed %d), "+
+				"want 1", product, k, seed)
+		}
+	}
+
+	// The inverse of one is one and scalars are reduced before inverting.
+	nPlus1 := new(big.Int).Add(N, one).Bytes()
+	wideNPlus1 := new(big.Int).Add(new(big.Int).Lsh(N, 256), one).Bytes()
+	hugeNPlus1 := new(big.Int).Add(new(big.Int).Lsh(N, 300), one).Bytes()
+	for _, k := range [][]byte{{1}, nPlus1, wideNPlus1, hugeNPlus1} {
+		inv, err := InvertScalar(k)
+		if err != nil {
+			t.Fatalf("unexpected error inverting %x: %v", k, err)
+		}
+		if new(big.Int).SetBytes(inv).Cmp(one) != 0 {
+			t.Fatalf("inverse of %x is %x, want 1", k, inv)
+		}
+	}
+
+	// Scalars congruent to zero have no inverse.
+	twoN := new(big.Int).Lsh(N, 1).Bytes()
+	for _, k := range [][]byte{nil, {0}, N.Bytes(), twoN} {
+		if _, err := InvertScalar(k); err == nil {
+			t.Fatalf("inverted %x without error", k)
+		}
+	}
+}
+
+// TestScalarSub ensures ScalarSub matches big.Int subtraction modulo the group
+// order for random values, including when the first is smaller and inputs are
+// not reduced, and that it undoes ScalarAdd.
+func TestScalarSub(t *testing.T) {
+	N := S256().N
+	seed := time.Now().Unix()
+	rng := rand.New(rand.NewSource(seed))
+
+	// Random 32-byte values are N or larger with negligible probability,
+	// so include such values explicitly along with a smaller first value.
+	nMinus1 := new(big.Int).Sub(N, one).Bytes()
+	nPlus5 := new(big.Int).Add(N, big.NewInt(5)).Bytes()
+	maxScalar := bytes.Repeat([]byte{0xff}, 32)
+	pairs := [][2][]byte{
+		{{5}, {3}},
+		{{3}, {5}},
+		{nil, {1}},
+		{{7}, {7}},
+		{N.Bytes(), {1}},
+		{nPlus5, {7}},
+		{nMinus1, maxScalar},
+		{maxScalar, nMinus1},
+	}
+	for i := 0; i < 100; i++ {
+		a, b := make([]byte, 32), make([]byte, 32)
+		rng.Read(a)
+		rng.Read(b)
+		pairs = append(pairs, [2][]byte{a, b})
+	}
+
+	for _, pair := range pairs {
+		a, b := pair[0], pair[1]
+		diff, err := ScalarSub(a, b)
+		if err != nil {
+			t.Fatalf("unexpected error for %x - %x (seed %d): %v", a, b,
+				seed, err)
+		}
+		if len(diff) != 32 {
+			t.Fatalf("%x - %x (seed %d) is %d bytes, want 32", a, b,
+				seed, len(diff))
+		}
+		want := new(big.Int).Sub(new(big.Int).SetBytes(a),
+			new(big.Int).SetBytes(b))
+		want.Mod(want, N)
+		if got := new(big.Int).SetBytes(diff); got.Cmp(want) != 0 {
+			t.Fatalf("%x - %x = %x (seed %d), want %x", a, b, got, seed,
+				want)
+		}
+
+		// (a - b) + b = a mod N.
+		sum, err := ScalarAdd(diff, b)
+		if err != nil {
+			t.Fatalf("unexpected error for %x + %x (seed %d): %v", diff,
+				b, seed, err)
+		}
+		wantSum := new(big.Int).Mod(new(big.Int).SetBytes(a), N)
+		if got := new(big.Int).SetBytes(sum); got.Cmp(wantSum) != 0 {
+			t.Fatalf("(%x - %x) + %x = %x (seed %d), want %x", a, b, b,
+				got, seed, wantSum)
+		}
+	}
+
+	// Inputs longer than 32 bytes are rejected.
+	long := make([]byte, 33)
+	for _, pair := range [][2][]byte{{long, {1}}, {{1}, long}} {
+		if _, err := ScalarSub(pair[0], pair[1]); err == nil {
+			t.Fatalf("ScalarSub accepted a %d and %d byte input",
+				len(pair[0]), len(pair[1]))
+		}
+		if _, err := ScalarAdd(pair[0], pair[1]); err == nil {
+			t.Fatalf("ScalarAdd accepted a %d and %d byte input",
+				len(pair[0]), len(pair[1]))
+		}
+	}
+}
+
+// TestMulConst ensures the constant time multiplication and wide reduction
+// modulo the group order used by InvertScalar match big.Int for random values
+// and the largest possible inputs.
+func TestMulConst(t *testing.T) {
+	N := S256().N
+	seed := time.Now().Unix()
+	rng := rand.New(rand.NewSource(seed))
+
+	// scalar returns the scalar with the passed value, which must be less
+	// than N.
+	scalar := func(v *big.Int) *ModNScalar {
+		var b [32]byte
+		var s ModNScalar
+		for i := range s.n {
+			s.n[i] = binary.BigEndian.Uint32(bigIntToBytes32(v, &b)[28-4*i:])
+		}
+		return &s
+	}
+
+	nMinus1 := new(big.Int).Sub(N, one)
+	values := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(2), nMinus1}
+	for i := 0; i < 16; i++ {
+		values = append(values, new(big.Int).Rand(rng, N))
+	}
+	for _, a := range values {
+		for _, b := range values {
+			var got ModNScalar
+			got.mulConst(scalar(a), scalar(b))
+			want := new(big.Int).Mul(a, b)
+			want.Mod(want, N)
+			gotBytes := got.Bytes()
+			if new(big.Int).SetBytes(gotBytes[:]).Cmp(want) != 0 {
+				t.Fatalf("%x * %x = %x (seed %d), want %x", a, b,
+					gotBytes, seed, want)
+			}
+		}
+	}
+
+	// Reducing the largest 512-bit value, values just below and above
+	// multiples of 2^256, and random values.
+	wide := []*big.Int{
+		new(big.Int).Sub(new(big.Int).Lsh(one, 512), one),
+		new(big.Int).Sub(new(big.Int).Lsh(one, 256), one),
+		new(big.Int).Lsh(one, 256),
+		new(big.Int).Add(new(big.Int).Lsh(one, 256), nMinus1),
+		N,
+		nMinus1,
+	}
+	for i := 0; i < 64; i++ {
+		v := make([]byte, 64)
+		rng.Read(v)
+		wide = append(wide, new(big.Int).SetBytes(v))
+	}
+	for _, v := range wide {
+		var b [64]byte
+		vBytes := v.Bytes()
+		copy(b[64-len(vBytes):], vBytes)
+		var words [wideWords]uint32
+		for i := 0; i < 16; i++ {
+			words[i] = binary.BigEndian.Uint32(b[60-4*i:])
+		}
+		got := reduceWideConst(&words)
+		gotBytes := got.Bytes()
+		want := new(big.Int).Mod(v, N)
+		if new(big.Int).SetBytes(gotBytes[:]).Cmp(want) != 0 {
+			t.Fatalf("%x mod N = %x (seed %d), want %x", v, gotBytes,
+				seed, want)
+		}
+	}
+}
+
+// TestReduceMod512 ensures reducing 512-bit values modulo the group order with
+// ReduceMod512 matches big.Int for random values and the edge cases.
+func TestReduceMod512(t *testing.T) {
+	N := S256().N
+	two512 := new(big.Int).Lsh(big.NewInt(1), 512)
+	tests := []*big.Int{
+		new(big.Int),
+		big.NewInt(1),
+		new(big.Int).Sub(N, big.NewInt(1)),
+		N,
+		new(big.Int).Add(N, big.NewInt(1)),
+		new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)),
+		new(big.Int).Lsh(big.NewInt(1), 256),
+		new(big.Int).Mul(N, N),
+		new(big.Int).Sub(two512, big.NewInt(1)),
+		new(big.Int).Sub(two512, N),
+	}
+	seed := time.Now().Unix()
+	rng := rand.New(rand.NewSource(seed))
+	for i := 0; i < 1000; i++ {
+		b := make([]byte, 64)
+		rng.Read(b)
+		tests = append(tests, new(big.Int).SetBytes(b))
+	}
+
+	for i, v := range tests {
+		wide := paddedAppend(64, nil, v.Bytes())
+		var hi, lo [32]byte
+		copy(hi[:], wide[:32])
+		copy(lo[:], wide[32:])
+
+		got := ReduceMod512(hi, lo).Bytes()
+		want := paddedAppend(32, nil, new(big.Int).Mod(v, N).Bytes())
+		if !bytes.Equal(got[:], want) {
+			t.Fatalf("#%d: mismatched reduction of %x (seed %d) - got "+
+				"%x, want %x", i, wide, seed, got, want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package schnorr
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/sammyne/secp256k1"
+	"github.com/sammyne/secp256k1/internal/bigint"
+)
+
+// adaptorNonceTag is the tag used to deterministically derive the nonce of
+// an adaptor signature.
+const adaptorNonceTag = "secp256k1/adaptor/nonce"
+
+// AdaptorSignature is a Schnorr pre-signature that is bound to an adaptor
+// point T = t*G.  It is not a valid BIP340 signature by itself, but becomes
+// one once it is adapted with the secret t, and anyone holding both the
+// pre-signature and the adapted signature can extract t.
+//
+// R is the full nonce point, including the adaptor point, of the signature
+// that results from adapting the pre-signature.  Its parity determines whether
+// the secret is added to or subtracted from S during adaptation.
+type AdaptorSignature struct {
+	R *secp256k1.PublicKey
+	S *big.Int
+}
+
+// xOnly returns the 32-byte x coordinate of the passed public key.
+func xOnly(pubKey *secp256k1.PublicKey) [32]byte {
+	return bigint.Bytes32(pubKey.X)
+}
+
+// SignAdaptor produces a pre-signature of the passed message with the private
+// key which is bound to the passed adaptor point.  The pre-signature can be
+// checked with VerifyAdaptor and turned into a valid BIP340 signature for the
+// x-only public key of the private key with Adapt.
+//
+// The nonce is derived deterministically from the private key, message and
+// adaptor point, so signing the same message with different adaptor points
+// never reuses a nonce.
+func SignAdaptor(priv *secp256k1.PrivateKey, msg [32]byte,
+	adaptor *secp256k1.PublicKey) (*AdaptorSignature, error) {
+
+	curve := secp256k1.S256()
+	d := new(big.Int).Set(priv.D)
+	if d.Sign() == 0 || d.Cmp(curve.N) >= 0 {
+		return nil, errors.New("private key is out of range")
+	}
+	if adaptor == nil || !curve.IsOnCurve(adaptor.X, adaptor.Y) {
+		return nil, errors.New("adaptor point is not on the curve")
+	}
+
+	// BIP340 public keys implicitly have an even y coordinate, so negate the
+	// private key when its public key does not.
+	px, py := curve.ScalarBaseMult(priv.D.Bytes())
+	if py.Bit(0) == 1 {
+		d.Sub(curve.N, d)
+	}
+	pubX := bigint.Bytes32(px)
+
+	dBytes := bigint.Bytes32(d)
+	adaptorBytes := adaptor.SerializeCompressed()
+	k := new(big.Int).SetBytes(secp256k1.TaggedHash(adaptorNonceTag,
+		dBytes[:], pubX[:], adaptorBytes, msg[:]))
+	k.Mod(k, curve.N)
+	if k.Sign() == 0 {
+		return nil, errors.New("derived nonce is zero")
+	}
+
+	// R = k*G + T.  When R has an odd y coordinate the adapted signature
+	// will be made for -R instead, so negate the nonce so that adaptation
+	// only needs to subtract the secret.
+	kx, ky := curve.ScalarBaseMult(k.Bytes())
+	rx, ry := curve.Add(kx, ky, adaptor.X, adaptor.Y)
+	if rx.Sign() == 0 && ry.Sign() == 0 {
+		return nil, errors.New("nonce point is the point at infinity")
+	}
+	if ry.Bit(0) == 1 {
+		k.Sub(curve.N, k)
+	}
+
+	// s' = k + e*d mod N.
+	e := Challenge(bigint.Bytes32(rx), pubX, msg)
+	s := new(big.Int).Mul(e, d)
+	s.Add(s, k)
+	s.Mod(s, curve.N)
+
+	r := &secp256k1.PublicKey{Curve: curve, X: rx, Y: ry}
+	return &AdaptorSignature{R: r, S: s}, nil
+}
+
+// VerifyAdaptor returns whether or not the passed pre-signature is valid for
+// the message under the x-only public key and adaptor point.  A valid
+// pre-signature guarantees that adapting it with the discrete logarithm of
+// the adaptor point yields a valid BIP340 signature.
+func VerifyAdaptor(pubX, msg [32]byte, adaptor *secp256k1.PublicKey,
+	sig *AdaptorSignature) bool {
+
+	curve := secp256k1.S256()
+	if sig == nil || sig.R == nil || sig.S == nil || adaptor == nil {
+		return false
+	}
+	if sig.S.Sign() < 0 || sig.S.Cmp(curve.N) >= 0 {
+		return false
+	}
+	if !curve.IsOnCurve(sig.R.X, sig.R.Y) ||
+		!curve.IsOnCurve(adaptor.X, adaptor.Y) {
+
+		return false
+	}
+	pubKey, err := secp256k1.PublicKeyFromXAndParity(pubX, 0x02)
+	if err != nil {
+		return false
+	}
+
+	// s'*G - e*P must be R - T when R has an even y coordinate and T - R
+	// otherwise.
+	e := Challenge(xOnly(sig.R), pubX, msg)
+	negE := new(big.Int).Sub(curve.N, e)
+	sx, sy := curve.ScalarBaseMult(sig.S.Bytes())
+	ex, ey := curve.ScalarMult(pubKey.X, pubKey.Y, negE.Bytes())
+	gotX, gotY := curve.Add(sx, sy, ex, ey)
+
+	negY := new(big.Int).Sub(curve.P, adaptor.Y)
+	wantX, wantY := curve.Add(sig.R.X, sig.R.Y, adaptor.X, negY)
+	if sig.R.Y.Bit(0) == 1 {
+		wantY.Sub(curve.P, wantY)
+		wantY.Mod(wantY, curve.P)
+	}
+	return gotX.Cmp(wantX) == 0 && gotY.Cmp(wantY) == 0
+}
+
+// Adapt combines the passed pre-signature with the secret t, which must be
+// the discrete logarithm of the adaptor point the pre-signature was made for,
+// into a 64-byte BIP340 signature.
+func Adapt(sig *AdaptorSignature, t *big.Int) [64]byte {
+	curve := secp256k1.S256()
+	s := new(big.Int)
+	if sig.R.Y.Bit(0) == 0 {
+		s.Add(sig.S, t)
+	} else {
+		s.Sub(sig.S, t)
+	}
+	s.Mod(s, curve.N)
+
+	var out [64]byte
+	rX, sBytes := xOnly(sig.R), bigint.Bytes32(s)
+	copy(out[:32], rX[:])
+	copy(out[32:], sBytes[:])
+	return out
+}
+
+// ExtractSecret returns the secret t that was used to adapt the passed
+// pre-signature into the passed BIP340 signature.  An error is returned when
+// the signature was not produced from the pre-signature.
+func ExtractSecret(sig *AdaptorSignature, adapted [64]byte) (*big.Int, error) {
+	rX := xOnly(sig.R)
+	var sigRX [32]byte
+	copy(sigRX[:], adapted[:32])
+	if rX != sigRX {
+		return nil, errors.New("signature nonce does not match the " +
+			"pre-signature")
+	}
+
+	curve := secp256k1.S256()
+	s := new(big.Int).SetBytes(adapted[32:])
+	if s.Cmp(curve.N) >= 0 {
+		return nil, errors.New("signature s value is out of range")
+	}
+	preS := bigint.Bytes32(sig.S)
+	a, b := adapted[32:], preS[:]
+	if sig.R.Y.Bit(0) == 1 {
+		a, b = b, a
+	}
+	t, err := secp256k1.ScalarSub(a, b)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(t), nil
+}
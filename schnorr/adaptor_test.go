@@ -0,0 +1,84 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package schnorr
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/sammyne/secp256k1"
+	"github.com/sammyne/secp256k1/internal/bigint"
+)
+
+// TestAdaptorSignature ensures pre-signatures verify against their adaptor
+// point, adapt into valid BIP340 signatures, and leak the adaptor secret once
+// both the pre-signature and the adapted signature are known.
+func TestAdaptorSignature(t *testing.T) {
+	curve := secp256k1.S256()
+	for i := 0; i < 32; i++ {
+		// Derive the private key, adaptor secret and message from the
+		// iteration so both nonce and key parities get covered.
+		seed := sha256.Sum256([]byte{byte(i)})
+		priv, _ := secp256k1.PrivKeyFromBytes(curve, seed[:])
+		secretBytes := sha256.Sum256(seed[:])
+		secret := new(big.Int).SetBytes(secretBytes[:])
+		secret.Mod(secret, curve.N)
+		_, adaptor := secp256k1.PrivKeyFromBytes(curve, secret.Bytes())
+		msg := sha256.Sum256(secretBytes[:])
+		pubX := xOnly(priv.PubKey())
+
+		preSig, err := SignAdaptor(priv, msg, adaptor)
+		if err != nil {
+			t.Fatalf("#%d: unexpected error signing: %v", i, err)
+		}
+		if !VerifyAdaptor(pubX, msg, adaptor, preSig) {
+			t.Fatalf("#%d: valid pre-signature failed to verify", i)
+		}
+
+		// The pre-signature must not verify against a different adaptor
+		// point or message.
+		otherAdaptor := priv.PubKey()
+		if VerifyAdaptor(pubX, msg, otherAdaptor, preSig) {
+			t.Fatalf("#%d: pre-signature verified with wrong adaptor", i)
+		}
+		otherMsg := msg
+		otherMsg[0] ^= 0x01
+		if VerifyAdaptor(pubX, otherMsg, adaptor, preSig) {
+			t.Fatalf("#%d: pre-signature verified with wrong message", i)
+		}
+
+		// The pre-signature on its own is not a valid signature.
+		var unadapted [64]byte
+		rX, s := xOnly(preSig.R), bigint.Bytes32(preSig.S)
+		copy(unadapted[:32], rX[:])
+		copy(unadapted[32:], s[:])
+		if Verify(pubX, msg, unadapted) {
+			t.Fatalf("#%d: unadapted pre-signature is a valid "+
+				"signature", i)
+		}
+
+		sig := Adapt(preSig, secret)
+		if !Verify(pubX, msg, sig) {
+			t.Fatalf("#%d: adapted signature failed to verify", i)
+		}
+
+		extracted, err := ExtractSecret(preSig, sig)
+		if err != nil {
+			t.Fatalf("#%d: unexpected error extracting secret: %v",
+				i, err)
+		}
+		if extracted.Cmp(secret) != 0 {
+			t.Fatalf("#%d: mismatched secret - got %x, want %x", i,
+				extracted, secret)
+		}
+
+		// Extraction must fail for a signature with a different nonce.
+		sig[0] ^= 0x01
+		if _, err := ExtractSecret(preSig, sig); err == nil {
+			t.Fatalf("#%d: extracted secret from unrelated signature", i)
+		}
+	}
+}
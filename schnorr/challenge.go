@@ -0,0 +1,33 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package schnorr implements Schnorr signatures over the secp256k1 curve as
+// specified by BIP340 along with the building blocks needed to compose them
+// into more advanced protocols.
+package schnorr
+
+import (
+	"math/big"
+
+	"github.com/sammyne/secp256k1"
+)
+
+// ChallengeTag is the tag used to compute the BIP340 challenge hash.
+const ChallengeTag = "BIP0340/challenge"
+
+// Challenge computes the BIP340 challenge for the passed x coordinate of the
+// nonce point R, x-only public key, and message.  The challenge is the tagged
+// hash of the concatenation of the three values interpreted as a big endian
+// integer and reduced modulo the group order N.  That is to say:
+//
+//	e = int(TaggedHash("BIP0340/challenge", rX || pubX || msg)) mod N
+//
+// Exposing the challenge makes it possible to build protocols such as adaptor
+// signatures and multisignatures on top of BIP340 without re-deriving the
+// exact tagged hash construction.
+func Challenge(rX, pubX, msg [32]byte) *big.Int {
+	h := secp256k1.TaggedHash(ChallengeTag, rX[:], pubX[:], msg[:])
+	e := new(big.Int).SetBytes(h)
+	return e.Mod(e, secp256k1.S256().N)
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package schnorr
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/sammyne/secp256k1"
+)
+
+// hexToBytes32 converts the passed hex string into a 32-byte array and will
+// panic if there is an error.  This is only provided for the hard-coded
+// constants so errors in the source code can be detected.
+func hexToBytes32(s string) [32]byte {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 32 {
+		panic("invalid 32-byte hex in test source: " + s)
+	}
+	var arr [32]byte
+	copy(arr[:], b)
+	return arr
+}
+
+// TestChallenge ensures the challenge computed for BIP340 test vectors
+// satisfies the verification equation s*G = R + e*P of their signatures.
+func TestChallenge(t *testing.T) {
+	tests := []struct {
+		name string
+		pubX string
+		msg  string
+		sig  string
+	}{{
+		name: "BIP340 vector 0",
+		pubX: "f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+		msg:  "0000000000000000000000000000000000000000000000000000000000000000",
+		sig: "e907831f80848d1069a5371b402410364bdf1c5f8307b0084c55f1ce2dca8215" +
+			"25f66a4a85ea8b71e482a74f382d2ce5ebeee8fdb2172f477df4900d310536c0",
+	}, {
+		name: "BIP340 vector 1",
+		pubX: "dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+		msg:  "243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+		sig: "6896bd60eeae296db48a229ff71dfe071bde413e6d43f917dc8dcf8c78de3341" +
+			"8906d11ac976abccb20b091292bff4ea897efcb639ea871cfa95f6de339e4b0a",
+	}}
+
+	curve := secp256k1.S256()
+	for _, test := range tests {
+		pubX := hexToBytes32(test.pubX)
+		msg := hexToBytes32(test.msg)
+		rX := hexToBytes32(test.sig[:64])
+		s := hexToBytes32(test.sig[64:])
+
+		e := Challenge(rX, pubX, msg)
+		if e.Sign() < 0 || e.Cmp(curve.N) >= 0 {
+			t.Fatalf("%s: challenge %x is not reduced", test.name, e)
+		}
+
+		// R = s*G - e*P where P is the point with an even y coordinate
+		// for the x-only public key.
+		pubKey, err := secp256k1.PublicKeyFromXAndParity(pubX, 0x02)
+		if err != nil {
+			t.Fatalf("%s: invalid public key: %v", test.name, err)
+		}
+		negE := new(big.Int).Sub(curve.N, e)
+		sgx, sgy := curve.ScalarBaseMult(s[:])
+		epx, epy := curve.ScalarMult(pubKey.X, pubKey.Y, negE.Bytes())
+		rx, ry := curve.Add(sgx, sgy, epx, epy)
+		if rx.Cmp(new(big.Int).SetBytes(rX[:])) != 0 || ry.Bit(0) != 0 {
+			t.Fatalf("%s: challenge %x does not satisfy the "+
+				"verification equation", test.name, e)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package schnorr
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/sammyne/secp256k1"
+	"github.com/sammyne/secp256k1/internal/bigint"
+)
+
+const (
+	// auxTag is the tag used to hash the auxiliary randomness that is mixed
+	// into the private key when deriving the nonce.
+	auxTag = "BIP0340/aux"
+
+	// nonceTag is the tag used to derive the nonce of a signature.
+	nonceTag = "BIP0340/nonce"
+)
+
+// sign produces a BIP340 signature of the passed message with the private key
+// and auxiliary randomness as specified by the default signing algorithm of
+// BIP340.  The signature is verified before it is returned to guard against
+// faults during its computation.
+func sign(priv *secp256k1.PrivateKey, msg, auxRand [32]byte) ([64]byte, error) {
+	var sig [64]byte
+	curve := secp256k1.S256()
+	d := new(big.Int).Set(priv.D)
+	if d.Sign() == 0 || d.Cmp(curve.N) >= 0 {
+		return sig, errors.New("private key is out of range")
+	}
+
+	// BIP340 public keys implicitly have an even y coordinate, so negate the
+	// private key when its public key does not.
+	px, py := curve.ScalarBaseMult(d.Bytes())
+	if py.Bit(0) == 1 {
+		d.Sub(curve.N, d)
+	}
+	pubX := bigint.Bytes32(px)
+
+	// t = bytes(d) xor hash_BIP0340/aux(a)
+	t := bigint.Bytes32(d)
+	auxHash := secp256k1.TaggedHash(auxTag, auxRand[:])
+	for i := range t {
+		t[i] ^= auxHash[i]
+	}
+
+	// k = int(hash_BIP0340/nonce(t || bytes(P) || m)) mod N
+	k := new(big.Int).SetBytes(secp256k1.TaggedHash(nonceTag, t[:],
+		pubX[:], msg[:]))
+	k.Mod(k, curve.N)
+	if k.Sign() == 0 {
+		return sig, errors.New("derived nonce is zero")
+	}
+
+	// Negate the nonce when R has an odd y coordinate for the same reason
+	// as the private key.
+	rx, ry := curve.ScalarBaseMult(k.Bytes())
+	if ry.Bit(0) == 1 {
+		k.Sub(curve.N, k)
+	}
+	rX := bigint.Bytes32(rx)
+
+	// s = k + e*d mod N.
+	e := Challenge(rX, pubX, msg)
+	s := new(big.Int).Mul(e, d)
+	s.Add(s, k)
+	s.Mod(s, curve.N)
+
+	sBytes := bigint.Bytes32(s)
+	copy(sig[:32], rX[:])
+	copy(sig[32:], sBytes[:])
+	if !Verify(pubX, msg, sig) {
+		return [64]byte{}, errors.New("produced signature does not verify")
+	}
+	return sig, nil
+}
+
+// SignDeterministic produces a BIP340 signature of the passed message with the
+// private key using 32 zero bytes as the auxiliary randomness, which BIP340
+// permits.  The signature only depends on the private key and message, which
+// makes it suitable for test vectors and reproducible builds.
+//
+// Auxiliary randomness protects against side channel attacks on the nonce
+// derivation, so signers exposed to such attacks should prefer fresh random
+// bytes where reproducibility is not required.
+func SignDeterministic(priv *secp256k1.PrivateKey, msg [32]byte) ([64]byte, error) {
+	var auxRand [32]byte
+	return sign(priv, msg, auxRand)
+}
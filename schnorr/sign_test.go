@@ -0,0 +1,108 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package schnorr
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/sammyne/secp256k1"
+)
+
+// TestSign ensures signing produces the signatures of the BIP340 test vectors
+// for their auxiliary randomness.
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name    string
+		secKey  string
+		auxRand string
+		msg     string
+		sig     string
+	}{{
+		name:    "BIP340 vector 0",
+		secKey:  "0000000000000000000000000000000000000000000000000000000000000003",
+		auxRand: "0000000000000000000000000000000000000000000000000000000000000000",
+		msg:     "0000000000000000000000000000000000000000000000000000000000000000",
+		sig: "e907831f80848d1069a5371b402410364bdf1c5f8307b0084c55f1ce2dca8215" +
+			"25f66a4a85ea8b71e482a74f382d2ce5ebeee8fdb2172f477df4900d310536c0",
+	}, {
+		name:    "BIP340 vector 1",
+		secKey:  "b7e151628aed2a6abf7158809cf4f3c762e7160f38b4da56a784d9045190cfef",
+		auxRand: "0000000000000000000000000000000000000000000000000000000000000001",
+		msg:     "243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+		sig: "6896bd60eeae296db48a229ff71dfe071bde413e6d43f917dc8dcf8c78de3341" +
+			"8906d11ac976abccb20b091292bff4ea897efcb639ea871cfa95f6de339e4b0a",
+	}, {
+		name:    "BIP340 vector 2",
+		secKey:  "c90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74020bbea63b14e5c9",
+		auxRand: "c87aa53824b4d7ae2eb035a2b5bbbccc080e76cdc6d1692c4b0b62d798e6d906",
+		msg:     "7e2d58d8b3bcdf1abadec7829054f90dda9805aab56c77333024b9d0a508b75c",
+		sig: "5831aaeed7b44bb74e5eab94ba9d4294c49bcf2a60728d8b4c200f50dd313c1b" +
+			"ab745879a5ad954a72c45a91c3a51d3c7adea98d82f8481e0e1e03674a6f3fb7",
+	}, {
+		name:    "BIP340 vector 3",
+		secKey:  "0b432b2677937381aef05bb02a66ecd012773062cf3fa2549e44f58ed2401710",
+		auxRand: "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		msg:     "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		sig: "7eb0509757e246f19449885651611cb965ecc1a187dd51b64fda1edc9637d5ec" +
+			"97582b9cb13db3933705b32ba982af5af25fd78881ebb32771fc5922efc66ea3",
+	}}
+
+	for _, test := range tests {
+		secKey := hexToBytes32(test.secKey)
+		priv, _ := secp256k1.PrivKeyFromBytes(secp256k1.S256(), secKey[:])
+		sig, err := sign(priv, hexToBytes32(test.msg),
+			hexToBytes32(test.auxRand))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if got := hex.EncodeToString(sig[:]); got != test.sig {
+			t.Errorf("%s: mismatched signature -- got %s, want %s",
+				test.name, got, test.sig)
+		}
+	}
+}
+
+// TestSignDeterministic ensures deterministic signing matches the BIP340 test
+// vector that uses zero auxiliary randomness and always produces the same
+// valid signature for the same key and message.
+func TestSignDeterministic(t *testing.T) {
+	secKey := hexToBytes32("0000000000000000000000000000000000000000000000000000000000000003")
+	priv, _ := secp256k1.PrivKeyFromBytes(secp256k1.S256(), secKey[:])
+	var msg [32]byte
+	const want = "e907831f80848d1069a5371b402410364bdf1c5f8307b0084c55f1ce2dca8215" +
+		"25f66a4a85ea8b71e482a74f382d2ce5ebeee8fdb2172f477df4900d310536c0"
+
+	sig, err := SignDeterministic(priv, msg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := hex.EncodeToString(sig[:]); got != want {
+		t.Fatalf("mismatched signature -- got %s, want %s", got, want)
+	}
+
+	// Signing again must produce the same signature while a different
+	// message must produce a different one that verifies.
+	again, err := SignDeterministic(priv, msg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again != sig {
+		t.Fatalf("signature is not deterministic -- got %x, want %x",
+			again, sig)
+	}
+	msg[0] = 0x01
+	other, err := SignDeterministic(priv, msg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if other == sig {
+		t.Fatal("same signature produced for different messages")
+	}
+	if !Verify(xOnly(priv.PubKey()), msg, other) {
+		t.Fatal("deterministic signature does not verify")
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package schnorr
+
+import (
+	"math/big"
+
+	"github.com/sammyne/secp256k1"
+)
+
+// Verify returns whether or not the passed 64-byte signature is a valid BIP340
+// signature of the message for the x-only public key.
+//
+// The signature is valid when its nonce point R = s*G - e*P, where P is the
+// point with the x coordinate of the public key and an even y coordinate and
+// e is the challenge computed by Challenge, has an even y coordinate and the x
+// coordinate given by the first 32 bytes of the signature.
+func Verify(pubX, msg [32]byte, sig [64]byte) bool {
+	curve := secp256k1.S256()
+	pubKey, err := secp256k1.PublicKeyFromXAndParity(pubX, 0x02)
+	if err != nil {
+		return false
+	}
+	var rX [32]byte
+	copy(rX[:], sig[:32])
+	r := new(big.Int).SetBytes(rX[:])
+	s := new(big.Int).SetBytes(sig[32:])
+	if r.Cmp(curve.P) >= 0 || s.Cmp(curve.N) >= 0 {
+		return false
+	}
+
+	e := Challenge(rX, pubX, msg)
+	rx, ry := curve.VerifyEquation(sig[32:], e.Bytes(), pubKey.X, pubKey.Y)
+	if rx.Sign() == 0 && ry.Sign() == 0 {
+		return false
+	}
+	return ry.Bit(0) == 0 && rx.Cmp(r) == 0
+}
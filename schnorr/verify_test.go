@@ -0,0 +1,65 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package schnorr
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/sammyne/secp256k1"
+)
+
+// TestVerify ensures valid BIP340 signatures verify and that signatures with
+// any part of the statement or signature tampered with do not.
+func TestVerify(t *testing.T) {
+	pubX := hexToBytes32("dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659")
+	msg := hexToBytes32("243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89")
+	var sig [64]byte
+	sigBytes, _ := hex.DecodeString("6896bd60eeae296db48a229ff71dfe071bde413e6d43f917dc8dcf8c78de3341" +
+		"8906d11ac976abccb20b091292bff4ea897efcb639ea871cfa95f6de339e4b0a")
+	copy(sig[:], sigBytes)
+
+	if !Verify(pubX, msg, sig) {
+		t.Fatal("BIP340 vector 1 failed to verify")
+	}
+
+	// modify returns a copy of the signature with the passed change made.
+	modify := func(f func(sig *[64]byte)) [64]byte {
+		s := sig
+		f(&s)
+		return s
+	}
+	otherMsg := msg
+	otherMsg[0] ^= 0x01
+	var notOnCurve [32]byte
+	notOnCurve[31] = 0x05
+	var order [32]byte
+	copy(order[:], secp256k1.S256().N.Bytes())
+
+	tests := []struct {
+		name string
+		pubX [32]byte
+		msg  [32]byte
+		sig  [64]byte
+	}{
+		{"wrong message", pubX, otherMsg, sig},
+		{"public key not on curve", notOnCurve, msg, sig},
+		{"tampered R", pubX, msg, modify(func(s *[64]byte) { s[31] ^= 0x01 })},
+		{"tampered s", pubX, msg, modify(func(s *[64]byte) { s[63] ^= 0x01 })},
+		{"s equal to N", pubX, msg, modify(func(s *[64]byte) {
+			copy(s[32:], order[:])
+		})},
+		{"R not in field", pubX, msg, modify(func(s *[64]byte) {
+			for i := 0; i < 32; i++ {
+				s[i] = 0xff
+			}
+		})},
+	}
+	for _, test := range tests {
+		if Verify(test.pubX, test.msg, test.sig) {
+			t.Errorf("%s: invalid signature verified", test.name)
+		}
+	}
+}
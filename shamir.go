@@ -0,0 +1,149 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// LagrangeCoefficient returns the Lagrange coefficient at zero modulo the group
+// order N for the passed index with respect to the passed set of indices, that
+// is the product of j/(j-index) over all other indices j in the set.
+//
+// In a Shamir secret sharing over the scalar field, the secret is the sum of
+// each share multiplied by the coefficient for its index with respect to the
+// indices of all shares being combined.
+//
+// An error is returned when an index is not positive, when the index is not in
+// the set, or when the set contains duplicates.
+func LagrangeCoefficient(index int, indexSet []int) (*big.Int, error) {
+	N := S256().N
+	seen := make(map[int]struct{}, len(indexSet))
+	for _, j := range indexSet {
+		if j <= 0 {
+			return nil, fmt.Errorf("index %d is not positive", j)
+		}
+		if _, ok := seen[j]; ok {
+			return nil, fmt.Errorf("duplicate index %d", j)
+		}
+		seen[j] = struct{}{}
+	}
+	if _, ok := seen[index]; !ok {
+		return nil, fmt.Errorf("index %d is not in the index set", index)
+	}
+
+	num, den := big.NewInt(1), big.NewInt(1)
+	i := big.NewInt(int64(index))
+	for _, j := range indexSet {
+		if j == index {
+			continue
+		}
+		jBig := big.NewInt(int64(j))
+		num.Mul(num, jBig)
+		num.Mod(num, N)
+		den.Mul(den, jBig.Sub(jBig, i))
+		den.Mod(den, N)
+	}
+
+	// The denominator is nonzero since the indices are distinct and much
+	// smaller than N.
+	den.ModInverse(den, N)
+	return num.Mul(num, den).Mod(num, N), nil
+}
+
+// Share is a share of a private key produced by SplitPrivateKey.  Index is the
+// positive point at which the sharing polynomial was evaluated and Value is
+// the result of the evaluation modulo the group order.
+type Share struct {
+	Index int
+	Value *big.Int
+}
+
+// SplitPrivateKey splits the passed private key into total shares such that
+// any threshold of them reconstruct the key with CombineShares while fewer
+// reveal nothing about it.
+//
+// This is a Shamir secret sharing over the scalar field: the key is the
+// constant term of a random polynomial of degree threshold-1 modulo N, and the
+// shares are its evaluations at the indices 1 through total.  The remaining
+// coefficients are read from crypto/rand.
+func SplitPrivateKey(priv *PrivateKey, threshold, total int) ([]Share, error) {
+	N := S256().N
+	if threshold < 1 || threshold > total {
+		return nil, fmt.Errorf("threshold %d is not in the range [1, %d]",
+			threshold, total)
+	}
+	if priv.D.Sign() <= 0 || priv.D.Cmp(N) >= 0 {
+		return nil, errors.New("private key is not in the range [1, N-1]")
+	}
+
+	coefficients := make([]*big.Int, threshold)
+	coefficients[0] = new(big.Int).Set(priv.D)
+	for i := 1; i < threshold; i++ {
+		c, err := rand.Int(rand.Reader, N)
+		if err != nil {
+			return nil, err
+		}
+		coefficients[i] = c
+	}
+
+	// Evaluate the polynomial at each index with Horner's method.
+	shares := make([]Share, total)
+	for i := range shares {
+		x := big.NewInt(int64(i + 1))
+		y := new(big.Int)
+		for j := threshold - 1; j >= 0; j-- {
+			y.Mul(y, x)
+			y.Add(y, coefficients[j])
+			y.Mod(y, N)
+		}
+		shares[i] = Share{Index: i + 1, Value: y}
+	}
+	return shares, nil
+}
+
+// CombineShares reconstructs a private key from shares produced by
+// SplitPrivateKey via Lagrange interpolation at zero.  At least as many
+// shares as the threshold used to split the key must be passed, otherwise an
+// unrelated key is returned, which can't be detected here.
+//
+// An error is returned when no shares are passed, when the indices are not
+// valid as described by LagrangeCoefficient, or when the result is zero.
+func CombineShares(shares []Share) (*PrivateKey, error) {
+	curve := S256()
+	if len(shares) == 0 {
+		return nil, errors.New("no shares to combine")
+	}
+
+	indexSet := make([]int, len(shares))
+	for i, share := range shares {
+		indexSet[i] = share.Index
+	}
+	d := new(big.Int)
+	for _, share := range shares {
+		if share.Value == nil || share.Value.Sign() < 0 ||
+			share.Value.Cmp(curve.N) >= 0 {
+
+			return nil, fmt.Errorf("share %d is not in the range "+
+				"[0, N-1]", share.Index)
+		}
+		lambda, err := LagrangeCoefficient(share.Index, indexSet)
+		if err != nil {
+			return nil, err
+		}
+		d.Add(d, lambda.Mul(lambda, share.Value))
+	}
+	d.Mod(d, curve.N)
+	if d.Sign() == 0 {
+		return nil, errors.New("combined private key is zero")
+	}
+
+	priv, _ := PrivKeyFromBytes(curve, paddedAppend(PrivKeyBytesLen, nil,
+		d.Bytes()))
+	return priv, nil
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"math/big"
+	"testing"
+)
+
+// TestLagrangeCoefficient ensures the Lagrange coefficients reconstruct the
+// constant term of a polynomial from its evaluations and that invalid index
+// sets are rejected.
+func TestLagrangeCoefficient(t *testing.T) {
+	N := S256().N
+	secret := fromHex("c9afa9d845ba75166b5c215767b1d6934e50c3db36e89b127b8a622b120f6721")
+	coefficients := []*big.Int{
+		secret,
+		fromHex("7b"),
+		fromHex("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140"),
+	}
+
+	// share returns f(x) mod N for the polynomial with the coefficients
+	// above.
+	share := func(x int) *big.Int {
+		result, power := new(big.Int), big.NewInt(1)
+		for _, c := range coefficients {
+			term := new(big.Int).Mul(c, power)
+			result.Add(result, term)
+			power.Mul(power, big.NewInt(int64(x)))
+		}
+		return result.Mod(result, N)
+	}
+
+	indexSets := [][]int{{1, 2, 3}, {2, 4, 5}, {1, 3, 5, 7}, {10, 20, 300}}
+	for _, indexSet := range indexSets {
+		sum := new(big.Int)
+		for _, i := range indexSet {
+			lambda, err := LagrangeCoefficient(i, indexSet)
+			if err != nil {
+				t.Fatalf("%v: unexpected error: %v", indexSet, err)
+			}
+			sum.Add(sum, new(big.Int).Mul(lambda, share(i)))
+		}
+		sum.Mod(sum, N)
+		if sum.Cmp(secret) != 0 {
+			t.Errorf("%v: reconstructed %x, want %x", indexSet, sum,
+				secret)
+		}
+	}
+
+	// Too few shares must not reconstruct the secret.
+	sum := new(big.Int)
+	for _, i := range []int{1, 2} {
+		lambda, err := LagrangeCoefficient(i, []int{1, 2})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		sum.Add(sum, new(big.Int).Mul(lambda, share(i)))
+	}
+	if sum.Mod(sum, N).Cmp(secret) == 0 {
+		t.Error("reconstructed the secret from too few shares")
+	}
+
+	invalid := []struct {
+		name     string
+		index    int
+		indexSet []int
+	}{
+		{"index not in set", 4, []int{1, 2, 3}},
+		{"duplicate index", 1, []int{1, 2, 2}},
+		{"zero index", 1, []int{0, 1, 2}},
+		{"negative index", -1, []int{-1, 1}},
+	}
+	for _, test := range invalid {
+		if _, err := LagrangeCoefficient(test.index, test.indexSet); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
+
+// TestSplitCombinePrivateKey ensures any threshold of the shares of a private
+// key reconstruct it and that fewer shares do not.
+func TestSplitCombinePrivateKey(t *testing.T) {
+	priv, _ := PrivKeyFromBytes(S256(), decodeHex("eaf02ca348c524e6"+
+		"392655ba4d29603cd1a7347d9d65cfe93ce1ebffdca22694"))
+
+	const threshold, total = 3, 5
+	shares, err := SplitPrivateKey(priv, threshold, total)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(shares) != total {
+		t.Fatalf("got %d shares, want %d", len(shares), total)
+	}
+
+	// Every subset of the shares reconstructs the key exactly when it has
+	// at least threshold shares.
+	for mask := 1; mask < 1<<total; mask++ {
+		var subset []Share
+		for i := 0; i < total; i++ {
+			if mask>>uint(i)&1 == 1 {
+				subset = append(subset, shares[i])
+			}
+		}
+
+		combined, err := CombineShares(subset)
+		if err != nil {
+			t.Fatalf("%05b: unexpected error: %v", mask, err)
+		}
+		matches := combined.D.Cmp(priv.D) == 0
+		if want := len(subset) >= threshold; matches != want {
+			t.Fatalf("%05b: reconstructed key matches %v, want %v",
+				mask, matches, want)
+		}
+		if matches && !combined.PubKey().IsEqual(priv.PubKey()) {
+			t.Fatalf("%05b: public key does not match", mask)
+		}
+	}
+
+	for _, params := range [][2]int{{0, 5}, {6, 5}, {-1, 3}} {
+		if _, err := SplitPrivateKey(priv, params[0], params[1]); err == nil {
+			t.Errorf("%v: expected error", params)
+		}
+	}
+	if _, err := CombineShares(nil); err == nil {
+		t.Error("expected error for no shares")
+	}
+	dup := []Share{shares[0], shares[1], shares[1]}
+	if _, err := CombineShares(dup); err == nil {
+		t.Error("expected error for duplicate shares")
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// SerializeSignatureBatch serializes the passed signatures into a compact
+// encoding in which each distinct R value is only stored once.  This saves 32
+// bytes for each signature that shares its R with an earlier one, such as
+// signatures produced by protocols that reuse a nonce point across messages
+// by design.  The format is:
+//
+//	uvarint(number of distinct R values)
+//	R values as 32-byte big endian integers, in order of first use
+//	uvarint(number of signatures)
+//	for each signature: uvarint(index of its R value) || 32-byte S
+//
+// Since the R values are stored in order of first use, the encoding of a
+// batch is unique.  An error is returned when a signature has an R or S value
+// that is not in the range [1, N-1], since ParseSignatureBatch would reject it.
+func SerializeSignatureBatch(sigs []*Signature) ([]byte, error) {
+	N := S256().N
+
+	// Assign each distinct R value an index in order of first use.
+	indices := make([]uint64, len(sigs))
+	rIndex := make(map[[32]byte]uint64)
+	var rValues [][32]byte
+	for i, sig := range sigs {
+		if sig.R.Sign() <= 0 || sig.R.Cmp(N) >= 0 {
+			return nil, fmt.Errorf("R value of signature %d is not "+
+				"in the range [1, N-1]", i)
+		}
+		if sig.S.Sign() <= 0 || sig.S.Cmp(N) >= 0 {
+			return nil, fmt.Errorf("S value of signature %d is not "+
+				"in the range [1, N-1]", i)
+		}
+
+		var r [32]byte
+		bigIntToBytes32(sig.R, &r)
+		idx, ok := rIndex[r]
+		if !ok {
+			idx = uint64(len(rValues))
+			rIndex[r] = idx
+			rValues = append(rValues, r)
+		}
+		indices[i] = idx
+	}
+
+	var varint [binary.MaxVarintLen64]byte
+	size := binary.PutUvarint(varint[:], uint64(len(rValues)))
+	b := make([]byte, 0, size+32*len(rValues)+binary.MaxVarintLen64+
+		len(sigs)*(binary.MaxVarintLen64+32))
+	b = append(b, varint[:size]...)
+	for i := range rValues {
+		b = append(b, rValues[i][:]...)
+	}
+	size = binary.PutUvarint(varint[:], uint64(len(sigs)))
+	b = append(b, varint[:size]...)
+	for i, sig := range sigs {
+		size = binary.PutUvarint(varint[:], indices[i])
+		b = append(b, varint[:size]...)
+		var s [32]byte
+		b = append(b, bigIntToBytes32(sig.S, &s)[:]...)
+	}
+	return b, nil
+}
+
+// ParseSignatureBatch parses a batch of signatures serialized with
+// SerializeSignatureBatch.  An error is returned when the encoding is
+// truncated, has trailing bytes, is not the unique encoding of the batch, such
+// as when a uvarint is not minimally encoded, or contains R or S values that
+// are not in the range [1, N-1].
+func ParseSignatureBatch(b []byte) ([]*Signature, error) {
+	N := S256().N
+
+	// readUvarint reads a uvarint from the front of b and advances past it.
+	// binary.Uvarint accepts values padded with redundant 0x80 bytes, so
+	// the length is compared against that of the minimal encoding to keep
+	// the encoding of a batch unique.
+	readUvarint := func(what string) (uint64, error) {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return 0, fmt.Errorf("malformed signature batch: invalid %s",
+				what)
+		}
+		var minimal [binary.MaxVarintLen64]byte
+		if n != binary.PutUvarint(minimal[:], v) {
+			return 0, fmt.Errorf("malformed signature batch: "+
+				"non-minimal %s", what)
+		}
+		b = b[n:]
+		return v, nil
+	}
+
+	numR, err := readUvarint("R count")
+	if err != nil {
+		return nil, err
+	}
+	if numR > uint64(len(b)/32) {
+		return nil, errors.New("malformed signature batch: too short " +
+			"for R values")
+	}
+	rValues := make([]*big.Int, numR)
+	seen := make(map[[32]byte]struct{}, numR)
+	for i := range rValues {
+		var r [32]byte
+		copy(r[:], b[:32])
+		b = b[32:]
+		if _, ok := seen[r]; ok {
+			return nil, fmt.Errorf("malformed signature batch: R value "+
+				"%d is a duplicate", i)
+		}
+		seen[r] = struct{}{}
+		rValues[i] = new(big.Int).SetBytes(r[:])
+		if rValues[i].Sign() == 0 || rValues[i].Cmp(N) >= 0 {
+			return nil, fmt.Errorf("malformed signature batch: R value "+
+				"%d is not in the range [1, N-1]", i)
+		}
+	}
+
+	numSigs, err := readUvarint("signature count")
+	if err != nil {
+		return nil, err
+	}
+	// Each signature takes at least 33 bytes.
+	if numSigs > uint64(len(b)/33) {
+		return nil, errors.New("malformed signature batch: too short " +
+			"for signatures")
+	}
+
+	sigs := make([]*Signature, numSigs)
+	var nextNew uint64
+	for i := range sigs {
+		idx, err := readUvarint("R index")
+		if err != nil {
+			return nil, err
+		}
+		// R values must be used in the order they are stored.
+		switch {
+		case idx == nextNew && idx < numR:
+			nextNew++
+		case idx >= nextNew:
+			return nil, fmt.Errorf("malformed signature batch: "+
+				"signature %d references R value %d out of order",
+				i, idx)
+		}
+		if len(b) < 32 {
+			return nil, errors.New("malformed signature batch: too " +
+				"short for S value")
+		}
+		s := new(big.Int).SetBytes(b[:32])
+		b = b[32:]
+		if s.Sign() == 0 || s.Cmp(N) >= 0 {
+			return nil, fmt.Errorf("malformed signature batch: S value "+
+				"of signature %d is not in the range [1, N-1]", i)
+		}
+		sigs[i] = &Signature{R: new(big.Int).Set(rValues[idx]), S: s}
+	}
+
+	if nextNew != numR {
+		return nil, errors.New("malformed signature batch: unused R " +
+			"values")
+	}
+	if len(b) != 0 {
+		return nil, errors.New("malformed signature batch: trailing bytes")
+	}
+	return sigs, nil
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+)
+
+// TestSignatureBatchRoundTrip ensures batches of signatures with and without
+// shared R values round trip through SerializeSignatureBatch and
+// ParseSignatureBatch and that shared R values are only stored once.
+func TestSignatureBatchRoundTrip(t *testing.T) {
+	privKey, _ := PrivKeyFromBytes(S256(), decodeHex("0123456789abcdef0123"+
+		"456789abcdef0123456789abcdef0123456789abcdef"))
+	var distinct []*Signature
+	for i := 0; i < 5; i++ {
+		hash := sha256.Sum256([]byte{byte(i)})
+		sig, err := privKey.Sign(hash[:])
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		distinct = append(distinct, sig)
+	}
+
+	// shared returns a signature with the R of the passed one and the
+	// passed S.
+	shared := func(sig *Signature, s int64) *Signature {
+		return &Signature{R: new(big.Int).Set(sig.R), S: big.NewInt(s)}
+	}
+
+	tests := []struct {
+		name    string
+		sigs    []*Signature
+		numR    int
+		wantLen int
+	}{
+		{"empty", nil, 0, 2},
+		{"single", distinct[:1], 1, 2 + 32 + 33},
+		{"distinct R", distinct, 5, 2 + 5*32 + 5*33},
+		{"all shared R", []*Signature{distinct[0], shared(distinct[0], 1),
+			shared(distinct[0], 2)}, 1, 2 + 32 + 3*33},
+		{"interleaved shared R", []*Signature{distinct[0], distinct[1],
+			shared(distinct[0], 3), distinct[2], shared(distinct[1], 4),
+			shared(distinct[2], 5)}, 3, 2 + 3*32 + 6*33},
+	}
+
+	for _, test := range tests {
+		b, err := SerializeSignatureBatch(test.sigs)
+		if err != nil {
+			t.Errorf("%s: unexpected serialize error: %v", test.name,
+				err)
+			continue
+		}
+		if len(b) != test.wantLen {
+			t.Errorf("%s: unexpected length -- got %d, want %d",
+				test.name, len(b), test.wantLen)
+		}
+		if int(b[0]) != test.numR {
+			t.Errorf("%s: unexpected number of R values -- got %d, "+
+				"want %d", test.name, b[0], test.numR)
+		}
+
+		sigs, err := ParseSignatureBatch(b)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if len(sigs) != len(test.sigs) {
+			t.Errorf("%s: got %d signatures, want %d", test.name,
+				len(sigs), len(test.sigs))
+			continue
+		}
+		for i := range sigs {
+			if !sigs[i].IsEqual(test.sigs[i]) {
+				t.Errorf("%s: mismatched signature %d -- got %v, "+
+					"want %v", test.name, i, sigs[i], test.sigs[i])
+			}
+		}
+
+		// The encoding is unique, so reserializing must reproduce it.
+		reserialized, err := SerializeSignatureBatch(sigs)
+		if err != nil || !bytes.Equal(reserialized, b) {
+			t.Errorf("%s: reserialized batch differs", test.name)
+		}
+	}
+}
+
+// TestSerializeSignatureBatchErrors ensures signatures with R or S values that
+// are not in the range [1, N-1] are rejected instead of being serialized.
+func TestSerializeSignatureBatchErrors(t *testing.T) {
+	N := S256().N
+	one := big.NewInt(1)
+	tests := []struct {
+		name string
+		r, s *big.Int
+	}{
+		{"zero R", new(big.Int), one},
+		{"negative R", big.NewInt(-1), one},
+		{"R equal to N", N, one},
+		{"R of 2^256", new(big.Int).Lsh(one, 256), one},
+		{"zero S", one, new(big.Int)},
+		{"negative S", one, big.NewInt(-1)},
+		{"S equal to N", one, N},
+		{"S of 2^256", one, new(big.Int).Lsh(one, 256)},
+	}
+	for _, test := range tests {
+		sigs := []*Signature{{R: one, S: one}, {R: test.r, S: test.s}}
+		if _, err := SerializeSignatureBatch(sigs); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
+
+// TestParseSignatureBatchErrors ensures malformed and non-canonical batch
+// encodings are rejected.
+func TestParseSignatureBatchErrors(t *testing.T) {
+	r1 := bytes.Repeat([]byte{0x11}, 32)
+	r2 := bytes.Repeat([]byte{0x22}, 32)
+	s := bytes.Repeat([]byte{0x33}, 32)
+	zero := make([]byte, 32)
+	order := S256().N.Bytes()
+
+	// batch concatenates the passed parts into an encoded batch.
+	batch := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+	valid := batch([]byte{2}, r1, r2, []byte{3}, []byte{0}, s, []byte{1}, s,
+		[]byte{0}, s)
+	if _, err := ParseSignatureBatch(valid); err != nil {
+		t.Fatalf("valid batch rejected: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{"empty input", nil},
+		{"truncated R values", valid[:40]},
+		{"missing signature count", valid[:65]},
+		{"truncated signature", valid[:len(valid)-1]},
+		{"trailing bytes", append(append([]byte(nil), valid...), 0)},
+		{"duplicate R values", batch([]byte{2}, r1, r1, []byte{2},
+			[]byte{0}, s, []byte{1}, s)},
+		{"R index out of order", batch([]byte{2}, r1, r2, []byte{2},
+			[]byte{1}, s, []byte{0}, s)},
+		{"R index out of range", batch([]byte{1}, r1, []byte{2},
+			[]byte{0}, s, []byte{1}, s)},
+		{"unused R value", batch([]byte{2}, r1, r2, []byte{1}, []byte{0},
+			s)},
+		{"zero R", batch([]byte{1}, zero, []byte{1}, []byte{0}, s)},
+		{"R equal to N", batch([]byte{1}, order, []byte{1}, []byte{0}, s)},
+		{"zero S", batch([]byte{1}, r1, []byte{1}, []byte{0}, zero)},
+		{"S equal to N", batch([]byte{1}, r1, []byte{1}, []byte{0}, order)},
+		{"padded R count", batch([]byte{0x81, 0x00}, r1, []byte{1},
+			[]byte{0}, s)},
+		{"padded zero R count", batch([]byte{0x80, 0x00}, []byte{0})},
+		{"padded signature count", batch([]byte{1}, r1, []byte{0x81,
+			0x00}, []byte{0}, s)},
+		{"padded R index", batch([]byte{1}, r1, []byte{1}, []byte{0x80,
+			0x00}, s)},
+		{"doubly padded R index", batch([]byte{2}, r1, r2, []byte{2},
+			[]byte{0}, s, []byte{0x81, 0x80, 0x00}, s)},
+		{"huge R count", batch([]byte{0xff, 0xff, 0xff, 0xff, 0x0f}, r1)},
+		{"huge signature count", batch([]byte{1}, r1, []byte{0xff, 0xff,
+			0xff, 0xff, 0x0f}, []byte{0}, s)},
+	}
+	for _, test := range tests {
+		if _, err := ParseSignatureBatch(test.b); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
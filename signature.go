@@ -6,7 +6,6 @@ package secp256k1
 
 import (
 	"bytes"
-	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/hmac"
 	"crypto/sha256"
@@ -71,10 +70,117 @@ func (sig *Signature) Serialize() []byte {
 	return b
 }
 
-// Verify calls ecdsa.Verify to verify the signature of hash using the public
-// key.  It returns true if the signature is valid, false otherwise.
+// Verify verifies the signature of hash using the public key.  It returns true
+// if the signature is valid, false otherwise.
 func (sig *Signature) Verify(hash []byte, pubKey *PublicKey) bool {
-	return ecdsa.Verify(pubKey.ToECDSA(), hash, sig.R, sig.S)
+	return verifyProjective(pubKey, hashToInt(hash, S256()), sig.R, sig.S,
+		nil)
+}
+
+// VerifyFullHash verifies the signature of hash using the public key like
+// Verify, except the whole hash is interpreted as a big endian integer and
+// reduced modulo N instead of being truncated to the bit length of N first as
+// specified by [SECG].  This is needed to interoperate with non-standard
+// implementations that skip the truncation.
+//
+// Since N is 256 bits long, both modes agree for hashes of up to 32 bytes, so
+// they only differ for longer hashes such as the ones produced by SHA-512.
+func VerifyFullHash(pubKey *PublicKey, hash []byte, sig *Signature) bool {
+	e := new(big.Int).SetBytes(hash)
+	return verifyProjective(pubKey, e.Mod(e, S256().N), sig.R, sig.S, nil)
+}
+
+// verifyProjective verifies the ECDSA signature (r, s) of the message, already
+// converted to the integer e, using the public key.  It produces the same
+// results as ecdsa.Verify, however, the point R = u1*G + u2*Q is left in
+// Jacobian coordinates and compared against r without converting it to affine,
+// which saves a field inversion per verification.
+//
+// When mulQ is not nil, it is used to compute u2*Q instead of a generic scalar
+// multiplication, which allows a precomputed table for Q to be used.
+func verifyProjective(pubKey *PublicKey, e *big.Int, r, s *big.Int,
+	mulQ func(k []byte, qx, qy, qz *fieldVal)) bool {
+
+	curve := S256()
+	N := curve.N
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(N) >= 0 || s.Cmp(N) >= 0 {
+		return false
+	}
+	if pubKey.X.Sign() < 0 || pubKey.Y.Sign() < 0 ||
+		pubKey.X.Cmp(curve.P) >= 0 || pubKey.Y.Cmp(curve.P) >= 0 {
+		return false
+	}
+
+	// u1 = e/s mod N and u2 = r/s mod N.
+	w := new(big.Int).ModInverse(s, N)
+	u1 := new(big.Int).Mul(e, w)
+	u1.Mod(u1, N)
+	u2 := new(big.Int).Mul(r, w)
+	u2.Mod(u2, N)
+
+	// R = u1*G + u2*Q.
+	var u1Gx, u1Gy, u1Gz, u2Qx, u2Qy, u2Qz, x, y, z fieldVal
+	curve.scalarBaseMultJacobian(u1.Bytes(), &u1Gx, &u1Gy, &u1Gz)
+	if mulQ != nil {
+		mulQ(u2.Bytes(), &u2Qx, &u2Qy, &u2Qz)
+	} else {
+		qx, qy := curve.bigAffineToField(pubKey.X, pubKey.Y)
+		qz := new(fieldVal).SetInt(1)
+		curve.scalarMultJacobian(u2.Bytes(), qx, qy, qz, &u2Qx, &u2Qy,
+			&u2Qz)
+	}
+	curve.addJacobian(&u1Gx, &u1Gy, &u1Gz, &u2Qx, &u2Qy, &u2Qz, &x, &y, &z)
+	if z.Normalize().IsZero() {
+		return false
+	}
+
+	// The signature is valid when the affine x coordinate of R reduced
+	// modulo N is r.  Since x = X/Z², that is equivalent to X = r*Z² when
+	// x < N, so the comparison is done without inverting Z.  Also, since
+	// N < P, the affine x coordinate may instead be r+N when that is less
+	// than P, so check that case as well.
+	var zSquared, rz fieldVal
+	zSquared.SquareVal(&z)
+	x.Normalize()
+	if rz.SetByteSlice(r.Bytes()).Mul(&zSquared).Normalize().Equals(&x) {
+		return true
+	}
+	rPlusN := new(big.Int).Add(r, N)
+	if rPlusN.Cmp(curve.P) >= 0 {
+		return false
+	}
+	return rz.SetByteSlice(rPlusN.Bytes()).Mul(&zSquared).Normalize().Equals(&x)
+}
+
+// SignTagged signs the tagged hash of msg under the passed tag with the
+// private key.  See TaggedHash for details on how the hash is computed.
+//
+// Since the tag is committed to by the signed hash, a signature produced for
+// one tag will not verify under any other tag.  This prevents a signature that
+// was produced for one protocol from being replayed in another one.
+func SignTagged(priv *PrivateKey, tag string, msg []byte) (*Signature, error) {
+	return priv.Sign(TaggedHash(tag, msg))
+}
+
+// SignScalar signs the passed message, which must already be expressed as an
+// integer in the range [0, N-1] where N is the order of the secp256k1 group.
+// This is useful for protocols that define the signed value directly in the
+// scalar field, since it skips the conversion of a hash to an integer.
+//
+// Signing a hash with Sign is equivalent to signing the integer the hash
+// converts to, reduced modulo N, with SignScalar.
+func SignScalar(priv *PrivateKey, e *big.Int) (*Signature, error) {
+	if e.Sign() < 0 || e.Cmp(S256().N) >= 0 {
+		return nil, errors.New("message scalar is not in the range [0, N-1]")
+	}
+	return signRFC6979(priv, int2octets(e, PrivKeyBytesLen))
+}
+
+// VerifyTagged verifies the signature of the tagged hash of msg under the
+// passed tag using the public key.  It returns true if the signature is valid,
+// false otherwise.
+func VerifyTagged(pubKey *PublicKey, tag string, msg []byte, sig *Signature) bool {
+	return sig.Verify(TaggedHash(tag, msg), pubKey)
 }
 
 // IsEqual compares this Signature instance to the one passed, returning true
@@ -85,6 +191,15 @@ func (sig *Signature) IsEqual(otherSig *Signature) bool {
 		sig.S.Cmp(otherSig.S) == 0
 }
 
+// Equal returns whether or not the passed signature has the same R and S
+// values as this one.  The values are compared rather than any serialized form
+// of them, so signatures parsed from different encodings of the same values,
+// such as a BER encoding with excess padding and the strict DER encoding, are
+// equal.  It is equivalent to IsEqual.
+func (sig *Signature) Equal(other *Signature) bool {
+	return sig.IsEqual(other)
+}
+
 // MinSigLen is the minimum length of a DER encoded signature and is when both R
 // and S are 1 byte each.
 // 0x30 + <1-byte> + 0x02 + 0x01 + <byte> + 0x2 + 0x01 + <byte>
@@ -219,6 +334,138 @@ func ParseDERSignature(sigStr []byte, curve elliptic.Curve) (*Signature, error)
 	return parseSig(sigStr, curve, true)
 }
 
+// ParseDERSignatureLax parses a signature that is only loosely DER encoded
+// into a Signature type.  It mirrors the lax parsing rules Bitcoin Core uses
+// to validate historical signatures which were accepted by old versions of
+// OpenSSL, and it should only be used for that purpose.  New signatures must
+// be parsed with ParseDERSignature instead.
+//
+// In particular, the following deviations from DER are tolerated:
+//   - Long form length bytes, including non-minimal ones
+//   - A sequence length that does not match the actual length
+//   - Excess leading zero bytes in R and S
+//   - R and S values with the high bit set (negative in strict DER)
+//   - Trailing bytes after the S value
+//
+// An error is returned when the structure can't be parsed at all, or when R
+// or S is not in the range [1, N-1].
+func ParseDERSignatureLax(sigStr []byte) (*Signature, error) {
+	inputLen := len(sigStr)
+	pos := 0
+
+	// Sequence tag byte.
+	if pos == inputLen || sigStr[pos] != 0x30 {
+		return nil, errors.New("malformed signature: no header magic")
+	}
+	pos++
+
+	// Sequence length bytes.  The actual length is ignored.
+	if pos == inputLen {
+		return nil, errors.New("malformed signature: no sequence length")
+	}
+	lenByte := int(sigStr[pos])
+	pos++
+	if lenByte&0x80 != 0 {
+		lenByte -= 0x80
+		if lenByte > inputLen-pos {
+			return nil, errors.New("malformed signature: bad " +
+				"sequence length")
+		}
+		pos += lenByte
+	}
+
+	// Integer tag byte and length bytes for R followed by R itself.
+	rBytes, pos, err := parseLaxDERInt(sigStr, pos, "R")
+	if err != nil {
+		return nil, err
+	}
+
+	// Integer tag byte and length bytes for S followed by S itself.  Any
+	// trailing bytes after S are ignored.
+	sBytes, _, err := parseLaxDERInt(sigStr, pos, "S")
+	if err != nil {
+		return nil, err
+	}
+
+	// Ignore leading zeros and ensure the remaining values fit in 256 bits.
+	rBytes = bytes.TrimLeft(rBytes, "\x00")
+	if len(rBytes) > 32 {
+		return nil, errors.New("signature R overflows 256 bits")
+	}
+	sBytes = bytes.TrimLeft(sBytes, "\x00")
+	if len(sBytes) > 32 {
+		return nil, errors.New("signature S overflows 256 bits")
+	}
+
+	signature := &Signature{
+		R: new(big.Int).SetBytes(rBytes),
+		S: new(big.Int).SetBytes(sBytes),
+	}
+	if signature.R.Sign() != 1 {
+		return nil, errors.New("signature R isn't 1 or more")
+	}
+	if signature.S.Sign() != 1 {
+		return nil, errors.New("signature S isn't 1 or more")
+	}
+	if signature.R.Cmp(S256().N) >= 0 {
+		return nil, errors.New("signature R is >= curve.N")
+	}
+	if signature.S.Cmp(S256().N) >= 0 {
+		return nil, errors.New("signature S is >= curve.N")
+	}
+
+	return signature, nil
+}
+
+// parseLaxDERInt parses a loosely DER encoded integer from sigStr beginning at
+// the passed position.  It returns the raw bytes of the integer along with the
+// position immediately after it.  The name is only used in error messages.
+func parseLaxDERInt(sigStr []byte, pos int, name string) ([]byte, int, error) {
+	inputLen := len(sigStr)
+
+	// Integer tag byte.
+	if pos == inputLen || sigStr[pos] != 0x02 {
+		return nil, 0, fmt.Errorf("malformed signature: no %s int "+
+			"marker", name)
+	}
+	pos++
+
+	// Integer length bytes.
+	if pos == inputLen {
+		return nil, 0, fmt.Errorf("malformed signature: no %s length",
+			name)
+	}
+	intLen := int(sigStr[pos])
+	pos++
+	if intLen&0x80 != 0 {
+		lenBytes := intLen - 0x80
+		if lenBytes > inputLen-pos {
+			return nil, 0, fmt.Errorf("malformed signature: bogus "+
+				"%s length", name)
+		}
+		for lenBytes > 0 && sigStr[pos] == 0 {
+			pos++
+			lenBytes--
+		}
+		if lenBytes >= 4 {
+			return nil, 0, fmt.Errorf("malformed signature: bogus "+
+				"%s length", name)
+		}
+		intLen = 0
+		for lenBytes > 0 {
+			intLen = intLen<<8 + int(sigStr[pos])
+			pos++
+			lenBytes--
+		}
+	}
+	if intLen > inputLen-pos {
+		return nil, 0, fmt.Errorf("malformed signature: bogus %s "+
+			"length", name)
+	}
+
+	return sigStr[pos : pos+intLen], pos + intLen, nil
+}
+
 // canonicalizeInt returns the bytes for the passed big integer adjusted as
 // necessary to ensure that a big-endian encoded integer can't possibly be
 // misinterpreted as a negative number.  This can happen when the most
@@ -284,12 +531,31 @@ func hashToInt(hash []byte, c elliptic.Curve) *big.Int {
 // format and thus we match bitcoind's behaviour here.
 func recoverKeyFromSignature(curve *KoblitzCurve, sig *Signature, msg []byte,
 	iter int, doChecks bool) (*PublicKey, error) {
+	// The recovery ID selects one of the two candidate x coordinates and
+	// one of the two y coordinates for each of them.
+	if iter < 0 || iter > 3 {
+		return nil, fmt.Errorf("invalid recovery ID %d", iter)
+	}
+
+	// R is inverted below, so it must be a nonzero scalar.
+	if sig.R.Sign() <= 0 {
+		return nil, errors.New("signature R must be positive")
+	}
+	if sig.R.Cmp(curve.Params().N) >= 0 {
+		return nil, errors.New("signature R is not less than the group " +
+			"order N")
+	}
+
 	// 1.1 x = (n * i) + r
+	//
+	// Since R < N < P, only the second candidate x coordinate, which is
+	// selected by recovery IDs 2 and 3, can exceed the field.
 	Rx := new(big.Int).Mul(curve.Params().N,
 		new(big.Int).SetInt64(int64(iter/2)))
 	Rx.Add(Rx, sig.R)
 	if Rx.Cmp(curve.Params().P) != -1 {
-		return nil, errors.New("calculated Rx is larger than curve P")
+		return nil, fmt.Errorf("signature R + N is not less than curve P "+
+			"as required by recovery ID %d", iter)
 	}
 
 	// convert 02<Rx> to point R. (step 1.2 and 1.3). If we are on an odd
@@ -405,9 +671,9 @@ func RecoverCompact(curve *KoblitzCurve, signature,
 	iteration := int((signature[0] - 27) & ^byte(4))
 
 	// format is <header byte><bitlen R><bitlen S>
-	sig := &Signature{
-		R: new(big.Int).SetBytes(signature[1 : bitlen+1]),
-		S: new(big.Int).SetBytes(signature[bitlen+1:]),
+	sig, err := ParseCompact64(signature[1:])
+	if err != nil {
+		return nil, false, err
 	}
 	// The iteration used here was encoded
 	key, err := recoverKeyFromSignature(curve, sig, hash, iteration, false)
@@ -418,19 +684,162 @@ func RecoverCompact(curve *KoblitzCurve, signature,
 	return key, ((signature[0] - 27) & 4) == 4, nil
 }
 
+// RecoverCompactBoth is like RecoverCompact, except it also returns the
+// alternate public key that is recovered when the parity bit of the header
+// byte is flipped, which corresponds to using the nonce point R with the
+// other Y coordinate.  The signature is valid for both keys, so this helps
+// to diagnose mismatches between a signature and the key that was expected
+// to produce it, such as those caused by a wrong header byte.
+//
+// The alternate key is nil when there is no valid alternate key.  An error is
+// only returned when the key indicated by the header can't be recovered.
+func RecoverCompactBoth(curve *KoblitzCurve, signature,
+	hash []byte) (key, alt *PublicKey, compressed bool, err error) {
+
+	key, compressed, err = RecoverCompact(curve, signature, hash)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	flipped := make([]byte, len(signature))
+	copy(flipped, signature)
+	flipped[0] = 27 + ((signature[0] - 27) ^ 0x01)
+	alt, _, err = RecoverCompact(curve, flipped, hash)
+	if err != nil {
+		return key, nil, compressed, nil
+	}
+	return key, alt, compressed, nil
+}
+
+// CompactSigLen is the length of a signature serialized as the 32-byte big
+// endian R followed by the 32-byte big endian S.
+const CompactSigLen = 64
+
+// ParseCompact64 parses a signature serialized as the 32-byte big endian R
+// followed by the 32-byte big endian S.  Both R and S must be in the range
+// [1, N-1].  Values that are not are rejected rather than reduced modulo N
+// since reducing them would allow multiple encodings of the same signature.
+func ParseCompact64(sigStr []byte) (*Signature, error) {
+	if len(sigStr) != CompactSigLen {
+		return nil, fmt.Errorf("malformed signature: got %d bytes, want %d",
+			len(sigStr), CompactSigLen)
+	}
+
+	N := S256().N
+	r := new(big.Int).SetBytes(sigStr[:32])
+	if r.Sign() == 0 || r.Cmp(N) >= 0 {
+		return nil, errors.New("signature R is not in the range [1, N-1]")
+	}
+	s := new(big.Int).SetBytes(sigStr[32:])
+	if s.Sign() == 0 || s.Cmp(N) >= 0 {
+		return nil, errors.New("signature S is not in the range [1, N-1]")
+	}
+	return &Signature{R: r, S: s}, nil
+}
+
+// Canonicalize parses the passed signature, which may be DER encoded, loosely
+// DER encoded as accepted by ParseDERSignatureLax, or in the 64-byte compact
+// format accepted by ParseCompact64, and returns its canonical encoding: strict
+// DER with S normalized to the lower half of the order.
+//
+// Since (R, S) and (R, N-S) are both valid signatures for the same message and
+// key, and the same values may be encoded in multiple ways, this yields the
+// same bytes for all equivalent signatures, which is useful for deduplicating
+// and storing them.
+//
+// A DER signature can also be 64 bytes long, such as one with a 32-byte R and
+// a 26-byte S.  So, a 64-byte input that starts with the DER sequence tag and
+// a length byte covering the rest of the input is parsed as DER first, and
+// only treated as a compact signature when that fails.  Callers that know the
+// format should use the parser for it directly, since a compact signature
+// that happens to also be a valid DER encoding is parsed as DER.
+func Canonicalize(sigBytes []byte) ([]byte, error) {
+	var sig *Signature
+	var err error
+	switch {
+	case len(sigBytes) != CompactSigLen:
+		sig, err = ParseDERSignatureLax(sigBytes)
+
+	case sigBytes[0] == 0x30 && int(sigBytes[1]) == len(sigBytes)-2:
+		sig, err = ParseDERSignatureLax(sigBytes)
+		if err != nil {
+			sig, err = ParseCompact64(sigBytes)
+		}
+
+	default:
+		sig, err = ParseCompact64(sigBytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Serialize produces strict DER and normalizes S.
+	return sig.Serialize(), nil
+}
+
+// VerifyRaw verifies the signature of hash, serialized as the 32-byte big
+// endian R followed by the 32-byte big endian S, using the public key.  It
+// returns true if the signature is valid, false otherwise.  See ParseCompact64
+// for the requirements of the serialized signature.
+func VerifyRaw(pubKey *PublicKey, hash, sig []byte) bool {
+	signature, err := ParseCompact64(sig)
+	if err != nil {
+		return false
+	}
+	return signature.Verify(hash, pubKey)
+}
+
+// VerifyWithSerializedKey parses the passed public key, which may be in any of
+// the compressed, uncompressed, or hybrid formats, and verifies the signature
+// of hash using it.  An error is only returned when the public key fails to
+// parse, which allows callers to distinguish a malformed key from a signature
+// that is simply not valid for the key.
+func VerifyWithSerializedKey(pubBytes []byte, hash []byte, sig *Signature) (bool, error) {
+	pubKey, err := ParsePubKey(pubBytes, S256())
+	if err != nil {
+		return false, err
+	}
+	return sig.Verify(hash, pubKey), nil
+}
+
 // signRFC6979 generates a deterministic ECDSA signature according to RFC 6979 and BIP 62.
 func signRFC6979(privateKey *PrivateKey, hash []byte) (*Signature, error) {
+	sig, _, _, err := signRFC6979WithR(privateKey, hash)
+	return sig, err
+}
+
+// signRFC6979Recoverable generates a deterministic ECDSA signature like
+// signRFC6979 and additionally returns the recovery id of the signature.  Bit
+// 0 of the recovery id is the parity of the y coordinate of the nonce point R
+// and bit 1 is set when the x coordinate of R is at least N.
+func signRFC6979Recoverable(privateKey *PrivateKey, hash []byte) (*Signature, int, error) {
+	sig, rx, ry, err := signRFC6979WithR(privateKey, hash)
+	if err != nil {
+		return nil, 0, err
+	}
+	recid := int(ry.Bit(0))
+	if rx.Cmp(S256().N) >= 0 {
+		recid |= 2
+	}
+	return sig, recid, nil
+}
+
+// signRFC6979WithR generates a deterministic ECDSA signature like signRFC6979
+// and additionally returns the affine coordinates of the nonce point R the
+// signature was made with.  R accounts for the negation of S performed to
+// produce a canonical signature, since that corresponds to signing with -R.
+func signRFC6979WithR(privateKey *PrivateKey, hash []byte) (*Signature, *big.Int, *big.Int, error) {
 
 	privkey := privateKey.ToECDSA()
 	N := S256().N
 	halfOrder := S256().halfOrder
 	k := nonceRFC6979(privkey.D, hash)
 	inv := new(big.Int).ModInverse(k, N)
-	r, _ := privkey.Curve.ScalarBaseMult(k.Bytes())
-	r.Mod(r, N)
+	rx, ry := privkey.Curve.ScalarBaseMult(k.Bytes())
+	r := new(big.Int).Mod(rx, N)
 
 	if r.Sign() == 0 {
-		return nil, errors.New("calculated R is zero")
+		return nil, nil, nil, errors.New("calculated R is zero")
 	}
 
 	e := hashToInt(hash, privkey.Curve)
@@ -441,11 +850,49 @@ func signRFC6979(privateKey *PrivateKey, hash []byte) (*Signature, error) {
 
 	if s.Cmp(halfOrder) == 1 {
 		s.Sub(N, s)
+		ry.Sub(S256().P, ry)
 	}
 	if s.Sign() == 0 {
-		return nil, errors.New("calculated S is zero")
+		return nil, nil, nil, errors.New("calculated S is zero")
 	}
-	return &Signature{R: r, S: s}, nil
+	return &Signature{R: r, S: s}, rx, ry, nil
+}
+
+// SignWithR signs hash with the private key like PrivateKey.Sign and also
+// returns the nonce point R the signature was made with, which is useful for
+// interactive and threshold protocols where signers commit to R and reveal it
+// later.  Since the nonce is derived deterministically according to RFC 6979,
+// R is determined by the key and the hash.
+//
+// The x coordinate of R reduced modulo N is the R value of the signature.
+// When the S value of the signature is negated to make it canonical, the nonce
+// point is negated as well, so the returned point is always the one that
+// satisfies the verification equation.
+func SignWithR(priv *PrivateKey, hash []byte) (sig *Signature, R *PublicKey, err error) {
+	sig, rx, ry, err := signRFC6979WithR(priv, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sig, &PublicKey{Curve: S256(), X: rx, Y: ry}, nil
+}
+
+// SignAllFormats signs hash with the private key once and returns the
+// signature in all the commonly used formats: its DER encoding, its 64-byte
+// compact encoding consisting of the 32-byte big endian R followed by the
+// 32-byte big endian S, as parsed by ParseCompact64, and its recovery id.
+//
+// The recovery id is in the range [0, 3] and is derived from the nonce point
+// while signing, so unlike SignCompact, no key recovery attempts are needed to
+// find it.  A header byte for the format produced by SignCompact can be built
+// as 27 + recid, plus 4 for a compressed public key.
+func SignAllFormats(priv *PrivateKey, hash []byte) (der []byte, compact []byte, recid int, err error) {
+	sig, recid, err := signRFC6979Recoverable(priv, hash)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	compact = paddedAppend(PrivKeyBytesLen, nil, sig.R.Bytes())
+	compact = paddedAppend(PrivKeyBytesLen, compact, sig.S.Bytes())
+	return sig.Serialize(), compact, recid, nil
 }
 
 // nonceRFC6979 generates an ECDSA nonce (`k`) deterministically according to RFC 6979.
@@ -458,46 +905,19 @@ func nonceRFC6979(privkey *big.Int, hash []byte) *big.Int {
 	alg := sha256.New
 
 	qlen := q.BitLen()
-	holen := alg().Size()
 	rolen := (qlen + 7) >> 3
 	bx := append(int2octets(x, rolen), bits2octets(hash, curve, rolen)...)
 
-	// Step B
-	v := bytes.Repeat(oneInitializer, holen)
+	// Steps B through G instantiate an HMAC-DRBG seeded with bx.
+	drbg := NewHMACDRBG(alg, bx)
 
-	// Step C (Go zeroes the all allocated memory)
-	k := make([]byte, holen)
-
-	// Step D
-	k = mac(alg, k, append(append(v, 0x00), bx...))
-
-	// Step E
-	v = mac(alg, k, v)
-
-	// Step F
-	k = mac(alg, k, append(append(v, 0x01), bx...))
-
-	// Step G
-	v = mac(alg, k, v)
-
-	// Step H
+	// Step H.  Each candidate is the next output of the generator, which
+	// also performs the state update required before retrying.
 	for {
-		// Step H1
-		var t []byte
-
-		// Step H2
-		for len(t)*8 < qlen {
-			v = mac(alg, k, v)
-			t = append(t, v...)
-		}
-
-		// Step H3
-		secret := hashToInt(t, curve)
+		secret := hashToInt(drbg.Generate(rolen), curve)
 		if secret.Cmp(one) >= 0 && secret.Cmp(q) < 0 {
 			return secret
 		}
-		k = mac(alg, k, append(v, 0x00))
-		v = mac(alg, k, v)
 	}
 }
 
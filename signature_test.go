@@ -6,12 +6,15 @@ package secp256k1
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"fmt"
 	"math/big"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -530,6 +533,57 @@ func TestSignCompact(t *testing.T) {
 	}
 }
 
+// TestRecoverCompactBoth ensures both the recovered key and the alternate key
+// recovered with the flipped parity bit are valid for the signature and that
+// the recovered key is the signing key.
+func TestRecoverCompactBoth(t *testing.T) {
+	for i := 0; i < 32; i++ {
+		privKey, _ := PrivKeyFromBytes(S256(), []byte{byte(i + 1), 0x42})
+		hash := sha256.Sum256([]byte{byte(i)})
+		compressed := i%2 != 0
+		sig, err := SignCompact(S256(), privKey, hash[:], compressed)
+		if err != nil {
+			t.Fatalf("#%d: failed to sign: %v", i, err)
+		}
+
+		key, alt, wasCompressed, err := RecoverCompactBoth(S256(), sig,
+			hash[:])
+		if err != nil {
+			t.Fatalf("#%d: unexpected error: %v", i, err)
+		}
+		if !key.IsEqual(privKey.PubKey()) {
+			t.Fatalf("#%d: recovered key is not the signing key", i)
+		}
+		if wasCompressed != compressed {
+			t.Fatalf("#%d: got compressed %v, want %v", i,
+				wasCompressed, compressed)
+		}
+		if alt == nil {
+			t.Fatalf("#%d: no alternate key recovered", i)
+		}
+		if alt.IsEqual(key) {
+			t.Fatalf("#%d: alternate key is the recovered key", i)
+		}
+
+		// The signature must be valid for both keys.
+		if !VerifyRaw(key, hash[:], sig[1:]) {
+			t.Fatalf("#%d: signature invalid for recovered key", i)
+		}
+		if !VerifyRaw(alt, hash[:], sig[1:]) {
+			t.Fatalf("#%d: signature invalid for alternate key", i)
+		}
+	}
+
+	// An error is returned when the key indicated by the header can't be
+	// recovered.
+	sig := make([]byte, 65)
+	sig[0] = 27
+	hash := sha256.Sum256([]byte("invalid"))
+	if _, _, _, err := RecoverCompactBoth(S256(), sig, hash[:]); err == nil {
+		t.Fatal("recovered key from invalid signature")
+	}
+}
+
 // recoveryTests assert basic tests for public key recovery from signatures.
 // The cases are borrowed from github.com/fjl/btcec-issue.
 var recoveryTests = []struct {
@@ -591,6 +645,82 @@ func TestRecoverCompact(t *testing.T) {
 	}
 }
 
+// TestRecoverRRange ensures public key recovery rejects signatures with an R
+// or recovery ID that is out of range with a descriptive error and that valid
+// signatures still recover the signing key.
+func TestRecoverRRange(t *testing.T) {
+	curve := S256()
+	N, P := curve.N, curve.P
+	hash := sha256.Sum256([]byte("recovery range"))
+	privKey, _ := PrivKeyFromBytes(curve, decodeHex("0123456789abcdef0123"+
+		"456789abcdef0123456789abcdef0123456789abcdef"))
+
+	// R + N equals P for this R, so the second candidate x coordinate is
+	// not in the field.
+	pMinusN := new(big.Int).Sub(P, N)
+
+	tests := []struct {
+		name    string
+		r       *big.Int
+		iter    int
+		wantErr string
+	}{
+		{"R is zero", new(big.Int), 0, "must be positive"},
+		{"R is negative", big.NewInt(-1), 1, "must be positive"},
+		{"R equals N", N, 0, "not less than the group order"},
+		{"R greater than N", new(big.Int).Add(N, one), 1,
+			"not less than the group order"},
+		{"R + N equals P", pMinusN, 2, "R + N is not less than curve P"},
+		{"R + N greater than P", new(big.Int).Sub(N, one), 3,
+			"R + N is not less than curve P"},
+		{"recovery ID too large", one, 4, "invalid recovery ID"},
+		{"recovery ID negative", one, -1, "invalid recovery ID"},
+	}
+	for _, test := range tests {
+		sig := &Signature{R: test.r, S: big.NewInt(1)}
+		_, err := recoverKeyFromSignature(curve, sig, hash[:], test.iter,
+			false)
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("%s: unexpected error -- got %v, want %q",
+				test.name, err, test.wantErr)
+		}
+	}
+
+	// Compact signatures with an out of range R are rejected too.
+	compact, err := SignCompact(curve, privKey, hash[:], true)
+	if err != nil {
+		t.Fatalf("failed to sign compact: %v", err)
+	}
+	bad := append([]byte(nil), compact...)
+	copy(bad[1:33], make([]byte, 32))
+	if _, _, err := RecoverCompact(curve, bad, hash[:]); err == nil {
+		t.Error("compact signature with R = 0 was recovered")
+	}
+	bad = append([]byte(nil), compact...)
+	copy(bad[1:33], paddedAppend(32, nil, pMinusN.Bytes()))
+	bad[0] = 27 + 4 + 2
+	_, _, err = RecoverCompact(curve, bad, hash[:])
+	if err == nil || !strings.Contains(err.Error(), "R + N") {
+		t.Errorf("unexpected error for R + N = P -- got %v", err)
+	}
+	bad = append([]byte(nil), compact...)
+	bad[0] = 26
+	_, _, err = RecoverCompact(curve, bad, hash[:])
+	if err == nil || !strings.Contains(err.Error(), "recovery ID") {
+		t.Errorf("unexpected error for header byte 26 -- got %v", err)
+	}
+
+	// A valid signature still recovers the signing key.
+	pubKey, compressed, err := RecoverCompact(curve, compact, hash[:])
+	if err != nil {
+		t.Fatalf("failed to recover valid signature: %v", err)
+	}
+	if !compressed || !pubKey.IsEqual(privKey.PubKey()) {
+		t.Fatalf("recovered wrong key %x (compressed %v)",
+			pubKey.SerializeCompressed(), compressed)
+	}
+}
+
 func TestRFC6979(t *testing.T) {
 	// Test vectors matching Trezor and CoreBitcoin implementations.
 	// - https://github.com/trezor/trezor-crypto/blob/9fea8f8ab377dc514e40c6fd1f7c89a74c1d8dc6/tests.c#L432-L453
@@ -693,3 +823,739 @@ func TestSignatureIsEqual(t *testing.T) {
 			"equal to %v", sig1, sig2)
 	}
 }
+
+// TestSignTagged ensures signatures over tagged hashes verify under the tag
+// they were produced with and fail under any other tag.
+func TestSignTagged(t *testing.T) {
+	privKey, err := NewPrivateKey(S256())
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+	pubKey := privKey.PubKey()
+	msg := []byte("domain separated message")
+
+	sig, err := SignTagged(privKey, "protocol-a", msg)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if !VerifyTagged(pubKey, "protocol-a", msg, sig) {
+		t.Fatal("signature does not verify under the signing tag")
+	}
+	if VerifyTagged(pubKey, "protocol-b", msg, sig) {
+		t.Fatal("signature verifies under a different tag")
+	}
+	if sig.Verify(msg, pubKey) {
+		t.Fatal("signature verifies against the untagged message")
+	}
+
+	// The tagged hash must differ from a plain hash of the message.
+	plain := sha256.Sum256(msg)
+	if bytes.Equal(TaggedHash("protocol-a", msg), plain[:]) {
+		t.Fatal("tagged hash equals the untagged hash")
+	}
+}
+
+// TestSignScalar ensures signing a message scalar produces the same signature
+// as signing the hash it was derived from and that scalars outside of the
+// valid range are rejected.
+func TestSignScalar(t *testing.T) {
+	privKey, err := NewPrivateKey(S256())
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+
+	plain := sha256.Sum256([]byte("scalar"))
+	hashes := [][]byte{
+		plain[:],
+		// Hash that is greater than N and therefore reduced.
+		decodeHex("ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"),
+		// Short hash.
+		decodeHex("01"),
+	}
+	for i, hash := range hashes {
+		want, err := privKey.Sign(hash)
+		if err != nil {
+			t.Fatalf("#%d: failed to sign hash: %v", i, err)
+		}
+
+		e := hashToInt(hash, S256())
+		e.Mod(e, S256().N)
+		got, err := SignScalar(privKey, e)
+		if err != nil {
+			t.Fatalf("#%d: failed to sign scalar: %v", i, err)
+		}
+		if !got.IsEqual(want) {
+			t.Fatalf("#%d: mismatched signature - got (%x, %x), "+
+				"want (%x, %x)", i, got.R, got.S, want.R, want.S)
+		}
+		if !got.Verify(hash, privKey.PubKey()) {
+			t.Fatalf("#%d: signature does not verify", i)
+		}
+	}
+
+	for _, e := range []*big.Int{big.NewInt(-1), S256().N} {
+		if _, err := SignScalar(privKey, e); err == nil {
+			t.Fatalf("signing out of range scalar %x did not fail", e)
+		}
+	}
+}
+
+// TestVerifyProjective ensures verifying signatures without converting the
+// final point to affine agrees with the affine verification in the standard
+// library, including when the x coordinate of R exceeds the group order.
+func TestVerifyProjective(t *testing.T) {
+	curve := S256()
+	privKey, err := NewPrivateKey(curve)
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+	pubKey := privKey.PubKey()
+
+	type vector struct {
+		name   string
+		pubKey *PublicKey
+		hash   []byte
+		r, s   *big.Int
+	}
+	var vectors []vector
+	for i := 0; i < 32; i++ {
+		hash := sha256.Sum256([]byte{byte(i)})
+		sig, err := privKey.Sign(hash[:])
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		negS := new(big.Int).Sub(curve.N, sig.S)
+		badHash := sha256.Sum256([]byte{byte(i), 0x01})
+		vectors = append(vectors,
+			vector{"valid", pubKey, hash[:], sig.R, sig.S},
+			vector{"high s", pubKey, hash[:], sig.R, negS},
+			vector{"wrong hash", pubKey, badHash[:], sig.R, sig.S},
+			vector{"r+1", pubKey, hash[:],
+				new(big.Int).Add(sig.R, one), sig.S},
+		)
+	}
+
+	// Construct a valid signature with an R whose x coordinate is greater
+	// than N by choosing the public key Q = r⁻¹(R - eG) for s = 1, so that
+	// u1*G + u2*Q = eG + r*r⁻¹(R - eG) = R.
+	rx := new(big.Int).Add(curve.N, one)
+	var ry *big.Int
+	for {
+		if ry, err = decompressPoint(curve, rx, false); err == nil {
+			break
+		}
+		rx.Add(rx, one)
+	}
+	hash := sha256.Sum256([]byte("r greater than N"))
+	e := hashToInt(hash[:], curve)
+	r := new(big.Int).Sub(rx, curve.N)
+	egx, egy := curve.ScalarBaseMult(e.Bytes())
+	qx, qy := curve.Add(rx, ry, egx, new(big.Int).Sub(curve.P, egy))
+	rInv := new(big.Int).ModInverse(r, curve.N)
+	qx, qy = curve.ScalarMult(qx, qy, rInv.Bytes())
+	forged := &PublicKey{Curve: curve, X: qx, Y: qy}
+	vectors = append(vectors,
+		vector{"x(R) >= N", forged, hash[:], r, one},
+		vector{"x(R) >= N with r = x(R)", forged, hash[:], rx, one},
+		vector{"r = N", pubKey, hash[:], curve.N, one},
+		vector{"s = 0", pubKey, hash[:], one, new(big.Int)},
+	)
+
+	var sawRPlusN bool
+	for i, v := range vectors {
+		got := (&Signature{R: v.r, S: v.s}).Verify(v.hash, v.pubKey)
+		want := ecdsa.Verify(v.pubKey.ToECDSA(), v.hash, v.r, v.s)
+		if got != want {
+			t.Fatalf("#%d (%s): mismatched verification result - "+
+				"got %v, want %v", i, v.name, got, want)
+		}
+		if v.pubKey == forged && v.r == r {
+			sawRPlusN = got
+		}
+	}
+	if !sawRPlusN {
+		t.Fatal("signature with x(R) >= N did not verify")
+	}
+}
+
+// TestSignatureEqual ensures signatures parsed from different encodings of the
+// same values are equal while different signatures are not.
+func TestSignatureEqual(t *testing.T) {
+	privKey, err := NewPrivateKey(S256())
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+	hash := sha256.Sum256([]byte("equal"))
+	sig, err := privKey.Sign(hash[:])
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	// Build a BER encoding of the same signature with an excess zero byte
+	// of padding in front of both R and S.
+	der := sig.Serialize()
+	rLen := int(der[3])
+	rBytes := der[4 : 4+rLen]
+	sBytes := der[4+rLen+2:]
+	ber := []byte{0x30, byte(len(der) - 2 + 2), 0x02, byte(rLen + 1), 0x00}
+	ber = append(ber, rBytes...)
+	ber = append(ber, 0x02, byte(len(sBytes)+1), 0x00)
+	ber = append(ber, sBytes...)
+	if bytes.Equal(der, ber) {
+		t.Fatal("BER and DER encodings are identical")
+	}
+
+	fromDER, err := ParseDERSignature(der, S256())
+	if err != nil {
+		t.Fatalf("failed to parse DER signature: %v", err)
+	}
+	fromBER, err := ParseSignature(ber, S256())
+	if err != nil {
+		t.Fatalf("failed to parse BER signature: %v", err)
+	}
+	if !fromDER.Equal(fromBER) || !fromBER.Equal(fromDER) {
+		t.Fatalf("signatures from equivalent encodings are not equal: "+
+			"%v, %v", fromDER, fromBER)
+	}
+
+	otherHash := sha256.Sum256([]byte("not equal"))
+	otherSig, err := privKey.Sign(otherHash[:])
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if sig.Equal(otherSig) {
+		t.Fatal("different signatures are equal")
+	}
+	sameR := &Signature{R: sig.R, S: new(big.Int).Add(sig.S, one)}
+	if sig.Equal(sameR) {
+		t.Fatal("signatures with different S values are equal")
+	}
+}
+
+// TestParseDERSignatureLax ensures the lax parser accepts the historical
+// encoding malformations tolerated by old versions of OpenSSL while still
+// rejecting signatures that can't be parsed.
+//
+// Besides synthetic encodings constructed by hand from a single strictly
+// encoded signature, one per class of malformation, it covers real signatures
+// from historical mainnet transactions that are only accepted by lax parsing.
+func TestParseDERSignatureLax(t *testing.T) {
+	// R and S from the signature in the first bitcoin transaction from
+	// Satoshi to Hal Finney which is strictly DER encoded.
+	const (
+		r = "4e45e16932b8af514961a1d3a1a25fdf3f4f7732e9d624c6c61548ab5fb8cd41"
+		s = "181522ec8eca07de4860a4acdd12909d831cc56cbbac4622082221a8768d1d09"
+
+		// highR has the high bit set, so it must be padded in DER.
+		highR = "82235e21a2300022738dabb8e1bbd9d19cfb1e7ab8c30a23b0afbb8d178abcf3"
+	)
+
+	tests := []struct {
+		name  string
+		sig   string
+		wantR string
+		wantS string
+	}{{
+		name:  "strict DER",
+		sig:   "3044" + "0220" + r + "0220" + s,
+		wantR: r,
+		wantS: s,
+	}, {
+		name:  "trailing hash type byte",
+		sig:   "3044" + "0220" + r + "0220" + s + "01",
+		wantR: r,
+		wantS: s,
+	}, {
+		name:  "sequence length too short",
+		sig:   "3040" + "0220" + r + "0220" + s,
+		wantR: r,
+		wantS: s,
+	}, {
+		name:  "sequence length too long",
+		sig:   "307f" + "0220" + r + "0220" + s,
+		wantR: r,
+		wantS: s,
+	}, {
+		name:  "long form sequence length",
+		sig:   "308144" + "0220" + r + "0220" + s,
+		wantR: r,
+		wantS: s,
+	}, {
+		name:  "non-minimal long form integer lengths",
+		sig:   "3048" + "02820020" + r + "0283000020" + s,
+		wantR: r,
+		wantS: s,
+	}, {
+		name:  "excess zero padding",
+		sig:   "3048" + "02230000" + "00" + r + "0221" + "00" + s,
+		wantR: r,
+		wantS: s,
+	}, {
+		name:  "negative R",
+		sig:   "3044" + "0220" + highR + "0220" + s,
+		wantR: highR,
+		wantS: s,
+	}}
+
+	for _, test := range tests {
+		sig, err := ParseDERSignatureLax(decodeHex(test.sig))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if sig.R.Cmp(fromHex(test.wantR)) != 0 ||
+			sig.S.Cmp(fromHex(test.wantS)) != 0 {
+			t.Errorf("%s: mismatched signature - got (%x, %x), "+
+				"want (%s, %s)", test.name, sig.R, sig.S,
+				test.wantR, test.wantS)
+		}
+	}
+
+	// Real signatures from mainnet transactions whose R or S has its high
+	// bit set without the zero byte of padding DER requires, so that it is
+	// negative when read strictly.  Each is listed with the signature hash
+	// of its input and the public key that produced it, and must verify.
+	historical := []struct {
+		name   string
+		sig    string
+		hash   string
+		pubKey string
+	}{{
+		name: "23b397edccd3740a74adb603c9756370fafcde9bcc4483eb271ecad09a94dd63 " +
+			"input 0 (negative S)",
+		sig: "304402203f16c6f40162ab686621ef3000b04e75418a0c0cb2d8aebeac894ae3" +
+			"60ac1e780220ddc15ecdfc3507ac48e1681a33eb60996631bf6bf5bc0a0682c4" +
+			"db743ce7ca2b",
+		hash: "259d83e4174d7a386542918b53294b6d0affd82b2939d37f5066d296f36914c2",
+		pubKey: "04cc71eb30d653c0c3163990c47b976f3fb3f37cccdcbedb169a1dfef58bbf" +
+			"bfaff7d8a473e7e2e6d317b87bafe8bde97e3cf8f065dec022b51d11fcdd0d348ac4",
+	}, {
+		name: "f7fdd091fa6d8f5e7a8c2458f5c38faffff2d3f1406b6e4fe2c99dcc0d2d1cbb " +
+			"input 0 (negative R)",
+		sig: "30440220ffda47bfc776bcd269da4832626ac332adfca6dd835e8ecd83cd1ebe" +
+			"7d709b0e022049cffa1cdc102a0b56e0e04913606c70af702a1149dc3b305ab9" +
+			"439288fee090",
+		hash: "0860b78454cdfc9704452dc35e4c9f03aaecaea7ead242358d2b3bba37f9d0dc",
+		pubKey: "04266abb36d66eb4218a6dd31f09bb92cf3cfa803c7ea72c1fc80a50f91927" +
+			"3e613f895b855fb7465ccbc8919ad1bd4a306c783f22cd3227327694c4fa4c1c439a",
+	}, {
+		name: "f7fdd091fa6d8f5e7a8c2458f5c38faffff2d3f1406b6e4fe2c99dcc0d2d1cbb " +
+			"input 1 (negative S)",
+		sig: "30440220503ff10e9f1e0de731407a4a245531c9ff17676eda461f8ceeb8c060" +
+			"49fa2c810220c008ac34694510298fa60b3f000df01caa244f165b727d4896eb" +
+			"84f81e46bcc4",
+		hash: "fe5bb443be535dab1ccdd2fe9320e5eb75036cbfb6de5fd22c421bba0d438281",
+		pubKey: "04266abb36d66eb4218a6dd31f09bb92cf3cfa803c7ea72c1fc80a50f91927" +
+			"3e613f895b855fb7465ccbc8919ad1bd4a306c783f22cd3227327694c4fa4c1c439a",
+	}}
+	for _, test := range historical {
+		if _, err := ParseDERSignature(decodeHex(test.sig), S256()); err == nil {
+			t.Errorf("%s: strict parser accepted signature", test.name)
+		}
+		sig, err := ParseDERSignatureLax(decodeHex(test.sig))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		pubKey, err := ParsePubKey(decodeHex(test.pubKey), S256())
+		if err != nil {
+			t.Fatalf("%s: failed to parse public key: %v", test.name, err)
+		}
+		if !sig.Verify(decodeHex(test.hash), pubKey) {
+			t.Errorf("%s: signature failed to verify", test.name)
+		}
+	}
+
+	// The strict parser must continue to reject the malformed encodings.
+	if _, err := ParseDERSignature(decodeHex(tests[6].sig), S256()); err == nil {
+		t.Error("strict parser accepted excess zero padding")
+	}
+	if _, err := ParseDERSignature(decodeHex(tests[7].sig), S256()); err == nil {
+		t.Error("strict parser accepted negative R")
+	}
+
+	order := fmt.Sprintf("%x", S256().N)
+	invalid := []struct {
+		name string
+		sig  string
+	}{
+		{"empty", ""},
+		{"no header magic", "3144" + "0220" + r + "0220" + s},
+		{"no R marker", "3044" + "0320" + r + "0220" + s},
+		{"R length past end", "3044" + "0250" + r + "0220" + s},
+		{"truncated S", "3044" + "0220" + r + "0220" + s[:62]},
+		{"no S", "3022" + "0220" + r},
+		{"long form length past end", "3044" + "0284"},
+		{"R overflows 256 bits", "3045" + "0221" + "01" + r + "0220" + s},
+		{"zero R", "3025" + "020100" + "0220" + s},
+		{"R equal to N", "3044" + "0220" + order + "0220" + s},
+		{"S equal to N", "3044" + "0220" + r + "0220" + order},
+	}
+	for _, test := range invalid {
+		if _, err := ParseDERSignatureLax(decodeHex(test.sig)); err == nil {
+			t.Errorf("%s: signature was accepted", test.name)
+		}
+	}
+}
+
+// TestParseCompact64 ensures 64-byte signatures with R or S outside of the
+// range [1, N-1] are rejected instead of being reduced modulo N.
+func TestParseCompact64(t *testing.T) {
+	privKey, err := NewPrivateKey(S256())
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+	hash := sha256.Sum256([]byte("compact"))
+	sig, err := privKey.Sign(hash[:])
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	// compact64 returns the 64-byte serialization of r and s.
+	compact64 := func(r, s *big.Int) []byte {
+		b := paddedAppend(32, nil, r.Bytes())
+		return paddedAppend(32, b, s.Bytes())
+	}
+	N := S256().N
+	nMinusOne := new(big.Int).Sub(N, one)
+	tests := []struct {
+		name    string
+		sig     []byte
+		isValid bool
+	}{
+		{"valid", compact64(sig.R, sig.S), true},
+		{"r = N-1", compact64(nMinusOne, sig.S), true},
+		{"s = N-1", compact64(sig.R, nMinusOne), true},
+		{"r = N", compact64(N, sig.S), false},
+		{"s = N", compact64(sig.R, N), false},
+		{"r = 0", compact64(new(big.Int), sig.S), false},
+		{"s = 0", compact64(sig.R, new(big.Int)), false},
+		{"r = 2^256-1", compact64(new(big.Int).SetBytes(
+			bytes.Repeat([]byte{0xff}, 32)), sig.S), false},
+		{"short", compact64(sig.R, sig.S)[:63], false},
+		{"long", append(compact64(sig.R, sig.S), 0x00), false},
+	}
+	for _, test := range tests {
+		parsed, err := ParseCompact64(test.sig)
+		if (err == nil) != test.isValid {
+			t.Errorf("%s: unexpected parse result - got err %v, "+
+				"want valid %v", test.name, err, test.isValid)
+			continue
+		}
+		if test.isValid && !bytes.Equal(compact64(parsed.R, parsed.S),
+			test.sig) {
+
+			t.Errorf("%s: parsed signature does not round trip",
+				test.name)
+		}
+	}
+
+	pubKey := privKey.PubKey()
+	if !VerifyRaw(pubKey, hash[:], compact64(sig.R, sig.S)) {
+		t.Fatal("valid raw signature did not verify")
+	}
+
+	if VerifyRaw(pubKey, hash[:], compact64(sig.R, N)) {
+		t.Fatal("raw signature with S = N verified")
+	}
+
+	// Compact signatures with a recovery code must also be rejected.
+	compact, err := SignCompact(S256(), privKey, hash[:], true)
+	if err != nil {
+		t.Fatalf("failed to sign compact: %v", err)
+	}
+	copy(compact[1:33], N.Bytes())
+	if _, _, err := RecoverCompact(S256(), compact, hash[:]); err == nil {
+		t.Fatal("compact signature with R = N was recovered")
+	}
+}
+
+// TestVerifyWithSerializedKey ensures signatures verify against public keys in
+// all serialized formats and that malformed keys are reported as errors
+// distinct from verification failures.
+func TestVerifyWithSerializedKey(t *testing.T) {
+	privKey, _ := PrivKeyFromBytes(S256(), decodeHex("eaf02ca348c524e6"+
+		"392655ba4d29603cd1a7347d9d65cfe93ce1ebffdca22694"))
+	hash := sha256.Sum256([]byte("serialized key"))
+	sig, err := privKey.Sign(hash[:])
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	pubKey := privKey.PubKey()
+	otherHash := sha256.Sum256([]byte("other message"))
+
+	malformed := pubKey.SerializeCompressed()
+	malformed[0] = 0x05
+
+	tests := []struct {
+		name     string
+		pubBytes []byte
+		hash     []byte
+		valid    bool
+		err      bool
+	}{
+		{"compressed", pubKey.SerializeCompressed(), hash[:], true, false},
+		{"uncompressed", pubKey.SerializeUncompressed(), hash[:], true, false},
+		{"hybrid", pubKey.SerializeHybrid(), hash[:], true, false},
+		{"wrong hash", pubKey.SerializeCompressed(), otherHash[:], false, false},
+		{"bad format byte", malformed, hash[:], false, true},
+		{"truncated", pubKey.SerializeCompressed()[:20], hash[:], false, true},
+		{"empty", nil, hash[:], false, true},
+	}
+
+	for _, test := range tests {
+		valid, err := VerifyWithSerializedKey(test.pubBytes, test.hash, sig)
+		if (err != nil) != test.err {
+			t.Errorf("%s: unexpected error - got %v, want error %v",
+				test.name, err, test.err)
+			continue
+		}
+		if valid != test.valid {
+			t.Errorf("%s: got valid %v, want %v", test.name, valid,
+				test.valid)
+		}
+	}
+}
+
+// TestVerifyFullHash ensures signatures over the full hash reduced modulo N
+// verify with VerifyFullHash but not with Verify when the hash is longer than
+// N, and that both modes agree for 32-byte hashes.
+func TestVerifyFullHash(t *testing.T) {
+	privKey, _ := PrivKeyFromBytes(S256(), decodeHex("eaf02ca348c524e6"+
+		"392655ba4d29603cd1a7347d9d65cfe93ce1ebffdca22694"))
+	pubKey := privKey.PubKey()
+	N := S256().N
+
+	// A 64-byte hash where truncation and reduction modulo N differ.
+	hash := sha512.Sum512([]byte("full hash"))
+	full := new(big.Int).SetBytes(hash[:])
+	full.Mod(full, N)
+	if full.Cmp(hashToInt(hash[:], S256())) == 0 {
+		t.Fatal("test hash does not distinguish the modes")
+	}
+
+	fullSig, err := SignScalar(privKey, full)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if !VerifyFullHash(pubKey, hash[:], fullSig) {
+		t.Error("full hash signature failed to verify with VerifyFullHash")
+	}
+	if fullSig.Verify(hash[:], pubKey) {
+		t.Error("full hash signature verified with truncation")
+	}
+
+	truncatedSig, err := privKey.Sign(hash[:])
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if !truncatedSig.Verify(hash[:], pubKey) {
+		t.Error("truncated hash signature failed to verify")
+	}
+	if VerifyFullHash(pubKey, hash[:], truncatedSig) {
+		t.Error("truncated hash signature verified with VerifyFullHash")
+	}
+
+	// Both modes agree for a 32-byte hash, even when it is at least N.
+	big32 := bytes.Repeat([]byte{0xff}, 32)
+	sig, err := privKey.Sign(big32)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if !sig.Verify(big32, pubKey) || !VerifyFullHash(pubKey, big32, sig) {
+		t.Error("modes disagree for a 32-byte hash")
+	}
+}
+
+// TestSignAllFormats ensures the DER and compact encodings produced by
+// SignAllFormats describe the same signature and that the recovery id recovers
+// the public key of the signer.
+func TestSignAllFormats(t *testing.T) {
+	for i := 0; i < 32; i++ {
+		privKey, err := NewPrivateKey(S256())
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		hash := sha256.Sum256([]byte(fmt.Sprintf("all formats %d", i)))
+
+		der, compact, recid, err := SignAllFormats(privKey, hash[:])
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+
+		derSig, err := ParseDERSignature(der, S256())
+		if err != nil {
+			t.Fatalf("%d: failed to parse DER signature: %v", i, err)
+		}
+		compactSig, err := ParseCompact64(compact)
+		if err != nil {
+			t.Fatalf("%d: failed to parse compact signature: %v", i, err)
+		}
+		if !derSig.IsEqual(compactSig) {
+			t.Fatalf("%d: DER signature %x does not match compact %x", i,
+				der, compact)
+		}
+		if !derSig.Verify(hash[:], privKey.PubKey()) {
+			t.Fatalf("%d: signature failed to verify", i)
+		}
+
+		if recid < 0 || recid > 3 {
+			t.Fatalf("%d: recovery id %d out of range", i, recid)
+		}
+		recoverable := append([]byte{byte(27 + recid + 4)}, compact...)
+		pubKey, compressed, err := RecoverCompact(S256(), recoverable,
+			hash[:])
+		if err != nil {
+			t.Fatalf("%d: failed to recover key: %v", i, err)
+		}
+		if !compressed || !pubKey.IsEqual(privKey.PubKey()) {
+			t.Fatalf("%d: recovered the wrong key", i)
+		}
+
+		// The header must match the one found by SignCompact.
+		want, err := SignCompact(S256(), privKey, hash[:], true)
+		if err != nil {
+			t.Fatalf("%d: failed to sign compact: %v", i, err)
+		}
+		if !bytes.Equal(recoverable, want) {
+			t.Fatalf("%d: got %x, want %x", i, recoverable, want)
+		}
+	}
+}
+
+// TestSignWithR ensures the nonce point returned by SignWithR corresponds to
+// the produced signature and that the signature matches PrivateKey.Sign.
+func TestSignWithR(t *testing.T) {
+	N := S256().N
+	for i := 0; i < 32; i++ {
+		privKey, err := NewPrivateKey(S256())
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		hash := sha256.Sum256([]byte(fmt.Sprintf("nonce point %d", i)))
+
+		sig, R, err := SignWithR(privKey, hash[:])
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		if !S256().IsOnCurve(R.X, R.Y) {
+			t.Fatalf("%d: nonce point is not on the curve", i)
+		}
+		if new(big.Int).Mod(R.X, N).Cmp(sig.R) != 0 {
+			t.Fatalf("%d: R.X mod N = %x, want %x", i, R.X, sig.R)
+		}
+
+		want, err := privKey.Sign(hash[:])
+		if err != nil {
+			t.Fatalf("%d: failed to sign: %v", i, err)
+		}
+		if !sig.IsEqual(want) {
+			t.Fatalf("%d: signature does not match Sign", i)
+		}
+
+		// R must satisfy s*R = e*G + r*P.
+		e := hashToInt(hash[:], S256())
+		sRx, sRy := S256().ScalarMult(R.X, R.Y, sig.S.Bytes())
+		eGx, eGy := S256().ScalarBaseMult(e.Bytes())
+		rPx, rPy := S256().ScalarMult(privKey.X, privKey.Y, sig.R.Bytes())
+		wantX, wantY := S256().Add(eGx, eGy, rPx, rPy)
+		if sRx.Cmp(wantX) != 0 || sRy.Cmp(wantY) != 0 {
+			t.Fatalf("%d: nonce point does not satisfy s*R = e*G + r*P", i)
+		}
+	}
+}
+
+// TestCanonicalize ensures equivalent encodings of the same signature,
+// including its high-S variant, canonicalize to identical strict DER bytes.
+func TestCanonicalize(t *testing.T) {
+	privKey, _ := PrivKeyFromBytes(S256(), decodeHex("eaf02ca348c524e6"+
+		"392655ba4d29603cd1a7347d9d65cfe93ce1ebffdca22694"))
+	hash := sha256.Sum256([]byte("canonicalize"))
+	sig, err := privKey.Sign(hash[:])
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	want := sig.Serialize()
+	highS := &Signature{R: sig.R, S: new(big.Int).Sub(S256().N, sig.S)}
+
+	// derHighS is the strict DER encoding of the high-S variant, which
+	// Serialize does not produce since it normalizes S.
+	rb, sb := canonicalizeInt(highS.R), canonicalizeInt(highS.S)
+	derHighS := []byte{0x30, byte(4 + len(rb) + len(sb)), 0x02, byte(len(rb))}
+	derHighS = append(derHighS, rb...)
+	derHighS = append(derHighS, 0x02, byte(len(sb)))
+	derHighS = append(derHighS, sb...)
+
+	// laxHighS has an excess leading zero in S and a long form length.
+	laxHighS := []byte{0x30, 0x81, byte(5 + len(rb) + len(sb)), 0x02, byte(len(rb))}
+	laxHighS = append(laxHighS, rb...)
+	laxHighS = append(laxHighS, 0x02, byte(len(sb)+1), 0x00)
+	laxHighS = append(laxHighS, sb...)
+
+	compact := paddedAppend(32, nil, sig.R.Bytes())
+	compact = paddedAppend(32, compact, sig.S.Bytes())
+	compactHighS := paddedAppend(32, nil, highS.R.Bytes())
+	compactHighS = paddedAppend(32, compactHighS, highS.S.Bytes())
+
+	tests := []struct {
+		name string
+		sig  []byte
+	}{
+		{"strict DER low S", want},
+		{"strict DER high S", derHighS},
+		{"lax DER high S", laxHighS},
+		{"compact low S", compact},
+		{"compact high S", compactHighS},
+	}
+	for _, test := range tests {
+		got, err := Canonicalize(test.sig)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: got %x, want %x", test.name, got, want)
+		}
+	}
+
+	for _, invalid := range [][]byte{nil, {0x30, 0x00}, make([]byte, 64)} {
+		if _, err := Canonicalize(invalid); err == nil {
+			t.Errorf("canonicalized invalid signature %x", invalid)
+		}
+	}
+
+	// A DER signature with a 32-byte R and a 26-byte S is 64 bytes long,
+	// the same as a compact signature, and must still be parsed as DER.
+	short := &Signature{
+		R: fromHex("4e45e16932b8af514961a1d3a1a25fdf3f4f7732e9d624c6c61548ab5fb8cd41"),
+		S: fromHex("181522ec8eca07de4860a4acdd12909d831cc56cbbac46220822"),
+	}
+	der64 := short.Serialize()
+	if len(der64) != CompactSigLen {
+		t.Fatalf("DER signature is %d bytes, want %d", len(der64),
+			CompactSigLen)
+	}
+	got, err := Canonicalize(der64)
+	if err != nil {
+		t.Fatalf("64-byte DER: unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, der64) {
+		t.Errorf("64-byte DER: got %x, want %x", got, der64)
+	}
+
+	// A compact signature that starts like a DER header but does not parse
+	// as DER must still be treated as compact.
+	derLike := append([]byte{0x30, 0x3e}, compact[2:]...)
+	wantSig, err := ParseCompact64(derLike)
+	if err != nil {
+		t.Fatalf("failed to parse compact signature: %v", err)
+	}
+	got, err = Canonicalize(derLike)
+	if err != nil {
+		t.Fatalf("DER-like compact: unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, wantSig.Serialize()) {
+		t.Errorf("DER-like compact: got %x, want %x", got,
+			wantSig.Serialize())
+	}
+}
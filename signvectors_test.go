@@ -0,0 +1,63 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// TestSignRFC6979Vectors ensures signatures produced by Sign are byte for byte
+// identical to the published deterministic RFC 6979 secp256k1 vectors, which
+// libsecp256k1 also produces with its default nonce function, and that the
+// published signatures verify.  See the source field of the data file for
+// where the vectors were taken from.
+func TestSignRFC6979Vectors(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata",
+		"rfc6979_secp256k1.json"))
+	if err != nil {
+		t.Fatalf("failed to read test vectors: %v", err)
+	}
+	var file struct {
+		Vectors []struct {
+			PrivKey string `json:"privKey"`
+			Msg     string `json:"msg"`
+			Sig     string `json:"sig"`
+		} `json:"vectors"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("failed to decode test vectors: %v", err)
+	}
+	if len(file.Vectors) == 0 {
+		t.Fatal("no test vectors")
+	}
+
+	for i, test := range file.Vectors {
+		privKey, pubKey := PrivKeyFromBytes(S256(), decodeHex(test.PrivKey))
+		hash := sha256.Sum256([]byte(test.Msg))
+		want := decodeHex(test.Sig)
+
+		sig, err := privKey.Sign(hash[:])
+		if err != nil {
+			t.Errorf("#%d (%q): failed to sign: %v", i, test.Msg, err)
+			continue
+		}
+		got := append(paddedAppend(32, nil, sig.R.Bytes()),
+			paddedAppend(32, nil, sig.S.Bytes())...)
+		if !bytes.Equal(got, want) {
+			t.Errorf("#%d (%q): mismatched signature - got %x, want %x",
+				i, test.Msg, got, want)
+		}
+
+		if !VerifyRaw(pubKey, hash[:], want) {
+			t.Errorf("#%d (%q): published signature failed to verify",
+				i, test.Msg)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"crypto/sha256"
+)
+
+// TaggedHash implements the tagged hash scheme described in BIP340.  It is
+// computed as:
+//
+//	sha256(sha256(tag) || sha256(tag) || msg[0] || msg[1] || ...)
+//
+// Prefixing the message with the hash of a tag means the hashes produced for
+// one purpose can never collide with those produced for another purpose that
+// uses a different tag, which provides domain separation between protocols.
+func TaggedHash(tag string, msgs ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, msg := range msgs {
+		h.Write(msg)
+	}
+	return h.Sum(nil)
+}
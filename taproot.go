@@ -0,0 +1,161 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// TapTweakTag is the tag used to compute the tweak of a Taproot output key as
+// defined by BIP341.
+const TapTweakTag = "TapTweak"
+
+// tapTweak returns the BIP341 tweak for the passed x coordinate of an internal
+// key and merkle root, which is either empty for outputs without a script
+// path or 32 bytes.  An error is returned when the merkle root has any other
+// length or in the astronomically unlikely event the tweak is not less than
+// the group order.
+func tapTweak(internalX *big.Int, merkleRoot []byte) (*big.Int, error) {
+	if len(merkleRoot) != 0 && len(merkleRoot) != 32 {
+		return nil, fmt.Errorf("merkle root must be empty or 32 bytes, "+
+			"got %d", len(merkleRoot))
+	}
+
+	xBytes := paddedAppend(32, nil, internalX.Bytes())
+	t := new(big.Int).SetBytes(TaggedHash(TapTweakTag, xBytes, merkleRoot))
+	if t.Cmp(S256().N) >= 0 {
+		return nil, errors.New("tap tweak is not less than the group order")
+	}
+	return t, nil
+}
+
+// TaprootOutputKey returns the Taproot output key for the passed internal key
+// and merkle root of the script tree as defined by BIP341.  The merkle root
+// must be empty when the output has no script path, or 32 bytes otherwise.
+//
+// Only the x coordinate of the internal key is used, so it is treated as the
+// point with an even y coordinate.  The output key is Q = P + t*G where
+// t = TaggedHash("TapTweak", x(P) || merkleRoot).  The full point is returned
+// since spending via a script path requires the parity of its y coordinate.
+func TaprootOutputKey(internalKey *PublicKey, merkleRoot []byte) (*PublicKey, error) {
+	curve := S256()
+	px, py := internalKey.X, internalKey.Y
+	if isOdd(py) {
+		py = new(big.Int).Sub(curve.P, py)
+	}
+	t, err := tapTweak(px, merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, ty := curve.ScalarBaseMult(t.Bytes())
+	qx, qy := curve.Add(px, py, tx, ty)
+	if qx.Sign() == 0 && qy.Sign() == 0 {
+		return nil, errors.New("output key is the point at infinity")
+	}
+	return &PublicKey{Curve: curve, X: qx, Y: qy}, nil
+}
+
+// VerifyTaprootTweak returns whether or not the Taproot output key with the
+// passed x coordinate and y coordinate parity, which must be 0 for even or 1
+// for odd, is the output key for the internal key with the passed x coordinate
+// and merkle root as computed by TaprootOutputKey.  This allows anyone to
+// confirm the key path tweak of an output without knowing any private key.
+func VerifyTaprootTweak(outputKeyX [32]byte, parity int, internalKeyX [32]byte, merkleRoot []byte) bool {
+	if parity != 0 && parity != 1 {
+		return false
+	}
+	internalKey, err := PublicKeyFromXAndParity(internalKeyX, pubkeyCompressed)
+	if err != nil {
+		return false
+	}
+	outputKey, err := TaprootOutputKey(internalKey, merkleRoot)
+	if err != nil {
+		return false
+	}
+
+	var x [32]byte
+	bigIntToBytes32(outputKey.X, &x)
+	return x == outputKeyX && int(outputKey.Y.Bit(0)) == parity
+}
+
+// TaprootTweakPrivKey returns the private key needed to spend a Taproot output
+// via the key path for the passed internal private key and merkle root.  The
+// public key of the returned private key is the output key returned by
+// TaprootOutputKey for the corresponding internal public key.
+//
+// The internal private key is negated when its public key has an odd y
+// coordinate, since the internal key is treated as the point with an even y
+// coordinate, and the tweak is then added to it.  Note that the output key
+// itself may have an odd y coordinate, so signers must still apply the usual
+// BIP340 negation when signing with the result.
+func TaprootTweakPrivKey(internalPriv *PrivateKey, merkleRoot []byte) (*PrivateKey, error) {
+	curve := S256()
+	d := new(big.Int).Set(internalPriv.D)
+	if d.Sign() == 0 || d.Cmp(curve.N) >= 0 {
+		return nil, errors.New("private key is not in the range [1, N-1]")
+	}
+
+	px, py := curve.ScalarBaseMult(d.Bytes())
+	if isOdd(py) {
+		d.Sub(curve.N, d)
+	}
+	t, err := tapTweak(px, merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	d.Add(d, t)
+	d.Mod(d, curve.N)
+	if d.Sign() == 0 {
+		return nil, errors.New("tweaked private key is zero")
+	}
+	priv, _ := PrivKeyFromBytes(curve, paddedAppend(PrivKeyBytesLen, nil,
+		d.Bytes()))
+	return priv, nil
+}
+
+// NUMSPoint returns the "nothing up my sleeve" point H suggested by BIP341 for
+// use as the internal key of outputs that must only be spendable via a script
+// path.  Its x coordinate is the SHA-256 hash of the uncompressed
+// serialization of the base point and its y coordinate is even, so nobody
+// knows its discrete logarithm.
+//
+// Using H directly as an internal key reveals that there is no key path, so
+// BIP341 recommends tweaking it with a secret random value first.
+func NUMSPoint() *PublicKey {
+	return &PublicKey{
+		Curve: S256(),
+		X:     fromHex("50929b74c1a04954b78b4b6035e97a5e078a5a0f28ec96d547bfee9ace803ac0"),
+		Y:     fromHex("31d3c6863973926e049e637cb1b5f40a36dac28af1766968c30c2313f3a38904"),
+	}
+}
+
+// NUMSPointFromTag returns a "nothing up my sleeve" point for the passed tag,
+// which is the result of HashToCurve applied to the tag.  Protocols that need
+// their own points with an unknown discrete logarithm should use a tag that
+// is unique to them.  See NUMSPoint for the point defined by BIP341.
+func NUMSPointFromTag(tag string) *PublicKey {
+	return HashToCurve([]byte(tag))
+}
+
+// ExpandXOnly reconstructs the full public key with the passed x coordinate and
+// the y coordinate with the passed parity.  Unlike x-only keys as used by
+// BIP340 and Taproot, which implicitly have an even y coordinate, the parity
+// is honored, so this is suitable for expanding x-only keys that are stored
+// alongside a separate parity bit, such as Taproot output keys along with the
+// parity of their y coordinate from a control block.
+//
+// An error is returned when x is not less than the field prime or there is no
+// point on the curve with the x coordinate.
+func ExpandXOnly(x [32]byte, parityOdd bool) (*PublicKey, error) {
+	parity := pubkeyCompressed
+	if parityOdd {
+		parity |= 0x1
+	}
+	return PublicKeyFromXAndParity(x, parity)
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+// TestTaprootTweakPrivKey ensures tweaking internal private keys produces the
+// expected BIP341 key path spending keys and that their public keys are the
+// output keys computed from the internal public keys.
+func TestTaprootTweakPrivKey(t *testing.T) {
+	// The following are from the key path spending test vectors of BIP341.
+	tests := []struct {
+		name         string
+		internalPriv string
+		merkleRoot   string
+		tweakedPriv  string
+	}{{
+		name:         "no script path",
+		internalPriv: "6b973d88838f27366ed61c9ad6367663045cb456e28335c109e30717ae0c6baa",
+		tweakedPriv:  "2405b971772ad26915c8dcdf10f238753a9b837e5f8e6a86fd7c0cce5b7296d9",
+	}, {
+		name:         "with script path",
+		internalPriv: "1e4da49f6aaf4e5cd175fe08a32bb5cb4863d963921255f33d3bc31e1343907f",
+		merkleRoot:   "5b75adecf53548f3ec6ad7d78383bf84cc57b55a3127c72b9a2481752dd88b21",
+		tweakedPriv:  "ea260c3b10e60f6de018455cd0278f2f5b7e454be1999572789e6a9565d26080",
+	}}
+
+	for _, test := range tests {
+		internalPriv, internalPub := PrivKeyFromBytes(S256(),
+			decodeHex(test.internalPriv))
+		merkleRoot := decodeHex(test.merkleRoot)
+
+		tweaked, err := TaprootTweakPrivKey(internalPriv, merkleRoot)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		want := decodeHex(test.tweakedPriv)
+		if !bytes.Equal(tweaked.Serialize(), want) {
+			t.Errorf("%s: mismatched tweaked key - got %x, want %x",
+				test.name, tweaked.Serialize(), want)
+			continue
+		}
+
+		outputKey, err := TaprootOutputKey(internalPub, merkleRoot)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !tweaked.PubKey().IsEqual(outputKey) {
+			t.Errorf("%s: public key of tweaked key is not the output "+
+				"key", test.name)
+		}
+	}
+
+	// The merkle root must be empty or 32 bytes.
+	priv, _ := PrivKeyFromBytes(S256(), decodeHex(tests[0].internalPriv))
+	if _, err := TaprootTweakPrivKey(priv, make([]byte, 31)); err == nil {
+		t.Error("tweaked key with 31-byte merkle root")
+	}
+}
+
+// TestTaprootOutputKey ensures the output keys computed from internal keys
+// match the BIP341 test vectors.
+func TestTaprootOutputKey(t *testing.T) {
+	// The following is from the scriptPubKey test vectors of BIP341.
+	// The odd y coordinate ensures only the x coordinate is used.
+	var internalX [32]byte
+	copy(internalX[:], decodeHex("d6889cb081036e0faefa3a35157ad71086b123"+
+		"b2b144b649798b494c300a961d"))
+	internalKey, err := PublicKeyFromXAndParity(internalX, 0x03)
+	if err != nil {
+		t.Fatalf("failed to parse internal key: %v", err)
+	}
+	outputKey, err := TaprootOutputKey(internalKey, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := decodeHex("53a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343")
+	if got := outputKey.SerializeCompressed()[1:]; !bytes.Equal(got, want) {
+		t.Fatalf("mismatched output key - got %x, want %x", got, want)
+	}
+}
+
+// TestVerifyTaprootTweak ensures output keys are only accepted for the
+// internal key, merkle root, and parity they were derived from.
+func TestVerifyTaprootTweak(t *testing.T) {
+	// The following are from the key path spending test vectors of BIP341.
+	// The output keys are the public keys of the tweaked private keys.
+	tests := []struct {
+		internalPriv string
+		merkleRoot   string
+		tweakedPriv  string
+	}{{
+		internalPriv: "6b973d88838f27366ed61c9ad6367663045cb456e28335c109e30717ae0c6baa",
+		tweakedPriv:  "2405b971772ad26915c8dcdf10f238753a9b837e5f8e6a86fd7c0cce5b7296d9",
+	}, {
+		internalPriv: "1e4da49f6aaf4e5cd175fe08a32bb5cb4863d963921255f33d3bc31e1343907f",
+		merkleRoot:   "5b75adecf53548f3ec6ad7d78383bf84cc57b55a3127c72b9a2481752dd88b21",
+		tweakedPriv:  "ea260c3b10e60f6de018455cd0278f2f5b7e454be1999572789e6a9565d26080",
+	}}
+
+	xOnly := func(pub *PublicKey) (x [32]byte, parity int) {
+		copy(x[:], pub.SerializeCompressed()[1:])
+		return x, int(pub.Y.Bit(0))
+	}
+
+	for i, test := range tests {
+		_, internalPub := PrivKeyFromBytes(S256(), decodeHex(test.internalPriv))
+		_, outputPub := PrivKeyFromBytes(S256(), decodeHex(test.tweakedPriv))
+		internalX, _ := xOnly(internalPub)
+		outputX, parity := xOnly(outputPub)
+		merkleRoot := decodeHex(test.merkleRoot)
+
+		if !VerifyTaprootTweak(outputX, parity, internalX, merkleRoot) {
+			t.Errorf("%d: valid tweak failed to verify", i)
+		}
+		if VerifyTaprootTweak(outputX, parity^1, internalX, merkleRoot) {
+			t.Errorf("%d: tweak verified with the wrong parity", i)
+		}
+		if VerifyTaprootTweak(outputX, 2, internalX, merkleRoot) {
+			t.Errorf("%d: tweak verified with an invalid parity", i)
+		}
+
+		// The internal key and merkle root of the other vector must not
+		// verify.
+		other := tests[1-i]
+		_, otherPub := PrivKeyFromBytes(S256(), decodeHex(other.internalPriv))
+		otherX, _ := xOnly(otherPub)
+		if VerifyTaprootTweak(outputX, parity, otherX, merkleRoot) {
+			t.Errorf("%d: tweak verified with the wrong internal key", i)
+		}
+		otherRoot := decodeHex(other.merkleRoot)
+		if VerifyTaprootTweak(outputX, parity, internalX, otherRoot) {
+			t.Errorf("%d: tweak verified with the wrong merkle root", i)
+		}
+	}
+}
+
+// TestNUMSPoint ensures the NUMS point matches the one specified by BIP341 and
+// that tagged NUMS points are valid, deterministic, and distinct per tag.
+func TestNUMSPoint(t *testing.T) {
+	curve := S256()
+	h := NUMSPoint()
+	want := "0250929b74c1a04954b78b4b6035e97a5e078a5a0f28ec96d547bfee9ace803ac0"
+	if got := hex.EncodeToString(h.SerializeCompressed()); got != want {
+		t.Fatalf("got NUMS point %s, want %s", got, want)
+	}
+	if !curve.IsOnCurve(h.X, h.Y) {
+		t.Fatal("NUMS point is not on the curve")
+	}
+
+	// BIP341 derives the x coordinate from the uncompressed base point.
+	g := PublicKey{Curve: curve, X: curve.Gx, Y: curve.Gy}
+	x := sha256.Sum256(g.SerializeUncompressed())
+	if !bytes.Equal(x[:], h.SerializeCompressed()[1:]) {
+		t.Fatal("NUMS point x coordinate is not the hash of G")
+	}
+
+	p1 := NUMSPointFromTag("example/nums")
+	p2 := NUMSPointFromTag("example/nums")
+	p3 := NUMSPointFromTag("other/nums")
+	if !curve.IsOnCurve(p1.X, p1.Y) || !curve.IsOnCurve(p3.X, p3.Y) {
+		t.Fatal("tagged NUMS point is not on the curve")
+	}
+	if !p1.IsEqual(p2) {
+		t.Fatal("tagged NUMS point is not deterministic")
+	}
+	if p1.IsEqual(p3) {
+		t.Fatal("different tags produced the same NUMS point")
+	}
+}
+
+// TestExpandXOnly ensures x-only keys are expanded to the points with the y
+// coordinates of the requested parity and that invalid x coordinates are
+// rejected.
+func TestExpandXOnly(t *testing.T) {
+	curve := S256()
+	privKey, _ := PrivKeyFromBytes(curve, decodeHex("0123456789abcdef0123"+
+		"456789abcdef0123456789abcdef0123456789abcdef"))
+	for _, pubKey := range []*PublicKey{privKey.PubKey(), NUMSPoint(),
+		{Curve: curve, X: curve.Gx, Y: curve.Gy}} {
+
+		var x [32]byte
+		bigIntToBytes32(pubKey.X, &x)
+		negY := new(big.Int).Sub(curve.P, pubKey.Y)
+		for _, parityOdd := range []bool{false, true} {
+			got, err := ExpandXOnly(x, parityOdd)
+			if err != nil {
+				t.Fatalf("x %x: unexpected error: %v", x, err)
+			}
+
+			// The key itself has one of the two parities and its
+			// negation the other.
+			want := pubKey.Y
+			if isOdd(want) != parityOdd {
+				want = negY
+			}
+			if got.X.Cmp(pubKey.X) != 0 || got.Y.Cmp(want) != 0 {
+				t.Fatalf("x %x, odd %v: got (%x, %x), want y %x", x,
+					parityOdd, got.X, got.Y, want)
+			}
+			if isOdd(got.Y) != parityOdd {
+				t.Fatalf("x %x: got y with wrong parity", x)
+			}
+		}
+	}
+
+	var notOnCurve, prime [32]byte
+	notOnCurve[31] = 0x05
+	bigIntToBytes32(curve.P, &prime)
+	for _, x := range [][32]byte{notOnCurve, prime} {
+		for _, parityOdd := range []bool{false, true} {
+			if _, err := ExpandXOnly(x, parityOdd); err == nil {
+				t.Errorf("x %x: expected error", x)
+			}
+		}
+	}
+}
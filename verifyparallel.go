@@ -0,0 +1,62 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"runtime"
+	"sync"
+)
+
+// VerifyItem houses a signature along with the hash and public key it is to be
+// verified against.
+type VerifyItem struct {
+	PubKey *PublicKey
+	Hash   []byte
+	Sig    *Signature
+}
+
+// VerifyParallel verifies each of the passed items independently and returns
+// whether or not each of the signatures is valid.  The result at each index
+// corresponds to the item at the same index.  Items with a nil public key or
+// signature are invalid.
+//
+// The verifications are distributed among the passed number of goroutines,
+// which defaults to the number of CPUs when it is not positive.  Unlike batch
+// verification, this requires no extra randomness and identifies exactly which
+// signatures are invalid, while still making use of multiple cores.
+//
+// The workers do not keep any reusable verification state since the package
+// has no such context to reuse.  Each verification is performed with
+// Signature.Verify, which keeps its field arithmetic on the stack and only
+// allocates a few small big integers for the scalars.
+func VerifyParallel(items []VerifyItem, workers int) []bool {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(items) {
+		workers = len(items)
+	}
+
+	// Split the items among the workers in an interleaved fashion so the
+	// work is evenly distributed.  Each worker writes to distinct indices
+	// of the results, so no further synchronization is needed.
+	valid := make([]bool, len(items))
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(items); i += workers {
+				item := &items[i]
+				if item.PubKey == nil || item.Sig == nil {
+					continue
+				}
+				valid[i] = item.Sig.Verify(item.Hash, item.PubKey)
+			}
+		}(w)
+	}
+	wg.Wait()
+	return valid
+}
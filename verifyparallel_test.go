@@ -0,0 +1,61 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"testing"
+)
+
+// TestVerifyParallel ensures verifying signatures in parallel produces the
+// same results, in the same order, as verifying them sequentially for various
+// numbers of workers.
+func TestVerifyParallel(t *testing.T) {
+	var items []VerifyItem
+	for i := 0; i < 40; i++ {
+		privKey, _ := PrivKeyFromBytes(S256(), []byte{byte(i + 1)})
+		hash := sha256.Sum256([]byte{byte(i)})
+		sig, err := privKey.Sign(hash[:])
+		if err != nil {
+			t.Fatalf("#%d: failed to sign: %v", i, err)
+		}
+		item := VerifyItem{PubKey: privKey.PubKey(), Hash: hash[:], Sig: sig}
+
+		// Invalidate some of the items in various ways.
+		switch i % 5 {
+		case 1:
+			item.Hash = []byte{0x01}
+		case 2:
+			item.Sig = &Signature{R: sig.R, S: new(big.Int).Add(sig.S, one)}
+		case 3:
+			item.PubKey = nil
+		}
+		items = append(items, item)
+	}
+
+	want := make([]bool, len(items))
+	for i, item := range items {
+		want[i] = item.PubKey != nil && item.Sig.Verify(item.Hash, item.PubKey)
+	}
+
+	for _, workers := range []int{-1, 0, 1, 3, 8, 100} {
+		got := VerifyParallel(items, workers)
+		if len(got) != len(want) {
+			t.Fatalf("workers %d: got %d results, want %d", workers,
+				len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("workers %d: item %d: got %v, want %v",
+					workers, i, got[i], want[i])
+			}
+		}
+	}
+
+	if got := VerifyParallel(nil, 4); len(got) != 0 {
+		t.Fatalf("got %d results for no items", len(got))
+	}
+}
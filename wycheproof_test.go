@@ -0,0 +1,217 @@
+// Copyright (c) 2019 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// The test vectors in the testdata directory are from Project Wycheproof
+// (https://github.com/C2SP/wycheproof).  Each vector is expected to either be
+// valid, invalid, or acceptable, where acceptable vectors are legal inputs
+// that implementations are allowed to reject.
+
+var (
+	// oidPublicKeyECDSA is the ASN.1 object identifier of the
+	// id-ecPublicKey algorithm.
+	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+
+	// oidNamedCurveS256 is the ASN.1 object identifier of the secp256k1
+	// named curve.
+	oidNamedCurveS256 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+// wycheproofTestFile describes the parts of a Wycheproof test vector file that
+// are common to all of the vector types.
+type wycheproofTestFile struct {
+	NumberOfTests int             `json:"numberOfTests"`
+	TestGroups    json.RawMessage `json:"testGroups"`
+}
+
+// wycheproofTest describes the fields that are common to all Wycheproof test
+// vectors.
+type wycheproofTest struct {
+	TcID    int      `json:"tcId"`
+	Comment string   `json:"comment"`
+	Flags   []string `json:"flags"`
+	Result  string   `json:"result"`
+}
+
+// loadWycheproofVectors loads the named Wycheproof test vector file from the
+// testdata directory and decodes its test groups into groups.  It returns the
+// number of tests the file claims to contain.
+func loadWycheproofVectors(t *testing.T, name string, groups interface{}) int {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read test vectors: %v", err)
+	}
+	var file wycheproofTestFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("failed to decode test vectors: %v", err)
+	}
+	if err := json.Unmarshal(file.TestGroups, groups); err != nil {
+		t.Fatalf("failed to decode test groups: %v", err)
+	}
+	return file.NumberOfTests
+}
+
+// checkWycheproofResult reports an error when the passed outcome of a test
+// vector does not match its expected result.
+func checkWycheproofResult(t *testing.T, test *wycheproofTest, ok bool) {
+	t.Helper()
+
+	switch {
+	case test.Result == "valid" && !ok:
+		t.Errorf("tcId %d (%s, %v): valid vector was rejected",
+			test.TcID, test.Comment, test.Flags)
+	case test.Result == "invalid" && ok:
+		t.Errorf("tcId %d (%s, %v): invalid vector was accepted",
+			test.TcID, test.Comment, test.Flags)
+	case test.Result != "valid" && test.Result != "invalid" &&
+		test.Result != "acceptable":
+
+		t.Errorf("tcId %d: unknown result %q", test.TcID, test.Result)
+	}
+}
+
+// parseWycheproofPubKey parses a DER encoded X.509 SubjectPublicKeyInfo that
+// holds a secp256k1 public key.
+func parseWycheproofPubKey(der []byte) (*PublicKey, error) {
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	rest, err := asn1.Unmarshal(der, &spki)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after public key")
+	}
+	if !spki.Algorithm.Algorithm.Equal(oidPublicKeyECDSA) {
+		return nil, errors.New("public key is not an EC public key")
+	}
+
+	var curveOID asn1.ObjectIdentifier
+	rest, err = asn1.Unmarshal(spki.Algorithm.Parameters.FullBytes, &curveOID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 || !curveOID.Equal(oidNamedCurveS256) {
+		return nil, errors.New("public key is not on the secp256k1 curve")
+	}
+	if spki.PublicKey.BitLength%8 != 0 {
+		return nil, errors.New("public key has a partial byte")
+	}
+	return ParsePubKey(spki.PublicKey.Bytes, S256())
+}
+
+// TestWycheproofECDSA ensures DER signature parsing and verification agree
+// with the expected results of the Wycheproof ECDSA test vectors.
+func TestWycheproofECDSA(t *testing.T) {
+	type ecdsaTest struct {
+		wycheproofTest
+		Msg string `json:"msg"`
+		Sig string `json:"sig"`
+	}
+	var groups []struct {
+		PublicKeyDER string       `json:"publicKeyDer"`
+		SHA          string       `json:"sha"`
+		Tests        []*ecdsaTest `json:"tests"`
+	}
+	want := loadWycheproofVectors(t, "ecdsa_secp256k1_sha256_test.json",
+		&groups)
+
+	var numTests int
+	for _, group := range groups {
+		if group.SHA != "SHA-256" {
+			t.Fatalf("unexpected hash function %q", group.SHA)
+		}
+		pubKey, err := parseWycheproofPubKey(decodeHex(group.PublicKeyDER))
+		if err != nil {
+			t.Fatalf("failed to parse public key: %v", err)
+		}
+
+		for _, test := range group.Tests {
+			numTests++
+			msg, err := hex.DecodeString(test.Msg)
+			if err != nil {
+				t.Fatalf("tcId %d: bad message: %v", test.TcID, err)
+			}
+			hash := sha256.Sum256(msg)
+
+			// ParseDERSignature intentionally permits data after
+			// the signature since such signatures exist in the
+			// blockchain, so reject it here to match the strict
+			// DER encoding the vectors expect.
+			var ok bool
+			sigBytes := decodeHex(test.Sig)
+			sig, err := ParseDERSignature(sigBytes, S256())
+			if err == nil && int(sigBytes[1])+2 == len(sigBytes) {
+				ok = sig.Verify(hash[:], pubKey)
+			}
+			checkWycheproofResult(t, &test.wycheproofTest, ok)
+		}
+	}
+	if numTests != want {
+		t.Fatalf("ran %d tests, want %d", numTests, want)
+	}
+}
+
+// TestWycheproofECDH ensures public key parsing and shared secret generation
+// agree with the expected results of the Wycheproof ECDH test vectors.
+func TestWycheproofECDH(t *testing.T) {
+	type ecdhTest struct {
+		wycheproofTest
+		Public  string `json:"public"`
+		Private string `json:"private"`
+		Shared  string `json:"shared"`
+	}
+	var groups []struct {
+		Curve string      `json:"curve"`
+		Tests []*ecdhTest `json:"tests"`
+	}
+	want := loadWycheproofVectors(t, "ecdh_secp256k1_test.json", &groups)
+
+	var numTests int
+	for _, group := range groups {
+		if group.Curve != "secp256k1" {
+			t.Fatalf("unexpected curve %q", group.Curve)
+		}
+
+		for _, test := range group.Tests {
+			numTests++
+			privKey, _ := PrivKeyFromBytes(S256(), decodeHex(test.Private))
+			shared := decodeHex(test.Shared)
+
+			var ok bool
+			pubKey, err := parseWycheproofPubKey(decodeHex(test.Public))
+			if err == nil {
+				secret := GenerateSharedSecretPadded(privKey, pubKey)
+				ok = bytes.Equal(secret, shared)
+				if !ok && test.Result != "invalid" {
+					t.Errorf("tcId %d (%s): mismatched shared "+
+						"secret - got %x, want %x", test.TcID,
+						test.Comment, secret, shared)
+				}
+			}
+			checkWycheproofResult(t, &test.wycheproofTest, ok)
+		}
+	}
+	if numTests != want {
+		t.Fatalf("ran %d tests, want %d", numTests, want)
+	}
+}